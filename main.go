@@ -1,10 +1,13 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"os"
 	"os/user"
+	"path/filepath"
 
+	"github.com/grantwforsythe/monkeylang/pkg/prelude"
 	"github.com/grantwforsythe/monkeylang/pkg/repl"
 )
 
@@ -22,6 +25,33 @@ const MONKEY_FACE = `            __,__
 `
 
 func main() {
+	var files []prelude.File
+	flag.Func("prelude", "evaluate a Monkey `file` after the standard prelude (can be repeated)", func(name string) error {
+		source, err := os.ReadFile(name)
+		if err != nil {
+			return err
+		}
+
+		files = append(files, prelude.File{Name: filepath.Base(name), Source: string(source)})
+		return nil
+	})
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [-prelude file]... [program]\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	// Run a program instead of starting the REPL when one is given
+	if flag.NArg() > 0 {
+		source, err := os.ReadFile(flag.Arg(0))
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+
+		os.Exit(repl.Run(string(source), os.Stdin, os.Stdout, files...))
+	}
+
 	user, err := user.Current()
 	if err != nil {
 		panic(err)
@@ -32,5 +62,5 @@ func main() {
 	fmt.Printf("Feel free to type in commands.\n")
 	fmt.Printf("Call `quit()` to quit.\n")
 
-	repl.Start(os.Stdin, os.Stdout)
+	os.Exit(repl.Start(os.Stdin, os.Stdout, files...))
 }
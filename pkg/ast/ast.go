@@ -3,6 +3,7 @@ package ast
 
 import (
 	"bytes"
+	"fmt"
 	"strings"
 
 	"github.com/grantwforsythe/monkeylang/pkg/token"
@@ -83,6 +84,31 @@ func (ls *LetStatement) String() string {
 	return out.String()
 }
 
+// ImportStatement binds the module at a path to a name, e.g. `import "util.mk" as util;`.
+type ImportStatement struct {
+	Token token.Token    // The IMPORT token
+	Path  *StringLiteral // The path of the module
+	Name  *Identifier    // The name the module is bound to
+}
+
+func (is *ImportStatement) statementNode()       {}
+func (is *ImportStatement) TokenLiteral() string { return is.Token.Literal }
+func (is *ImportStatement) String() string {
+	return fmt.Sprintf("%s %q as %s;", is.TokenLiteral(), is.Path.Value, is.Name.String())
+}
+
+// ExportStatement makes a binding of a module available to the programs that import it, e.g. `export let x = 1;`.
+type ExportStatement struct {
+	Token     token.Token // The EXPORT token
+	Statement *LetStatement
+}
+
+func (es *ExportStatement) statementNode()       {}
+func (es *ExportStatement) TokenLiteral() string { return es.Token.Literal }
+func (es *ExportStatement) String() string {
+	return es.TokenLiteral() + " " + es.Statement.String()
+}
+
 type ReturnStatement struct {
 	Token       token.Token // The RETURN token
 	ReturnValue Expression
@@ -128,6 +154,15 @@ func (il *IntegerLiteral) expressionNode()      {}
 func (il *IntegerLiteral) TokenLiteral() string { return il.Token.Literal }
 func (il *IntegerLiteral) String() string       { return il.Token.Literal }
 
+type FloatLiteral struct {
+	Token token.Token
+	Value float64
+}
+
+func (fl *FloatLiteral) expressionNode()      {}
+func (fl *FloatLiteral) TokenLiteral() string { return fl.Token.Literal }
+func (fl *FloatLiteral) String() string       { return fl.Token.Literal }
+
 type PrefixExpression struct {
 	Token    token.Token
 	Operator string
@@ -219,10 +254,44 @@ func (ie *IfExpression) String() string {
 	return out.String()
 }
 
+type WhileExpression struct {
+	Token     token.Token // the 'while' token
+	Condition Expression
+	Body      *BlockStatement
+}
+
+func (we *WhileExpression) expressionNode()      {}
+func (we *WhileExpression) TokenLiteral() string { return we.Token.Literal }
+func (we *WhileExpression) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("while")
+	out.WriteString(we.Condition.String())
+	out.WriteString(" ")
+	out.WriteString(we.Body.String())
+
+	return out.String()
+}
+
+// AssignExpression changes the value of an existing binding, e.g. `x = x + 1`.
+// It evaluates to the assigned value.
+type AssignExpression struct {
+	Token token.Token // the '=' token
+	Name  *Identifier
+	Value Expression
+}
+
+func (ae *AssignExpression) expressionNode()      {}
+func (ae *AssignExpression) TokenLiteral() string { return ae.Token.Literal }
+func (ae *AssignExpression) String() string {
+	return "(" + ae.Name.String() + " = " + ae.Value.String() + ")"
+}
+
 type FunctionLiteral struct {
 	Token      token.Token // the 'fn' token
 	Parameters []*Identifier
 	Body       *BlockStatement
+	Name       string // Name is the identifier the function is bound to by a let statement, if any, used in errors.
 }
 
 func (fl *FunctionLiteral) expressionNode()      {}
@@ -277,6 +346,32 @@ func (sl *StringLiteral) expressionNode()      {}
 func (sl *StringLiteral) TokenLiteral() string { return sl.Token.Literal }
 func (sl *StringLiteral) String() string       { return sl.Token.Literal }
 
+// TemplateLiteral is a string literal that interpolates expressions, e.g. "Hello ${name}!".
+// It evaluates to the concatenation of its parts.
+type TemplateLiteral struct {
+	Token token.Token  // The token.TEMPLATE token
+	Parts []Expression // String literals for the text between expressions and the interpolated expressions
+}
+
+func (tl *TemplateLiteral) expressionNode()      {}
+func (tl *TemplateLiteral) TokenLiteral() string { return tl.Token.Literal }
+func (tl *TemplateLiteral) String() string {
+	var out bytes.Buffer
+
+	for _, part := range tl.Parts {
+		if str, ok := part.(*StringLiteral); ok {
+			out.WriteString(strings.ReplaceAll(str.Value, "${", "$${"))
+			continue
+		}
+
+		out.WriteString("${")
+		out.WriteString(part.String())
+		out.WriteString("}")
+	}
+
+	return out.String()
+}
+
 type ArrayLiteral struct {
 	Token    token.Token // The '[' token
 	Elements []Expression
@@ -321,10 +416,38 @@ func (ie *IndexEpression) String() string {
 	return out.String()
 }
 
+// Access a member of a namespace or hash by name.
+// <expression>.<identifier>
+type MemberExpression struct {
+	Token    token.Token // The '.' token
+	Left     Expression
+	Property *Identifier
+}
+
+func (me *MemberExpression) expressionNode()      {}
+func (me *MemberExpression) TokenLiteral() string { return me.Token.Literal }
+func (me *MemberExpression) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("(")
+	out.WriteString(me.Left.String())
+	out.WriteString(".")
+	out.WriteString(me.Property.String())
+	out.WriteString(")")
+
+	return out.String()
+}
+
+// A key-value pair in a hash literal
+type HashPair struct {
+	Key   Expression
+	Value Expression
+}
+
 // A struct representing a hashmap
 type HashLiteral struct {
 	Token token.Token // The '{' token
-	Pairs map[Expression]Expression
+	Pairs []HashPair  // The pairs in the order they appear in the source
 }
 
 func (hl *HashLiteral) expressionNode()      {}
@@ -333,8 +456,8 @@ func (hl *HashLiteral) String() string {
 	var out bytes.Buffer
 
 	pairs := []string{}
-	for key, value := range hl.Pairs {
-		pairs = append(pairs, key.String()+":"+value.String())
+	for _, pair := range hl.Pairs {
+		pairs = append(pairs, pair.Key.String()+":"+pair.Value.String())
 	}
 
 	out.WriteString("{")
@@ -366,3 +366,45 @@ func TestStringLiteralExpression(t *testing.T) {
 		t.Errorf("exp.String() wrong. got=%s", exp.String())
 	}
 }
+
+func TestTemplateLiteralExpression(t *testing.T) {
+	exp := &TemplateLiteral{
+		Token: token.Token{Type: token.TEMPLATE, Literal: "Hello ${name}!"},
+		Parts: []Expression{
+			&StringLiteral{Value: "Hello "},
+			&Identifier{Token: token.Token{Type: token.IDENT, Literal: "name"}, Value: "name"},
+			&StringLiteral{Value: "!"},
+		},
+	}
+
+	if exp.TokenLiteral() != "Hello ${name}!" {
+		t.Errorf("exp.TokenLiteral() wrong. got=%s", exp.TokenLiteral())
+	}
+
+	if exp.String() != "Hello ${name}!" {
+		t.Errorf("exp.String() wrong. got=%s", exp.String())
+	}
+}
+
+func TestWhileAndAssignExpressions(t *testing.T) {
+	x := &Identifier{Token: token.Token{Type: token.IDENT, Literal: "x"}, Value: "x"}
+	exp := &WhileExpression{
+		Token:     token.Token{Type: token.WHILE, Literal: "while"},
+		Condition: x,
+		Body: &BlockStatement{
+			Statements: []Statement{
+				&ExpressionStatement{
+					Expression: &AssignExpression{
+						Token: token.Token{Type: token.ASSIGN, Literal: "="},
+						Name:  x,
+						Value: &IntegerLiteral{Token: token.Token{Type: token.INT, Literal: "1"}, Value: 1},
+					},
+				},
+			},
+		},
+	}
+
+	if exp.String() != "whilex (x = 1)" {
+		t.Errorf("exp.String() wrong. got=%s", exp.String())
+	}
+}
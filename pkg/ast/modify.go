@@ -33,6 +33,9 @@ func Modify(node Node, modifier ModifierFunc) Node {
 		node.Left, _ = Modify(node.Left, modifier).(Expression)
 		node.Index, _ = Modify(node.Index, modifier).(Expression)
 
+	case *MemberExpression:
+		node.Left, _ = Modify(node.Left, modifier).(Expression)
+
 	case *IfExpression:
 		node.Condition, _ = Modify(node.Condition, modifier).(Expression)
 		node.Consequence, _ = Modify(node.Consequence, modifier).(*BlockStatement)
@@ -40,6 +43,13 @@ func Modify(node Node, modifier ModifierFunc) Node {
 			node.Alternative, _ = Modify(node.Alternative, modifier).(*BlockStatement)
 		}
 
+	case *WhileExpression:
+		node.Condition, _ = Modify(node.Condition, modifier).(Expression)
+		node.Body, _ = Modify(node.Body, modifier).(*BlockStatement)
+
+	case *AssignExpression:
+		node.Value, _ = Modify(node.Value, modifier).(Expression)
+
 	case *BlockStatement:
 		for i, statement := range node.Statements {
 			node.Statements[i], _ = Modify(statement, modifier).(Statement)
@@ -51,6 +61,9 @@ func Modify(node Node, modifier ModifierFunc) Node {
 	case *LetStatement:
 		node.Value, _ = Modify(node.Value, modifier).(Expression)
 
+	case *ExportStatement:
+		node.Statement, _ = Modify(node.Statement, modifier).(*LetStatement)
+
 	case *FunctionLiteral:
 		if node.Parameters != nil {
 			for i, parameter := range node.Parameters {
@@ -65,16 +78,15 @@ func Modify(node Node, modifier ModifierFunc) Node {
 		}
 
 	case *HashLiteral:
-		modifiedPairs := make(map[Expression]Expression)
-
-		for key, value := range node.Pairs {
-			modifiedKey, _ := Modify(key, modifier).(Expression)
-			modifiedValue, _ := Modify(value, modifier).(Expression)
-
-			modifiedPairs[modifiedKey] = modifiedValue
+		for i, pair := range node.Pairs {
+			node.Pairs[i].Key, _ = Modify(pair.Key, modifier).(Expression)
+			node.Pairs[i].Value, _ = Modify(pair.Value, modifier).(Expression)
 		}
 
-		node.Pairs = modifiedPairs
+	case *TemplateLiteral:
+		for i, part := range node.Parts {
+			node.Parts[i], _ = Modify(part, modifier).(Expression)
+		}
 	}
 
 	return modifier(node)
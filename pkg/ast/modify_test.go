@@ -106,6 +106,24 @@ func TestModify(t *testing.T) {
 			&ArrayLiteral{Elements: []Expression{one(), two()}},
 			&ArrayLiteral{Elements: []Expression{two(), two()}},
 		},
+		{
+			&TemplateLiteral{Parts: []Expression{&StringLiteral{Value: "x"}, one()}},
+			&TemplateLiteral{Parts: []Expression{&StringLiteral{Value: "x"}, two()}},
+		},
+		{
+			&WhileExpression{
+				Condition: one(),
+				Body:      &BlockStatement{Statements: []Statement{&ExpressionStatement{Expression: one()}}},
+			},
+			&WhileExpression{
+				Condition: two(),
+				Body:      &BlockStatement{Statements: []Statement{&ExpressionStatement{Expression: two()}}},
+			},
+		},
+		{
+			&AssignExpression{Name: &Identifier{Value: "x"}, Value: one()},
+			&AssignExpression{Name: &Identifier{Value: "x"}, Value: two()},
+		},
 	}
 
 	for _, test := range tests {
@@ -117,20 +135,20 @@ func TestModify(t *testing.T) {
 	}
 
 	hashLiteral := &HashLiteral{
-		Pairs: map[Expression]Expression{
-			one(): one(),
-			one(): one(),
+		Pairs: []HashPair{
+			{Key: one(), Value: one()},
+			{Key: one(), Value: one()},
 		},
 	}
 
 	Modify(hashLiteral, turnOneIntoTwo)
 
-	for key, val := range hashLiteral.Pairs {
-		key, _ := key.(*IntegerLiteral)
+	for _, pair := range hashLiteral.Pairs {
+		key, _ := pair.Key.(*IntegerLiteral)
 		if key.Value != 2 {
 			t.Errorf("value is not %d, got=%d", 2, key.Value)
 		}
-		val, _ := val.(*IntegerLiteral)
+		val, _ := pair.Value.(*IntegerLiteral)
 		if val.Value != 2 {
 			t.Errorf("value is not %d, got=%d", 2, val.Value)
 		}
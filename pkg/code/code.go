@@ -64,12 +64,39 @@ type Opcode byte
 
 // We let iota generate the byte values because the actual values do not matter.
 const (
-	OpConstant Opcode = iota // OpConstant retrives the constant using the operand as an index and pushes it onto the stack.
-	OpAdd                    // OpAdd pops two objects off the stack, adds them together, and adds the result on the stack.
-	OpPop                    // OpPop pops the top most element off the stack
-	OpSub                    // OpSub pops two objects off the stack, subtracts them, and pushes the result onto the stack.
-	OpDiv                    // OpDiv pops two objects off the stack, divdes them, and pushes the result onto the stack.
-	OpMul                    // OpMul pops two objects off the stack, multiples them, and pushes the result onto the stack.
+	OpConstant      Opcode = iota // OpConstant retrives the constant using the operand as an index and pushes it onto the stack.
+	OpAdd                         // OpAdd pops two objects off the stack, adds them together, and adds the result on the stack.
+	OpPop                         // OpPop pops the top most element off the stack
+	OpSub                         // OpSub pops two objects off the stack, subtracts them, and pushes the result onto the stack.
+	OpDiv                         // OpDiv pops two objects off the stack, divdes them, and pushes the result onto the stack.
+	OpMul                         // OpMul pops two objects off the stack, multiples them, and pushes the result onto the stack.
+	OpTrue                        // OpTrue pushes true onto the stack.
+	OpFalse                       // OpFalse pushes false onto the stack.
+	OpNull                        // OpNull pushes null onto the stack.
+	OpEqual                       // OpEqual pops two objects off the stack and pushes whether they are equal.
+	OpNotEqual                    // OpNotEqual pops two objects off the stack and pushes whether they are not equal.
+	OpGreaterThan                 // OpGreaterThan pops two objects off the stack and pushes whether the first is greater. Less than is compiled by swapping the operands.
+	OpMinus                       // OpMinus pops an object off the stack and pushes its negation.
+	OpBang                        // OpBang pops an object off the stack and pushes its boolean inverse.
+	OpJumpNotTruthy               // OpJumpNotTruthy pops an object off the stack and jumps to the operand if it is not truthy.
+	OpJump                        // OpJump jumps to the operand.
+	OpGetGlobal                   // OpGetGlobal pushes the global binding at the operand onto the stack.
+	OpSetGlobal                   // OpSetGlobal pops an object off the stack and stores it in the global binding at the operand.
+	OpArray                       // OpArray pops the number of elements in the operand off the stack and pushes an array of them.
+	OpHash                        // OpHash pops the number of keys and values in the operand off the stack and pushes a hash of them.
+	OpIndex                       // OpIndex pops an index and an object off the stack and pushes the element at the index.
+	OpCall                        // OpCall calls the function below the number of arguments in the operand on the stack.
+	OpGetBuiltin                  // OpGetBuiltin pushes the builtin at the operand onto the stack.
+	OpConcat                      // OpConcat pops the number of objects in the operand off the stack and pushes the concatenation of their string representations.
+	OpReturnValue                 // OpReturnValue pops an object off the stack and returns it from the current function.
+	OpReturn                      // OpReturn returns null from the current function.
+	OpGetLocal                    // OpGetLocal pushes the local binding at the operand onto the stack.
+	OpSetLocal                    // OpSetLocal pops an object off the stack and stores it in the local binding at the operand.
+	OpModule                      // OpModule pops the number of export names and values in the second operand off the stack and pushes a module with the path of the constant at the first operand.
+	OpClosure                     // OpClosure pushes a closure of the function constant at the operand, capturing the free variables the function describes.
+	OpGetFree                     // OpGetFree pushes the free variable at the operand of the current closure onto the stack.
+	OpSetFree                     // OpSetFree pops an object off the stack and stores it in the free variable at the operand of the current closure.
+	OpTailCall                    // OpTailCall calls a function like OpCall, replacing the frame of the current function whose result is the result of the call.
 )
 
 // Definition represents the definition for an Opcode.
@@ -85,6 +112,34 @@ var definitions = map[Opcode]*Definition{
 	OpSub:      {"OpSub", make([]int, 0)},
 	OpDiv:      {"OpDiv", make([]int, 0)},
 	OpMul:      {"OpMul", make([]int, 0)},
+
+	OpTrue:          {"OpTrue", make([]int, 0)},
+	OpFalse:         {"OpFalse", make([]int, 0)},
+	OpNull:          {"OpNull", make([]int, 0)},
+	OpEqual:         {"OpEqual", make([]int, 0)},
+	OpNotEqual:      {"OpNotEqual", make([]int, 0)},
+	OpGreaterThan:   {"OpGreaterThan", make([]int, 0)},
+	OpMinus:         {"OpMinus", make([]int, 0)},
+	OpBang:          {"OpBang", make([]int, 0)},
+	OpJumpNotTruthy: {"OpJumpNotTruthy", []int{2}},
+	OpJump:          {"OpJump", []int{2}},
+	OpGetGlobal:     {"OpGetGlobal", []int{2}},
+	OpSetGlobal:     {"OpSetGlobal", []int{2}},
+	OpArray:         {"OpArray", []int{2}},
+	OpHash:          {"OpHash", []int{2}},
+	OpIndex:         {"OpIndex", make([]int, 0)},
+	OpCall:          {"OpCall", []int{1}},
+	OpGetBuiltin:    {"OpGetBuiltin", []int{2}},
+	OpConcat:        {"OpConcat", []int{2}},
+	OpReturnValue:   {"OpReturnValue", make([]int, 0)},
+	OpReturn:        {"OpReturn", make([]int, 0)},
+	OpGetLocal:      {"OpGetLocal", []int{1}},
+	OpSetLocal:      {"OpSetLocal", []int{1}},
+	OpModule:        {"OpModule", []int{2, 2}},
+	OpClosure:       {"OpClosure", []int{2}},
+	OpGetFree:       {"OpGetFree", []int{1}},
+	OpSetFree:       {"OpSetFree", []int{1}},
+	OpTailCall:      {"OpTailCall", []int{1}},
 }
 
 // Lookup gets the Opcode definition for a given byte.
@@ -118,6 +173,8 @@ func Make(op Opcode, operands ...int) Instructions {
 	// Iterate over the defined OperandWidths, take the matching element from the given operands and put it into the instruction.
 	for i, operand := range operands {
 		switch definition.OperandWidths[i] {
+		case 1:
+			instruction[offset] = byte(operand)
 		case 2:
 			binary.BigEndian.PutUint16(instruction[offset:], uint16(operand))
 		}
@@ -127,6 +184,24 @@ func Make(op Opcode, operands ...int) Instructions {
 	return instruction
 }
 
+// CheckOperands checks that the operands of an instruction fit in the operand widths of its opcode, since Make
+// silently truncates the operands that do not.
+func CheckOperands(op Opcode, operands ...int) error {
+	definition, err := Lookup(byte(op))
+	if err != nil {
+		return err
+	}
+
+	for i, operand := range operands {
+		limit := 1<<(8*definition.OperandWidths[i]) - 1
+		if operand < 0 || operand > limit {
+			return fmt.Errorf("operand %d of %s is out of range. got=%d, max=%d", i+1, definition.Name, operand, limit)
+		}
+	}
+
+	return nil
+}
+
 // ReadOperands is the opposite of Make - converts a definition and instruction to respective opcode and operands.
 // Returns the operands for the instruction and the offset which represents the index of the last operand in the instruction.
 func ReadOperands(definition *Definition, instruction Instructions) ([]int, int) {
@@ -135,6 +210,8 @@ func ReadOperands(definition *Definition, instruction Instructions) ([]int, int)
 
 	for i, width := range definition.OperandWidths {
 		switch width {
+		case 1:
+			operands[i] = int(ReadUint8(instruction[offset:]))
 		case 2:
 			operands[i] = int(ReadUint16(instruction[offset:]))
 		}
@@ -150,3 +227,8 @@ func ReadOperands(definition *Definition, instruction Instructions) ([]int, int)
 func ReadUint16(instruction Instructions) uint16 {
 	return binary.BigEndian.Uint16(instruction)
 }
+
+// ReadUint8 reads a single byte operand from an instruction.
+func ReadUint8(instruction Instructions) uint8 {
+	return uint8(instruction[0])
+}
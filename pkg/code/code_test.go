@@ -17,6 +17,9 @@ func TestMake(t *testing.T) {
 		{OpSub, []int{}, []byte{byte(OpSub)}},
 		{OpDiv, []int{}, []byte{byte(OpDiv)}},
 		{OpMul, []int{}, []byte{byte(OpMul)}},
+		{OpJump, []int{65534}, []byte{byte(OpJump), 255, 254}},
+		// OpCall's operand is one byte wide meaning 255 is the highest value that can be represented.
+		{OpCall, []int{255}, []byte{byte(OpCall), 255}},
 	}
 
 	for _, test := range tests {
@@ -56,6 +59,12 @@ func TestInstructionsString(t *testing.T) {
 				Make(OpConstant, 2),
 				Make(OpConstant, 65534),
 			}, "0000 OpAdd\n0001 OpConstant 2\n0004 OpConstant 65534"},
+		{
+			[]Instructions{
+				Make(OpGetBuiltin, 1),
+				Make(OpConstant, 2),
+				Make(OpCall, 1),
+			}, "0000 OpGetBuiltin 1\n0003 OpConstant 2\n0006 OpCall 1"},
 	}
 
 	for _, test := range tests {
@@ -86,6 +95,15 @@ func TestReadOperands(t *testing.T) {
 		{OpMul, []int{}, 0},
 		{OpDiv, []int{}, 0},
 		{OpSub, []int{}, 0},
+		{OpGetGlobal, []int{65535}, 2},
+		{OpGetBuiltin, []int{65535}, 2},
+		{OpConcat, []int{65535}, 2},
+		{OpModule, []int{65535, 2}, 4},
+		{OpGetLocal, []int{255}, 1},
+		{OpSetLocal, []int{255}, 1},
+		{OpClosure, []int{65535}, 2},
+		{OpGetFree, []int{255}, 1},
+		{OpTailCall, []int{255}, 1},
 	}
 
 	for _, test := range tests {
@@ -108,3 +126,31 @@ func TestReadOperands(t *testing.T) {
 		}
 	}
 }
+
+func TestCheckOperands(t *testing.T) {
+	tests := []struct {
+		op       Opcode
+		operands []int
+		expected string
+	}{
+		{OpConstant, []int{65535}, ""},
+		{OpConstant, []int{65536}, "operand 1 of OpConstant is out of range. got=65536, max=65535"},
+		{OpCall, []int{255}, ""},
+		{OpCall, []int{256}, "operand 1 of OpCall is out of range. got=256, max=255"},
+		{OpModule, []int{1, -1}, "operand 2 of OpModule is out of range. got=-1, max=65535"},
+		{OpAdd, []int{}, ""},
+	}
+
+	for _, test := range tests {
+		err := CheckOperands(test.op, test.operands...)
+
+		actual := ""
+		if err != nil {
+			actual = err.Error()
+		}
+
+		if actual != test.expected {
+			t.Errorf("wrong error. expected=%q, got=%q", test.expected, actual)
+		}
+	}
+}
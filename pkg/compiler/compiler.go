@@ -9,9 +9,39 @@ import (
 	"github.com/grantwforsythe/monkeylang/pkg/object"
 )
 
+// maxArguments is the maximum number of arguments a function can be called with.
+const maxArguments = 255
+
 type Compiler struct {
-	instructions code.Instructions
-	constants    []object.Object
+	constants []object.Object
+
+	// scopes is the stack of the instructions being compiled. The main program is at the bottom and every function
+	// literal being compiled is above the scope it is defined in.
+	scopes     []CompilationScope
+	scopeIndex int
+
+	symbolTable *SymbolTable
+
+	loader    object.ModuleLoader // loader finds the source of imported modules.
+	modules   map[string]int      // modules maps the path of every compiled module to the global it is stored in.
+	importing []string            // importing is the chain of modules being compiled, used to detect cycles.
+
+	// err is the first operand that did not fit in an instruction, which is returned once the node being compiled is
+	// done.
+	err error
+}
+
+// CompilationScope represents the instructions of the main program or of a function.
+type CompilationScope struct {
+	instructions        code.Instructions
+	lastInstruction     EmittedInstruction // lastInstruction represents the last instruction emitted.
+	previousInstruction EmittedInstruction // previousInstruction represents the instruction emitted before lastInstruction.
+}
+
+// EmittedInstruction represents an instruction that has been emitted by the compiler.
+type EmittedInstruction struct {
+	Opcode   code.Opcode // Opcode represents the opcode of the instruction.
+	Position int         // Position represents the position of the instruction in the instructions.
 }
 
 // ByteCode represents a domain-specific language for a domain-specific virtual machine.
@@ -22,10 +52,26 @@ type ByteCode struct {
 }
 
 // New initializes a new compiler.
+// Programs compiled without a module loader cannot import modules.
 func New() *Compiler {
+	return NewWithLoader(nil)
+}
+
+// NewWithLoader initializes a new compiler that compiles the modules a program imports from a loader.
+func NewWithLoader(loader object.ModuleLoader) *Compiler {
+	// constants is a global pool for all constants.
+	return NewWithState(NewGlobalSymbolTable(), []object.Object{}, loader)
+}
+
+// NewWithState initializes a new compiler that continues from the symbol table and constants of a previous compilation,
+// so the program it compiles can use the bindings of the previous one when it runs with the same globals.
+func NewWithState(symbolTable *SymbolTable, constants []object.Object, loader object.ModuleLoader) *Compiler {
 	return &Compiler{
-		instructions: code.Instructions{},
-		constants:    []object.Object{}, // constants is a global pool for all constants.
+		constants:   constants,
+		scopes:      []CompilationScope{{instructions: code.Instructions{}}},
+		symbolTable: symbolTable,
+		loader:      loader,
+		modules:     symbolTable.modules,
 	}
 }
 
@@ -33,6 +79,14 @@ func New() *Compiler {
 func (c *Compiler) Compile(node ast.Node) error {
 	switch node := node.(type) {
 	case *ast.Program:
+		// Globals are declared up front so that functions can refer to the globals defined after them, e.g. to recurse
+		// mutually, the same way they can in the evaluator. Using a global before it is defined is a runtime error.
+		for _, stmt := range node.Statements {
+			if let, ok := stmt.(*ast.LetStatement); ok && c.symbolTable.Outer == nil {
+				c.symbolTable.Declare(let.Name.Value)
+			}
+		}
+
 		for _, stmt := range node.Statements {
 			err := c.Compile(stmt)
 			if err != nil {
@@ -48,7 +102,155 @@ func (c *Compiler) Compile(node ast.Node) error {
 		// Expression statements emit a value but don't store it like an assignment statement
 		c.emit(code.OpPop)
 
+	case *ast.BlockStatement:
+		for _, stmt := range node.Statements {
+			err := c.Compile(stmt)
+			if err != nil {
+				return err
+			}
+		}
+
+	case *ast.LetStatement:
+		// A function is bound before it is compiled so that it can call itself. Other values are bound afterwards so
+		// that they can refer to a binding with the same name that they shadow, e.g. `let x = x + 1`.
+		_, isFunction := node.Value.(*ast.FunctionLiteral)
+
+		var symbol Symbol
+		if isFunction {
+			symbol = c.symbolTable.Define(node.Name.Value)
+		}
+
+		err := c.Compile(node.Value)
+		if err != nil {
+			return err
+		}
+
+		if !isFunction {
+			symbol = c.symbolTable.Define(node.Name.Value)
+		}
+		c.storeSymbol(symbol)
+
+	case *ast.ReturnStatement:
+		err := c.Compile(node.ReturnValue)
+		if err != nil {
+			return err
+		}
+
+		c.emit(code.OpReturnValue)
+
+	case *ast.FunctionLiteral:
+		c.enterScope()
+
+		params := make([]string, len(node.Parameters))
+		for i, parameter := range node.Parameters {
+			c.symbolTable.Define(parameter.Value)
+			params[i] = parameter.Value
+		}
+
+		err := c.Compile(node.Body)
+		if err != nil {
+			return err
+		}
+
+		// The value of the last expression is returned implicitly and a function without one returns null
+		if c.lastInstructionIs(code.OpPop) {
+			c.replaceLastPopWithReturn()
+		}
+		if !c.lastInstructionIs(code.OpReturnValue) {
+			c.emit(code.OpReturn)
+		}
+		c.markTailCalls()
+
+		freeSymbols := c.symbolTable.FreeSymbols
+		numLocals := c.symbolTable.numDefinitions
+		instructions := c.leaveScope()
+
+		// The free symbols were resolved in the enclosing function, so each is either one of its locals or one of the
+		// variables it captured itself
+		free := make([]object.FreeVariable, len(freeSymbols))
+		for i, symbol := range freeSymbols {
+			free[i] = object.FreeVariable{Local: symbol.Scope == LocalScope, Index: symbol.Index}
+		}
+
+		fn := &object.CompiledFunction{
+			Instructions:  instructions,
+			NumLocals:     numLocals,
+			NumParameters: len(node.Parameters),
+			Free:          free,
+			Name:          node.Name,
+			Parameters:    params,
+			Source:        object.FunctionSource(node.Parameters, node.Body),
+		}
+		c.emit(code.OpClosure, c.addConstant(fn))
+
+	case *ast.AssignExpression:
+		err := c.Compile(node.Value)
+		if err != nil {
+			return err
+		}
+
+		symbol, ok := c.symbolTable.Resolve(node.Name.Value)
+		if !ok || symbol.Scope == BuiltinScope {
+			return fmt.Errorf("cannot assign to undefined variable %s", node.Name.Value)
+		}
+
+		// An assignment evaluates to the assigned value
+		c.storeSymbol(symbol)
+		c.loadSymbol(symbol)
+
+	case *ast.ImportStatement:
+		index, err := c.compileModule(node.Path.Value)
+		if err != nil {
+			return err
+		}
+
+		c.emit(code.OpGetGlobal, index)
+		symbol := c.symbolTable.Define(node.Name.Value)
+		c.storeSymbol(symbol)
+
+	case *ast.ExportStatement:
+		return c.Compile(node.Statement)
+
+	case *ast.Identifier:
+		symbol, ok := c.symbolTable.Resolve(node.Value)
+		if !ok {
+			return fmt.Errorf("undefined variable %s", node.Value)
+		}
+
+		c.loadSymbol(symbol)
+
+	case *ast.PrefixExpression:
+		err := c.Compile(node.Right)
+		if err != nil {
+			return err
+		}
+
+		switch node.Operator {
+		case "!":
+			c.emit(code.OpBang)
+		case "-":
+			c.emit(code.OpMinus)
+		default:
+			return fmt.Errorf("unknown operator: %s", node.Operator)
+		}
+
 	case *ast.InfixExpression:
+		// There is no less than opcode, instead the operands are swapped and compiled as a greater than.
+		if node.Operator == "<" {
+			err := c.Compile(node.Right)
+			if err != nil {
+				return err
+			}
+
+			err = c.Compile(node.Left)
+			if err != nil {
+				return err
+			}
+
+			c.emit(code.OpGreaterThan)
+			return nil
+		}
+
 		err := c.Compile(node.Left)
 		if err != nil {
 			return err
@@ -68,17 +270,173 @@ func (c *Compiler) Compile(node ast.Node) error {
 			c.emit(code.OpMul)
 		case "/":
 			c.emit(code.OpDiv)
+		case ">":
+			c.emit(code.OpGreaterThan)
+		case "==":
+			c.emit(code.OpEqual)
+		case "!=":
+			c.emit(code.OpNotEqual)
 		default:
 			return fmt.Errorf("unknown operator: %s", node.Operator)
 		}
 
+	case *ast.IfExpression:
+		err := c.Compile(node.Condition)
+		if err != nil {
+			return err
+		}
+
+		// The operand is a placeholder that is replaced once the position after the consequence is known.
+		jumpNotTruthyPosition := c.emit(code.OpJumpNotTruthy, 9999)
+
+		err = c.Compile(node.Consequence)
+		if err != nil {
+			return err
+		}
+
+		c.keepBlockValue()
+
+		jumpPosition := c.emit(code.OpJump, 9999)
+		c.changeOperand(jumpNotTruthyPosition, len(c.scope().instructions))
+
+		if node.Alternative == nil {
+			// An if expression without an alternative evaluates to null when the condition is not truthy.
+			c.emit(code.OpNull)
+		} else {
+			err = c.Compile(node.Alternative)
+			if err != nil {
+				return err
+			}
+
+			c.keepBlockValue()
+		}
+
+		c.changeOperand(jumpPosition, len(c.scope().instructions))
+
+	case *ast.WhileExpression:
+		start := len(c.scope().instructions)
+
+		err := c.Compile(node.Condition)
+		if err != nil {
+			return err
+		}
+
+		jumpNotTruthyPosition := c.emit(code.OpJumpNotTruthy, 9999)
+
+		// The value of each statement in the body is discarded
+		err = c.Compile(node.Body)
+		if err != nil {
+			return err
+		}
+
+		c.emit(code.OpJump, start)
+		c.changeOperand(jumpNotTruthyPosition, len(c.scope().instructions))
+
+		// A while expression evaluates to null once its condition is not truthy
+		c.emit(code.OpNull)
+
+	case *ast.CallExpression:
+		err := c.Compile(node.Function)
+		if err != nil {
+			return err
+		}
+
+		// The number of arguments is a single byte operand
+		if len(node.Arguments) > maxArguments {
+			return fmt.Errorf("too many arguments. got=%d, max=%d", len(node.Arguments), maxArguments)
+		}
+
+		for _, argument := range node.Arguments {
+			err := c.Compile(argument)
+			if err != nil {
+				return err
+			}
+		}
+
+		c.emit(code.OpCall, len(node.Arguments))
+
+	case *ast.IndexEpression:
+		err := c.Compile(node.Left)
+		if err != nil {
+			return err
+		}
+
+		err = c.Compile(node.Index)
+		if err != nil {
+			return err
+		}
+
+		c.emit(code.OpIndex)
+
+	case *ast.MemberExpression:
+		err := c.Compile(node.Left)
+		if err != nil {
+			return err
+		}
+
+		// `hash.name` is compiled the same as `hash["name"]`
+		name := &object.String{Value: node.Property.Value}
+		c.emit(code.OpConstant, c.addConstant(name))
+		c.emit(code.OpIndex)
+
+	case *ast.ArrayLiteral:
+		for _, element := range node.Elements {
+			err := c.Compile(element)
+			if err != nil {
+				return err
+			}
+		}
+
+		c.emit(code.OpArray, len(node.Elements))
+
+	case *ast.HashLiteral:
+		// Keys and values are compiled in the order they appear in the source so the hash keeps that order.
+		for _, pair := range node.Pairs {
+			err := c.Compile(pair.Key)
+			if err != nil {
+				return err
+			}
+
+			err = c.Compile(pair.Value)
+			if err != nil {
+				return err
+			}
+		}
+
+		c.emit(code.OpHash, len(node.Pairs)*2)
+
+	case *ast.BooleanExpression:
+		if node.Value {
+			c.emit(code.OpTrue)
+		} else {
+			c.emit(code.OpFalse)
+		}
+
 	case *ast.IntegerLiteral:
 		integer := &object.Integer{Value: node.Value}
 		// The index of the newly added constant is used as an operand in the emitted instruction.
 		c.emit(code.OpConstant, c.addConstant(integer))
+
+	case *ast.FloatLiteral:
+		float := &object.Float{Value: node.Value}
+		c.emit(code.OpConstant, c.addConstant(float))
+
+	case *ast.StringLiteral:
+		str := &object.String{Value: node.Value}
+		c.emit(code.OpConstant, c.addConstant(str))
+
+	case *ast.TemplateLiteral:
+		for _, part := range node.Parts {
+			err := c.Compile(part)
+			if err != nil {
+				return err
+			}
+		}
+
+		c.emit(code.OpConcat, len(node.Parts))
 	}
 	// Iterate over the instructions in memory, repeating the fetch-decode-execute cycle like in an actual machine.
-	return nil
+	return c.err
 }
 
 // addConstant adds a constant to the constants pool.
@@ -92,17 +450,225 @@ func (c *Compiler) addConstant(obj object.Object) int {
 // emit generates an instruction and add it to the results.
 // Returns the position of the newly added instruction.
 func (c *Compiler) emit(op code.Opcode, operands ...int) int {
+	c.checkOperands(op, operands...)
+
 	instruction := code.Make(op, operands...)
 	// Starting position of the newly added instruction.
-	position := len(c.instructions)
+	position := len(c.scope().instructions)
 	// PERF: Unperformant way to add elements to a slice because the cap is 0 by default and will always be x2 the len by default
-	c.instructions = append(c.instructions, instruction...)
+	c.scope().instructions = append(c.scope().instructions, instruction...)
+
+	c.scope().previousInstruction = c.scope().lastInstruction
+	c.scope().lastInstruction = EmittedInstruction{Opcode: op, Position: position}
+
 	return position
 }
 
+// checkOperands records an error if the operands of an instruction do not fit in it, unless there already is one.
+func (c *Compiler) checkOperands(op code.Opcode, operands ...int) {
+	if c.err != nil {
+		return
+	}
+
+	c.err = code.CheckOperands(op, operands...)
+}
+
+// lastInstructionIs checks if the last emitted instruction has the given opcode.
+func (c *Compiler) lastInstructionIs(op code.Opcode) bool {
+	return len(c.scope().instructions) != 0 && c.scope().lastInstruction.Opcode == op
+}
+
+// removeLastPop removes the last emitted instruction which must be an OpPop.
+func (c *Compiler) removeLastPop() {
+	c.scope().instructions = c.scope().instructions[:c.scope().lastInstruction.Position]
+	c.scope().lastInstruction = c.scope().previousInstruction
+}
+
+// keepBlockValue leaves the value of the last expression of a compiled block on the stack as the value of the block.
+// A block that does not end with an expression, e.g. an empty block, has a value of null.
+func (c *Compiler) keepBlockValue() {
+	if c.lastInstructionIs(code.OpPop) {
+		c.removeLastPop()
+	} else {
+		c.emit(code.OpNull)
+	}
+}
+
+// replaceLastPopWithReturn replaces the last emitted instruction, which must be an OpPop, with an OpReturnValue so the
+// value of the last expression of a function is returned.
+func (c *Compiler) replaceLastPopWithReturn() {
+	position := c.scope().lastInstruction.Position
+	c.replaceInstruction(position, code.Make(code.OpReturnValue))
+	c.scope().lastInstruction.Opcode = code.OpReturnValue
+}
+
+// replaceInstruction replaces the instruction at a position with an instruction of the same width.
+func (c *Compiler) replaceInstruction(position int, instruction []byte) {
+	copy(c.scope().instructions[position:], instruction)
+}
+
+// changeOperand replaces the operand of the instruction at a position.
+// The new instruction must have the same width as the old one, which is the case when the opcode stays the same.
+func (c *Compiler) changeOperand(position int, operand int) {
+	op := code.Opcode(c.scope().instructions[position])
+	c.checkOperands(op, operand)
+
+	instruction := code.Make(op, operand)
+
+	c.replaceInstruction(position, instruction)
+}
+
+// markTailCalls replaces the calls in tail position of the function being compiled, the calls whose result is returned
+// as soon as they return, with tail calls.
+func (c *Compiler) markTailCalls() {
+	instructions := c.scope().instructions
+
+	for position := 0; position < len(instructions); {
+		definition, err := code.Lookup(instructions[position])
+		if err != nil {
+			return
+		}
+
+		_, offset := code.ReadOperands(definition, instructions[position+1:])
+		next := position + 1 + offset
+
+		if code.Opcode(instructions[position]) == code.OpCall && returnsAt(instructions, next) {
+			instructions[position] = byte(code.OpTailCall)
+		}
+
+		position = next
+	}
+}
+
+// returnsAt checks if the instruction at a position returns the value on top of the stack, either directly or after
+// jumping to the end of an if expression.
+func returnsAt(instructions code.Instructions, position int) bool {
+	for position < len(instructions) {
+		switch code.Opcode(instructions[position]) {
+		case code.OpReturnValue:
+			return true
+		case code.OpJump:
+			position = int(code.ReadUint16(instructions[position+1:]))
+		default:
+			return false
+		}
+	}
+
+	return false
+}
+
+// loadSymbol emits the instruction that pushes the value bound to a symbol onto the stack.
+func (c *Compiler) loadSymbol(symbol Symbol) {
+	switch symbol.Scope {
+	case GlobalScope:
+		c.emit(code.OpGetGlobal, symbol.Index)
+	case LocalScope:
+		c.emit(code.OpGetLocal, symbol.Index)
+	case BuiltinScope:
+		c.emit(code.OpGetBuiltin, symbol.Index)
+	case FreeScope:
+		c.emit(code.OpGetFree, symbol.Index)
+	}
+}
+
+// storeSymbol emits the instruction that pops a value off the stack and binds it to a symbol.
+func (c *Compiler) storeSymbol(symbol Symbol) {
+	switch symbol.Scope {
+	case GlobalScope:
+		c.emit(code.OpSetGlobal, symbol.Index)
+	case LocalScope:
+		c.emit(code.OpSetLocal, symbol.Index)
+	case FreeScope:
+		c.emit(code.OpSetFree, symbol.Index)
+	}
+}
+
+// scope gets the scope of the instructions being compiled.
+func (c *Compiler) scope() *CompilationScope {
+	return &c.scopes[c.scopeIndex]
+}
+
+// enterScope starts compiling the instructions of a function with its own symbol table.
+func (c *Compiler) enterScope() {
+	c.scopes = append(c.scopes, CompilationScope{instructions: code.Instructions{}})
+	c.scopeIndex++
+	c.symbolTable = NewEnclosedSymbolTable(c.symbolTable)
+}
+
+// leaveScope finishes compiling the instructions of a function, returning to the enclosing scope.
+// Returns the instructions of the function.
+func (c *Compiler) leaveScope() code.Instructions {
+	instructions := c.scope().instructions
+
+	c.scopes = c.scopes[:len(c.scopes)-1]
+	c.scopeIndex--
+	c.symbolTable = c.symbolTable.Outer
+
+	return instructions
+}
+
+// compileModule compiles the module at a path the first time it is imported.
+// The module is compiled inline with its own symbol table and stored in a global that is not bound to an identifier, so
+// it runs once no matter how many times it is imported.
+// Returns the index of the global the module is stored in.
+func (c *Compiler) compileModule(name string) (int, error) {
+	name = object.ModulePath(name)
+	if index, ok := c.modules[name]; ok {
+		return index, nil
+	}
+
+	for i, importing := range c.importing {
+		if importing == name {
+			return 0, fmt.Errorf("%s", object.ImportCycleError(c.importing[i:], name).Message)
+		}
+	}
+
+	program, loadErr := object.LoadModule(c.loader, name)
+	if loadErr != nil {
+		return 0, fmt.Errorf("%s", loadErr.Message)
+	}
+
+	// The module shares the globals of the program, so its bindings are allocated after the ones defined so far
+	outer := c.symbolTable
+	c.symbolTable = NewGlobalSymbolTable()
+	c.symbolTable.numDefinitions = outer.numDefinitions
+	c.importing = append(c.importing, name)
+
+	err := c.Compile(program)
+
+	moduleTable := c.symbolTable
+	c.symbolTable = outer
+	c.symbolTable.numDefinitions = moduleTable.numDefinitions
+	c.importing = c.importing[:len(c.importing)-1]
+
+	if err != nil {
+		return 0, err
+	}
+
+	exports := object.Exports(program)
+	for _, export := range exports {
+		symbol, _ := moduleTable.Resolve(export)
+		c.emit(code.OpConstant, c.addConstant(&object.String{Value: export}))
+		c.loadSymbol(symbol)
+	}
+	c.emit(code.OpModule, c.addConstant(&object.String{Value: name}), len(exports)*2)
+
+	index := c.symbolTable.reserve()
+	c.emit(code.OpSetGlobal, index)
+	c.modules[name] = index
+
+	return index, nil
+}
+
+// SymbolTable gets the global symbol table, which holds the bindings of the compiled program.
+func (c *Compiler) SymbolTable() *SymbolTable {
+	return c.symbolTable
+}
+
+// ByteCode gets the compiled instructions of the main program and the constants they use.
 func (c *Compiler) ByteCode() *ByteCode {
 	return &ByteCode{
-		Instructions: c.instructions,
+		Instructions: c.scope().instructions,
 		Constants:    c.constants,
 	}
 }
@@ -2,6 +2,8 @@ package compiler
 
 import (
 	"fmt"
+	"slices"
+	"strings"
 	"testing"
 
 	"github.com/grantwforsythe/monkeylang/pkg/ast"
@@ -74,6 +76,785 @@ func TestIntegerArithmetic(t *testing.T) {
 	runCompilerTests(t, tests)
 }
 
+func TestFloatArithmetic(t *testing.T) {
+	tests := []compilerTestCase{
+		{
+			"1.5 + 2",
+			[]any{1.5, 2},
+			[]code.Instructions{
+				code.Make(code.OpConstant, 0),
+				code.Make(code.OpConstant, 1),
+				code.Make(code.OpAdd),
+				code.Make(code.OpPop),
+			},
+		},
+	}
+
+	runCompilerTests(t, tests)
+}
+
+func TestBooleanExpressions(t *testing.T) {
+	tests := []compilerTestCase{
+		{
+			"true",
+			[]any{},
+			[]code.Instructions{
+				code.Make(code.OpTrue),
+				code.Make(code.OpPop),
+			},
+		},
+		{
+			"1 > 2",
+			[]any{1, 2},
+			[]code.Instructions{
+				code.Make(code.OpConstant, 0),
+				code.Make(code.OpConstant, 1),
+				code.Make(code.OpGreaterThan),
+				code.Make(code.OpPop),
+			},
+		},
+		{
+			// The operands are swapped so less than can be compiled as greater than
+			"1 < 2",
+			[]any{2, 1},
+			[]code.Instructions{
+				code.Make(code.OpConstant, 0),
+				code.Make(code.OpConstant, 1),
+				code.Make(code.OpGreaterThan),
+				code.Make(code.OpPop),
+			},
+		},
+		{
+			"true != false",
+			[]any{},
+			[]code.Instructions{
+				code.Make(code.OpTrue),
+				code.Make(code.OpFalse),
+				code.Make(code.OpNotEqual),
+				code.Make(code.OpPop),
+			},
+		},
+		{
+			"!true",
+			[]any{},
+			[]code.Instructions{
+				code.Make(code.OpTrue),
+				code.Make(code.OpBang),
+				code.Make(code.OpPop),
+			},
+		},
+		{
+			"-1",
+			[]any{1},
+			[]code.Instructions{
+				code.Make(code.OpConstant, 0),
+				code.Make(code.OpMinus),
+				code.Make(code.OpPop),
+			},
+		},
+	}
+
+	runCompilerTests(t, tests)
+}
+
+func TestConditionals(t *testing.T) {
+	tests := []compilerTestCase{
+		{
+			"if (true) { 10 }; 3333;",
+			[]any{10, 3333},
+			[]code.Instructions{
+				// 0000
+				code.Make(code.OpTrue),
+				// 0001
+				code.Make(code.OpJumpNotTruthy, 10),
+				// 0004
+				code.Make(code.OpConstant, 0),
+				// 0007
+				code.Make(code.OpJump, 11),
+				// 0010
+				code.Make(code.OpNull),
+				// 0011
+				code.Make(code.OpPop),
+				// 0012
+				code.Make(code.OpConstant, 1),
+				// 0015
+				code.Make(code.OpPop),
+			},
+		},
+		{
+			"if (true) { 10 } else { 20 }; 3333;",
+			[]any{10, 20, 3333},
+			[]code.Instructions{
+				// 0000
+				code.Make(code.OpTrue),
+				// 0001
+				code.Make(code.OpJumpNotTruthy, 10),
+				// 0004
+				code.Make(code.OpConstant, 0),
+				// 0007
+				code.Make(code.OpJump, 13),
+				// 0010
+				code.Make(code.OpConstant, 1),
+				// 0013
+				code.Make(code.OpPop),
+				// 0014
+				code.Make(code.OpConstant, 2),
+				// 0017
+				code.Make(code.OpPop),
+			},
+		},
+		{
+			"if (true) { }",
+			[]any{},
+			[]code.Instructions{
+				// 0000
+				code.Make(code.OpTrue),
+				// 0001
+				code.Make(code.OpJumpNotTruthy, 8),
+				// 0004
+				code.Make(code.OpNull),
+				// 0005
+				code.Make(code.OpJump, 9),
+				// 0008
+				code.Make(code.OpNull),
+				// 0009
+				code.Make(code.OpPop),
+			},
+		},
+	}
+
+	runCompilerTests(t, tests)
+}
+
+func TestGlobalLetStatements(t *testing.T) {
+	tests := []compilerTestCase{
+		{
+			"let one = 1; let two = one; two;",
+			[]any{1},
+			[]code.Instructions{
+				code.Make(code.OpConstant, 0),
+				code.Make(code.OpSetGlobal, 0),
+				code.Make(code.OpGetGlobal, 0),
+				code.Make(code.OpSetGlobal, 1),
+				code.Make(code.OpGetGlobal, 1),
+				code.Make(code.OpPop),
+			},
+		},
+	}
+
+	runCompilerTests(t, tests)
+}
+
+func TestUndefinedVariable(t *testing.T) {
+	err := New().Compile(parse("foobar"))
+	if err == nil || err.Error() != "undefined variable foobar" {
+		t.Fatalf("wrong error. got=%v", err)
+	}
+}
+
+func TestOperandLimits(t *testing.T) {
+	// joinN repeats a format with the numbers from 0 to n, separated by commas
+	joinN := func(format string, n int, separator string) string {
+		parts := make([]string, n)
+		for i := range parts {
+			parts[i] = fmt.Sprintf(format, i)
+		}
+		return strings.Join(parts, separator)
+	}
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{
+			fmt.Sprintf("let f = fn() { 1 }; f(%s)", joinN("%d", 300, ", ")),
+			"too many arguments. got=300, max=255",
+		},
+		{
+			fmt.Sprintf("[%s]", strings.TrimSuffix(strings.Repeat("true, ", 70000), ", ")),
+			"operand 1 of OpArray is out of range. got=70000, max=65535",
+		},
+		{
+			joinN("%d", 70000, "; "),
+			"operand 1 of OpConstant is out of range. got=65536, max=65535",
+		},
+		{
+			fmt.Sprintf("fn() { %s; 1 }", joinN("let a%d = 1", 257, "; ")),
+			"operand 1 of OpSetLocal is out of range. got=256, max=255",
+		},
+	}
+
+	for _, tt := range tests {
+		err := New().Compile(parse(tt.input))
+		if err == nil || err.Error() != tt.expected {
+			t.Errorf("wrong error. got=%v, want=%s", err, tt.expected)
+		}
+	}
+
+	// The largest call still compiles
+	err := New().Compile(parse(fmt.Sprintf("let f = fn() { 1 }; f(%s)", joinN("%d", 255, ", "))))
+	if err != nil {
+		t.Errorf("compiler error: %s", err)
+	}
+}
+
+func TestForwardReferences(t *testing.T) {
+	// A function can refer to a function defined after it
+	comp := New()
+	if err := comp.Compile(parse("let even = fn(n) { odd(n) }; let odd = fn(n) { even(n) };")); err != nil {
+		t.Fatalf("compiler error: %s", err)
+	}
+
+	even, _ := comp.SymbolTable().Resolve("even")
+	odd, _ := comp.SymbolTable().Resolve("odd")
+	if even != (Symbol{Name: "even", Scope: GlobalScope, Index: 0}) || odd != (Symbol{Name: "odd", Scope: GlobalScope, Index: 1}) {
+		t.Errorf("wrong symbols. got=%+v and %+v", even, odd)
+	}
+
+	// A function can refer to any global defined after it
+	if err := New().Compile(parse("let x = fn() { y }; let y = 1;")); err != nil {
+		t.Errorf("compiler error: %s", err)
+	}
+
+	// Outside of functions a global is only bound once it is defined
+	for _, input := range []string{"odd; let odd = fn() { 1 };", "let x = y; let y = 1;"} {
+		err := New().Compile(parse(input))
+		if err == nil || !strings.HasPrefix(err.Error(), "undefined variable") {
+			t.Errorf("%s: wrong error. got=%v", input, err)
+		}
+	}
+}
+
+func TestStringExpressions(t *testing.T) {
+	tests := []compilerTestCase{
+		{
+			`"mon" + "key"`,
+			[]any{"mon", "key"},
+			[]code.Instructions{
+				code.Make(code.OpConstant, 0),
+				code.Make(code.OpConstant, 1),
+				code.Make(code.OpAdd),
+				code.Make(code.OpPop),
+			},
+		},
+		{
+			`"a${1}b"`,
+			[]any{"a", 1, "b"},
+			[]code.Instructions{
+				code.Make(code.OpConstant, 0),
+				code.Make(code.OpConstant, 1),
+				code.Make(code.OpConstant, 2),
+				code.Make(code.OpConcat, 3),
+				code.Make(code.OpPop),
+			},
+		},
+	}
+
+	runCompilerTests(t, tests)
+}
+
+func TestArrayAndHashLiterals(t *testing.T) {
+	tests := []compilerTestCase{
+		{
+			"[1, 2 + 3]",
+			[]any{1, 2, 3},
+			[]code.Instructions{
+				code.Make(code.OpConstant, 0),
+				code.Make(code.OpConstant, 1),
+				code.Make(code.OpConstant, 2),
+				code.Make(code.OpAdd),
+				code.Make(code.OpArray, 2),
+				code.Make(code.OpPop),
+			},
+		},
+		{
+			"{}",
+			[]any{},
+			[]code.Instructions{
+				code.Make(code.OpHash, 0),
+				code.Make(code.OpPop),
+			},
+		},
+		{
+			// Pairs are compiled in source order
+			"{3: 4, 1: 2}",
+			[]any{3, 4, 1, 2},
+			[]code.Instructions{
+				code.Make(code.OpConstant, 0),
+				code.Make(code.OpConstant, 1),
+				code.Make(code.OpConstant, 2),
+				code.Make(code.OpConstant, 3),
+				code.Make(code.OpHash, 4),
+				code.Make(code.OpPop),
+			},
+		},
+		{
+			"[1][0]",
+			[]any{1, 0},
+			[]code.Instructions{
+				code.Make(code.OpConstant, 0),
+				code.Make(code.OpArray, 1),
+				code.Make(code.OpConstant, 1),
+				code.Make(code.OpIndex),
+				code.Make(code.OpPop),
+			},
+		},
+	}
+
+	runCompilerTests(t, tests)
+}
+
+func TestBuiltins(t *testing.T) {
+	builtinIndex := func(name string) int {
+		return slices.Index(object.BuiltinNames(), name)
+	}
+
+	tests := []compilerTestCase{
+		{
+			`len([]); upper("a");`,
+			[]any{"a"},
+			[]code.Instructions{
+				code.Make(code.OpGetBuiltin, builtinIndex("len")),
+				code.Make(code.OpArray, 0),
+				code.Make(code.OpCall, 1),
+				code.Make(code.OpPop),
+				code.Make(code.OpGetBuiltin, builtinIndex("upper")),
+				code.Make(code.OpConstant, 0),
+				code.Make(code.OpCall, 1),
+				code.Make(code.OpPop),
+			},
+		},
+	}
+
+	runCompilerTests(t, tests)
+}
+
+func TestMemberExpressions(t *testing.T) {
+	tests := []compilerTestCase{
+		{
+			// Member access is compiled as an index expression with a string key
+			"math.PI",
+			[]any{"PI"},
+			[]code.Instructions{
+				code.Make(code.OpGetBuiltin, slices.Index(object.BuiltinNames(), "math")),
+				code.Make(code.OpConstant, 0),
+				code.Make(code.OpIndex),
+				code.Make(code.OpPop),
+			},
+		},
+	}
+
+	runCompilerTests(t, tests)
+}
+
+func TestFunctions(t *testing.T) {
+	tests := []compilerTestCase{
+		{
+			"fn() { return 5 + 10 }",
+			[]any{
+				5,
+				10,
+				[]code.Instructions{
+					code.Make(code.OpConstant, 0),
+					code.Make(code.OpConstant, 1),
+					code.Make(code.OpAdd),
+					code.Make(code.OpReturnValue),
+				},
+			},
+			[]code.Instructions{
+				code.Make(code.OpClosure, 2),
+				code.Make(code.OpPop),
+			},
+		},
+		{
+			// The value of the last expression is returned implicitly
+			"fn() { 1; 2 }",
+			[]any{
+				1,
+				2,
+				[]code.Instructions{
+					code.Make(code.OpConstant, 0),
+					code.Make(code.OpPop),
+					code.Make(code.OpConstant, 1),
+					code.Make(code.OpReturnValue),
+				},
+			},
+			[]code.Instructions{
+				code.Make(code.OpClosure, 2),
+				code.Make(code.OpPop),
+			},
+		},
+		{
+			"fn() { }",
+			[]any{
+				[]code.Instructions{
+					code.Make(code.OpReturn),
+				},
+			},
+			[]code.Instructions{
+				code.Make(code.OpClosure, 0),
+				code.Make(code.OpPop),
+			},
+		},
+		{
+			"let f = fn(a, b) { let c = a + b; c }; f(1, 2)",
+			[]any{
+				[]code.Instructions{
+					code.Make(code.OpGetLocal, 0),
+					code.Make(code.OpGetLocal, 1),
+					code.Make(code.OpAdd),
+					code.Make(code.OpSetLocal, 2),
+					code.Make(code.OpGetLocal, 2),
+					code.Make(code.OpReturnValue),
+				},
+				1,
+				2,
+			},
+			[]code.Instructions{
+				code.Make(code.OpClosure, 0),
+				code.Make(code.OpSetGlobal, 0),
+				code.Make(code.OpGetGlobal, 0),
+				code.Make(code.OpConstant, 1),
+				code.Make(code.OpConstant, 2),
+				code.Make(code.OpCall, 2),
+				code.Make(code.OpPop),
+			},
+		},
+		{
+			// A function is bound before it is compiled so that it can call itself
+			"let f = fn(n) { f(n) };",
+			[]any{
+				[]code.Instructions{
+					code.Make(code.OpGetGlobal, 0),
+					code.Make(code.OpGetLocal, 0),
+					code.Make(code.OpTailCall, 1),
+					code.Make(code.OpReturnValue),
+				},
+			},
+			[]code.Instructions{
+				code.Make(code.OpClosure, 0),
+				code.Make(code.OpSetGlobal, 0),
+			},
+		},
+	}
+
+	runCompilerTests(t, tests)
+}
+
+func TestTailCalls(t *testing.T) {
+	tests := []compilerTestCase{
+		{
+			// Only the call whose result is returned is in tail position
+			"fn(f) { f(f(1)) }",
+			[]any{
+				1,
+				[]code.Instructions{
+					code.Make(code.OpGetLocal, 0),
+					code.Make(code.OpGetLocal, 0),
+					code.Make(code.OpConstant, 0),
+					code.Make(code.OpCall, 1),
+					code.Make(code.OpTailCall, 1),
+					code.Make(code.OpReturnValue),
+				},
+			},
+			[]code.Instructions{
+				code.Make(code.OpClosure, 1),
+				code.Make(code.OpPop),
+			},
+		},
+		{
+			// The calls in each branch of an if expression jump to the return
+			"fn(f) { if (true) { return f(); 1 } else { f() } }",
+			[]any{
+				1,
+				[]code.Instructions{
+					// 0000
+					code.Make(code.OpTrue),
+					// 0001
+					code.Make(code.OpJumpNotTruthy, 15),
+					// 0004
+					code.Make(code.OpGetLocal, 0),
+					// 0006
+					code.Make(code.OpTailCall, 0),
+					// 0008
+					code.Make(code.OpReturnValue),
+					// 0009
+					code.Make(code.OpConstant, 0),
+					// 0012
+					code.Make(code.OpJump, 19),
+					// 0015
+					code.Make(code.OpGetLocal, 0),
+					// 0017
+					code.Make(code.OpTailCall, 0),
+					// 0019
+					code.Make(code.OpReturnValue),
+				},
+			},
+			[]code.Instructions{
+				code.Make(code.OpClosure, 1),
+				code.Make(code.OpPop),
+			},
+		},
+		{
+			"fn(f) { f(); 1 }",
+			[]any{
+				1,
+				[]code.Instructions{
+					code.Make(code.OpGetLocal, 0),
+					code.Make(code.OpCall, 0),
+					code.Make(code.OpPop),
+					code.Make(code.OpConstant, 0),
+					code.Make(code.OpReturnValue),
+				},
+			},
+			[]code.Instructions{
+				code.Make(code.OpClosure, 1),
+				code.Make(code.OpPop),
+			},
+		},
+	}
+
+	runCompilerTests(t, tests)
+}
+
+func TestClosures(t *testing.T) {
+	tests := []compilerTestCase{
+		{
+			"fn(a) { fn(b) { a + b } }",
+			[]any{
+				[]code.Instructions{
+					code.Make(code.OpGetFree, 0),
+					code.Make(code.OpGetLocal, 0),
+					code.Make(code.OpAdd),
+					code.Make(code.OpReturnValue),
+				},
+				[]code.Instructions{
+					code.Make(code.OpClosure, 0),
+					code.Make(code.OpReturnValue),
+				},
+			},
+			[]code.Instructions{
+				code.Make(code.OpClosure, 1),
+				code.Make(code.OpPop),
+			},
+		},
+		{
+			// The innermost function captures a through the free variables of the function enclosing it
+			"fn(a) { fn(b) { fn(c) { a + b + c } } }",
+			[]any{
+				[]code.Instructions{
+					code.Make(code.OpGetFree, 0),
+					code.Make(code.OpGetFree, 1),
+					code.Make(code.OpAdd),
+					code.Make(code.OpGetLocal, 0),
+					code.Make(code.OpAdd),
+					code.Make(code.OpReturnValue),
+				},
+				[]code.Instructions{
+					code.Make(code.OpClosure, 0),
+					code.Make(code.OpReturnValue),
+				},
+				[]code.Instructions{
+					code.Make(code.OpClosure, 1),
+					code.Make(code.OpReturnValue),
+				},
+			},
+			[]code.Instructions{
+				code.Make(code.OpClosure, 2),
+				code.Make(code.OpPop),
+			},
+		},
+	}
+
+	runCompilerTests(t, tests)
+
+	comp := New()
+	err := comp.Compile(parse("fn(a) { fn(b) { fn(c) { a + b + c } } }"))
+	if err != nil {
+		t.Fatalf("compiler error: %s", err)
+	}
+
+	expected := [][]object.FreeVariable{
+		{{Local: false, Index: 0}, {Local: true, Index: 0}},
+		{{Local: true, Index: 0}},
+		{},
+	}
+	for i, free := range expected {
+		fn := comp.ByteCode().Constants[i].(*object.CompiledFunction)
+		if !slices.Equal(fn.Free, free) {
+			t.Errorf("wrong free variables for function %d. got=%+v, want=%+v", i, fn.Free, free)
+		}
+	}
+}
+
+func TestAssignments(t *testing.T) {
+	tests := []compilerTestCase{
+		{
+			"let x = 1; x = 2",
+			[]any{1, 2},
+			[]code.Instructions{
+				code.Make(code.OpConstant, 0),
+				code.Make(code.OpSetGlobal, 0),
+				code.Make(code.OpConstant, 1),
+				code.Make(code.OpSetGlobal, 0),
+				code.Make(code.OpGetGlobal, 0),
+				code.Make(code.OpPop),
+			},
+		},
+		{
+			"fn(x) { fn() { x = 1 } }",
+			[]any{
+				1,
+				[]code.Instructions{
+					code.Make(code.OpConstant, 0),
+					code.Make(code.OpSetFree, 0),
+					code.Make(code.OpGetFree, 0),
+					code.Make(code.OpReturnValue),
+				},
+				[]code.Instructions{
+					code.Make(code.OpClosure, 1),
+					code.Make(code.OpReturnValue),
+				},
+			},
+			[]code.Instructions{
+				code.Make(code.OpClosure, 2),
+				code.Make(code.OpPop),
+			},
+		},
+		{
+			// Redefining a global reuses its binding
+			"let x = 1; let x = 2;",
+			[]any{1, 2},
+			[]code.Instructions{
+				code.Make(code.OpConstant, 0),
+				code.Make(code.OpSetGlobal, 0),
+				code.Make(code.OpConstant, 1),
+				code.Make(code.OpSetGlobal, 0),
+			},
+		},
+	}
+
+	runCompilerTests(t, tests)
+
+	for _, input := range []string{"x = 1", "len = 1"} {
+		err := New().Compile(parse(input))
+		if err == nil || !strings.HasPrefix(err.Error(), "cannot assign to undefined variable") {
+			t.Errorf("%s: wrong error. got=%v", input, err)
+		}
+	}
+}
+
+func TestWhileExpressions(t *testing.T) {
+	tests := []compilerTestCase{
+		{
+			"while (true) { 1 }; 2",
+			[]any{1, 2},
+			[]code.Instructions{
+				// 0000
+				code.Make(code.OpTrue),
+				// 0001
+				code.Make(code.OpJumpNotTruthy, 11),
+				// 0004
+				code.Make(code.OpConstant, 0),
+				// 0007
+				code.Make(code.OpPop),
+				// 0008
+				code.Make(code.OpJump, 0),
+				// 0011
+				code.Make(code.OpNull),
+				// 0012
+				code.Make(code.OpPop),
+				// 0013
+				code.Make(code.OpConstant, 1),
+				// 0016
+				code.Make(code.OpPop),
+			},
+		},
+	}
+
+	runCompilerTests(t, tests)
+}
+
+func TestCompilerWithState(t *testing.T) {
+	first := New()
+	if err := first.Compile(parse("let a = 1;")); err != nil {
+		t.Fatalf("compiler error: %s", err)
+	}
+
+	bytecode := first.ByteCode()
+	second := NewWithState(first.SymbolTable(), bytecode.Constants, nil)
+	if err := second.Compile(parse("let b = 2; a + b")); err != nil {
+		t.Fatalf("compiler error: %s", err)
+	}
+
+	expectedInstructions := []code.Instructions{
+		code.Make(code.OpConstant, 1),
+		code.Make(code.OpSetGlobal, 1),
+		code.Make(code.OpGetGlobal, 0),
+		code.Make(code.OpGetGlobal, 1),
+		code.Make(code.OpAdd),
+		code.Make(code.OpPop),
+	}
+
+	err := testInstructions(expectedInstructions, second.ByteCode().Instructions)
+	if err != nil {
+		t.Fatalf("testInstructions failed: %s", err)
+	}
+
+	err = testConstants([]any{1, 2}, second.ByteCode().Constants)
+	if err != nil {
+		t.Fatalf("testConstants failed: %s", err)
+	}
+}
+
+func TestImports(t *testing.T) {
+	loader := object.MapLoader{"m.mk": `let hidden = 1; export let x = 2;`}
+
+	program := parse(`import "m.mk" as m; import "m.mk" as again; m.x`)
+	compiler := NewWithLoader(loader)
+	err := compiler.Compile(program)
+	if err != nil {
+		t.Fatalf("compiler error: %s", err)
+	}
+
+	expectedInstructions := []code.Instructions{
+		// The module is compiled once with its bindings in globals 0 and 1
+		code.Make(code.OpConstant, 0),
+		code.Make(code.OpSetGlobal, 0),
+		code.Make(code.OpConstant, 1),
+		code.Make(code.OpSetGlobal, 1),
+		code.Make(code.OpConstant, 2),
+		code.Make(code.OpGetGlobal, 1),
+		code.Make(code.OpModule, 3, 2),
+		code.Make(code.OpSetGlobal, 2),
+		code.Make(code.OpGetGlobal, 2),
+		code.Make(code.OpSetGlobal, 3),
+		// Importing the module again reuses the global it is stored in
+		code.Make(code.OpGetGlobal, 2),
+		code.Make(code.OpSetGlobal, 4),
+		code.Make(code.OpGetGlobal, 3),
+		code.Make(code.OpConstant, 4),
+		code.Make(code.OpIndex),
+		code.Make(code.OpPop),
+	}
+
+	bytecode := compiler.ByteCode()
+	err = testInstructions(expectedInstructions, bytecode.Instructions)
+	if err != nil {
+		t.Fatalf("testInstructions failed: %s", err)
+	}
+
+	err = testConstants([]any{1, 2, "x", "m.mk", "x"}, bytecode.Constants)
+	if err != nil {
+		t.Fatalf("testConstants failed: %s", err)
+	}
+
+	err = New().Compile(parse(`import "m.mk" as m;`))
+	if err == nil || err.Error() != `could not import "m.mk": modules are not enabled` {
+		t.Fatalf("wrong error for a compiler without a loader. got=%v", err)
+	}
+}
+
 func runCompilerTests(t *testing.T, tests []compilerTestCase) {
 	t.Helper()
 
@@ -154,6 +935,26 @@ func testConstants(expected []any, actual []object.Object) error {
 			if err != nil {
 				return fmt.Errorf("failed to create consant in position %d: %s", i, err)
 			}
+		case float64:
+			err := testFloatObject(constant, actual[i])
+			if err != nil {
+				return fmt.Errorf("failed to create consant in position %d: %s", i, err)
+			}
+		case string:
+			err := testStringObject(constant, actual[i])
+			if err != nil {
+				return fmt.Errorf("failed to create consant in position %d: %s", i, err)
+			}
+		case []code.Instructions:
+			fn, ok := actual[i].(*object.CompiledFunction)
+			if !ok {
+				return fmt.Errorf("constant %d is not a function. got=%T", i, actual[i])
+			}
+
+			err := testInstructions(constant, fn.Instructions)
+			if err != nil {
+				return fmt.Errorf("failed to create function in position %d: %s", i, err)
+			}
 		}
 	}
 
@@ -172,3 +973,29 @@ func testIntegerObject(expected int64, actual object.Object) error {
 
 	return nil
 }
+
+func testFloatObject(expected float64, actual object.Object) error {
+	result, ok := actual.(*object.Float)
+	if !ok {
+		return fmt.Errorf("object is not of type *object.Float. got=%T (%+v)", actual, actual)
+	}
+
+	if result.Value != expected {
+		return fmt.Errorf("mismatched values. expected=%f, got=%f", expected, result.Value)
+	}
+
+	return nil
+}
+
+func testStringObject(expected string, actual object.Object) error {
+	result, ok := actual.(*object.String)
+	if !ok {
+		return fmt.Errorf("object is not of type *object.String. got=%T (%+v)", actual, actual)
+	}
+
+	if result.Value != expected {
+		return fmt.Errorf("mismatched values. expected=%q, got=%q", expected, result.Value)
+	}
+
+	return nil
+}
@@ -0,0 +1,194 @@
+package compiler
+
+import (
+	"slices"
+
+	"github.com/grantwforsythe/monkeylang/pkg/object"
+)
+
+// SymbolScope represents where the value bound to a symbol is stored.
+type SymbolScope string
+
+const (
+	GlobalScope  SymbolScope = "GLOBAL"  // GlobalScope symbols are stored in the globals store of the virtual machine.
+	LocalScope   SymbolScope = "LOCAL"   // LocalScope symbols are stored on the stack in the frame of a function call.
+	BuiltinScope SymbolScope = "BUILTIN" // BuiltinScope symbols refer to the builtins shared with the evaluator.
+	FreeScope    SymbolScope = "FREE"    // FreeScope symbols refer to the locals of an enclosing function captured by a closure.
+)
+
+// Symbol represents the information the compiler needs about an identifier.
+type Symbol struct {
+	Name  string      // Name represents the identifier.
+	Scope SymbolScope // Scope represents where the value bound to the identifier is stored.
+	Index int         // Index represents the position of the value in its scope.
+}
+
+// SymbolTable associates identifiers with symbols.
+// The symbol table of a function is enclosed by the symbol table of the scope the function is defined in.
+type SymbolTable struct {
+	Outer *SymbolTable
+
+	// FreeSymbols are the symbols of the enclosing function that the function captures, in the order of their index.
+	FreeSymbols []Symbol
+
+	store          map[string]Symbol
+	numDefinitions int
+
+	modules map[string]int // modules maps the path of every module compiled with a global symbol table to the global it is stored in.
+
+	// forward holds the globals declared before they are defined, which only functions can refer to.
+	forward map[string]Symbol
+}
+
+// NewSymbolTable creates a new global symbol table.
+func NewSymbolTable() *SymbolTable {
+	return &SymbolTable{store: make(map[string]Symbol), modules: make(map[string]int)}
+}
+
+// NewGlobalSymbolTable creates a new global symbol table with every builtin defined.
+func NewGlobalSymbolTable() *SymbolTable {
+	symbolTable := NewSymbolTable()
+	for i, name := range object.BuiltinNames() {
+		symbolTable.DefineBuiltin(i, name)
+	}
+
+	return symbolTable
+}
+
+// NewEnclosedSymbolTable creates a new symbol table for the local bindings of a function.
+func NewEnclosedSymbolTable(outer *SymbolTable) *SymbolTable {
+	s := NewSymbolTable()
+	s.Outer = outer
+	return s
+}
+
+// Define creates a symbol for an identifier, which is local if the symbol table is enclosed and global otherwise.
+// Defining an identifier that is already defined in the same scope reuses its symbol, so closures that captured the
+// identifier see the new value the same way they do in the evaluator.
+func (s *SymbolTable) Define(name string) Symbol {
+	scope := GlobalScope
+	if s.Outer != nil {
+		scope = LocalScope
+	}
+
+	if symbol, ok := s.store[name]; ok && symbol.Scope == scope {
+		return symbol
+	}
+
+	if symbol, ok := s.forward[name]; ok {
+		delete(s.forward, name)
+		s.store[name] = symbol
+		return symbol
+	}
+
+	symbol := Symbol{Name: name, Scope: scope, Index: s.numDefinitions}
+	s.store[name] = symbol
+	s.numDefinitions++
+	return symbol
+}
+
+// Declare allocates the global for an identifier that is defined later in the program, so the functions defined before
+// it can refer to it, e.g. to call each other. The identifier is not bound outside of functions until it is defined.
+func (s *SymbolTable) Declare(name string) {
+	if symbol, ok := s.store[name]; ok && symbol.Scope == GlobalScope {
+		return
+	}
+	if _, ok := s.forward[name]; ok {
+		return
+	}
+
+	if s.forward == nil {
+		s.forward = make(map[string]Symbol)
+	}
+	s.forward[name] = Symbol{Name: name, Scope: GlobalScope, Index: s.reserve()}
+}
+
+// reserve allocates a global that is not bound to an identifier.
+// Returns the index of the global.
+func (s *SymbolTable) reserve() int {
+	index := s.numDefinitions
+	s.numDefinitions++
+	return index
+}
+
+// DefineBuiltin creates a builtin symbol for an identifier.
+func (s *SymbolTable) DefineBuiltin(index int, name string) Symbol {
+	symbol := Symbol{Name: name, Scope: BuiltinScope, Index: index}
+	s.store[name] = symbol
+	return symbol
+}
+
+// Resolve gets the symbol for an identifier, searching the enclosing symbol tables if it is not defined in this one.
+// A local of an enclosing function becomes a free symbol of this one.
+func (s *SymbolTable) Resolve(name string) (Symbol, bool) {
+	symbol, ok := s.store[name]
+	if ok || s.Outer == nil {
+		return symbol, ok
+	}
+
+	symbol, ok = s.Outer.Resolve(name)
+	if !ok {
+		return s.resolveForward(name)
+	}
+	if symbol.Scope == GlobalScope || symbol.Scope == BuiltinScope {
+		return symbol, ok
+	}
+
+	return s.defineFree(symbol), true
+}
+
+// resolveForward gets the symbol of a global that is declared but not yet defined, which functions can refer to.
+func (s *SymbolTable) resolveForward(name string) (Symbol, bool) {
+	global := s
+	for global.Outer != nil {
+		global = global.Outer
+	}
+
+	symbol, ok := global.forward[name]
+	return symbol, ok
+}
+
+// defineFree creates a free symbol for a symbol of the enclosing function.
+func (s *SymbolTable) defineFree(original Symbol) Symbol {
+	s.FreeSymbols = append(s.FreeSymbols, original)
+
+	symbol := Symbol{Name: original.Name, Scope: FreeScope, Index: len(s.FreeSymbols) - 1}
+	s.store[original.Name] = symbol
+	return symbol
+}
+
+// Copy creates a copy of a global symbol table that can have identifiers defined without affecting the original.
+func (s *SymbolTable) Copy() *SymbolTable {
+	copied := NewSymbolTable()
+	copied.numDefinitions = s.numDefinitions
+	for name, symbol := range s.store {
+		copied.store[name] = symbol
+	}
+	for name, index := range s.modules {
+		copied.modules[name] = index
+	}
+
+	return copied
+}
+
+// ForgetUnset removes the global symbols and modules whose globals have not been set, e.g. the bindings of a program
+// that failed before it got to them, so they are undefined again and the modules run again the next time they are
+// imported. A builtin shadowed by a removed symbol is defined again.
+func (s *SymbolTable) ForgetUnset(globals []object.Object) {
+	for name, symbol := range s.store {
+		if symbol.Scope != GlobalScope || globals[symbol.Index] != nil {
+			continue
+		}
+
+		delete(s.store, name)
+		if index := slices.Index(object.BuiltinNames(), name); index >= 0 {
+			s.DefineBuiltin(index, name)
+		}
+	}
+
+	for name, index := range s.modules {
+		if globals[index] == nil {
+			delete(s.modules, name)
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package compiler
+
+import (
+	"testing"
+
+	"github.com/grantwforsythe/monkeylang/pkg/object"
+)
+
+func TestSymbolTable(t *testing.T) {
+	global := NewSymbolTable()
+
+	a := global.Define("a")
+	b := global.Define("b")
+	builtin := global.DefineBuiltin(3, "len")
+
+	expected := map[string]Symbol{
+		"a":   {Name: "a", Scope: GlobalScope, Index: 0},
+		"b":   {Name: "b", Scope: GlobalScope, Index: 1},
+		"len": {Name: "len", Scope: BuiltinScope, Index: 3},
+	}
+
+	for _, symbol := range []Symbol{a, b, builtin} {
+		if symbol != expected[symbol.Name] {
+			t.Errorf("wrong symbol defined. expected=%+v, got=%+v", expected[symbol.Name], symbol)
+		}
+
+		resolved, ok := global.Resolve(symbol.Name)
+		if !ok {
+			t.Errorf("name %s not resolvable", symbol.Name)
+			continue
+		}
+
+		if resolved != symbol {
+			t.Errorf("expected %s to resolve to %+v, got=%+v", symbol.Name, symbol, resolved)
+		}
+	}
+
+	if _, ok := global.Resolve("c"); ok {
+		t.Errorf("undefined name c resolved")
+	}
+}
+
+func TestSymbolTableLocalScopes(t *testing.T) {
+	global := NewSymbolTable()
+	global.Define("a")
+
+	local := NewEnclosedSymbolTable(global)
+	b := local.Define("b")
+	if b != (Symbol{Name: "b", Scope: LocalScope, Index: 0}) {
+		t.Errorf("wrong local symbol. got=%+v", b)
+	}
+
+	nested := NewEnclosedSymbolTable(local)
+	c := nested.Define("c")
+	if c != (Symbol{Name: "c", Scope: LocalScope, Index: 0}) {
+		t.Errorf("wrong nested local symbol. got=%+v", c)
+	}
+
+	expected := []Symbol{
+		{Name: "a", Scope: GlobalScope, Index: 0},
+		{Name: "b", Scope: FreeScope, Index: 0},
+		{Name: "c", Scope: LocalScope, Index: 0},
+	}
+
+	for _, symbol := range expected {
+		resolved, ok := nested.Resolve(symbol.Name)
+		if !ok || resolved != symbol {
+			t.Errorf("expected %s to resolve to %+v, got=%+v", symbol.Name, symbol, resolved)
+		}
+	}
+
+	expectedFree := []Symbol{{Name: "b", Scope: LocalScope, Index: 0}}
+	if len(nested.FreeSymbols) != 1 || nested.FreeSymbols[0] != expectedFree[0] {
+		t.Errorf("wrong free symbols. got=%+v, want=%+v", nested.FreeSymbols, expectedFree)
+	}
+}
+
+func TestSymbolTableRedefinition(t *testing.T) {
+	global := NewSymbolTable()
+	a := global.Define("a")
+	if redefined := global.Define("a"); redefined != a {
+		t.Errorf("redefining a global created a new symbol. got=%+v, want=%+v", redefined, a)
+	}
+
+	local := NewEnclosedSymbolTable(global)
+	shadow := local.Define("a")
+	if shadow != (Symbol{Name: "a", Scope: LocalScope, Index: 0}) {
+		t.Errorf("a local did not shadow the global. got=%+v", shadow)
+	}
+
+	// A free symbol is shadowed by a local defined after it is used
+	nested := NewEnclosedSymbolTable(local)
+	nested.Resolve("a")
+	if symbol := nested.Define("a"); symbol.Scope != LocalScope {
+		t.Errorf("a local did not shadow the free symbol. got=%+v", symbol)
+	}
+}
+
+func TestSymbolTableCopy(t *testing.T) {
+	global := NewSymbolTable()
+	global.Define("a")
+
+	copied := global.Copy()
+	b := copied.Define("b")
+	if b.Index != 1 {
+		t.Errorf("copy does not continue the indexes of the original. got=%d", b.Index)
+	}
+
+	if _, ok := global.Resolve("b"); ok {
+		t.Errorf("defining an identifier in a copy affected the original")
+	}
+}
+
+func TestSymbolTableForgetUnset(t *testing.T) {
+	global := NewGlobalSymbolTable()
+	set := global.Define("set")
+	global.Define("unset")
+	global.Define("len")
+	global.modules["m.mk"] = global.reserve()
+
+	globals := make([]object.Object, 4)
+	globals[set.Index] = object.NULL
+	global.ForgetUnset(globals)
+
+	if _, ok := global.Resolve("set"); !ok {
+		t.Errorf("forgot a global that is set")
+	}
+	if _, ok := global.Resolve("unset"); ok {
+		t.Errorf("did not forget a global that is unset")
+	}
+	if symbol, ok := global.Resolve("len"); !ok || symbol.Scope != BuiltinScope {
+		t.Errorf("did not define the builtin shadowed by a forgotten global again. got=%+v", symbol)
+	}
+	if _, ok := global.modules["m.mk"]; ok {
+		t.Errorf("did not forget a module that is unset")
+	}
+}
@@ -1,141 +1,67 @@
 package evaluator
 
-import (
-	"fmt"
-	"os"
-
-	"github.com/grantwforsythe/monkeylang/pkg/object"
-)
-
-var builtin = map[string]*object.Builtin{
-	"len": {
-		// Calculate the length of array or string.
-		Fn: func(args ...object.Object) object.Object {
-			if len(args) != 1 {
-				return newError("wrong number of arguments. got=%d, want=1", len(args))
-			}
-
-			switch arg := args[0].(type) {
-			case *object.Array:
-				return &object.Integer{Value: int64(len(arg.Elements))}
-			case *object.String:
-				return &object.Integer{Value: int64(len(arg.Value))}
-			default:
-				return newError("argument to `len` not supported. got=%s", args[0].Type())
-			}
-		},
-	},
-	"first": {
-		// Get the first element of an array.
-		Fn: func(args ...object.Object) object.Object {
-			if len(args) != 1 {
-				return newError("wrong number of arguments. got=%d, want=1", len(args))
-			}
-
-			array, ok := args[0].(*object.Array)
-			if !ok {
-				return newError(
-					"'first' only accepts an array as an argument. got=%s",
-					args[0].Type(),
-				)
-			}
-
-			if len(array.Elements) == 0 {
-				return NULL
-			}
-
-			return array.Elements[0]
-		},
-	},
-	"last": {
-		// Get the last element of an array.
-		Fn: func(args ...object.Object) object.Object {
-			if len(args) != 1 {
-				return newError("wrong number of arguments. got=%d, want=1", len(args))
-			}
-
-			array, ok := args[0].(*object.Array)
-			if !ok {
-				return newError(
-					"'last' only accepts an array as an argument. got=%s",
-					args[0].Type(),
-				)
-			}
-
-			length := len(array.Elements)
-			if length == 0 {
-				return NULL
-			}
-
-			return array.Elements[length-1]
-		},
-	},
-	"rest": {
-		// Return a copy of the array with the first element removed.
-		Fn: func(args ...object.Object) object.Object {
-			if len(args) != 1 {
-				return newError("wrong number of arguments. got=%d, want=1", len(args))
-			}
-
-			array, ok := args[0].(*object.Array)
-			if !ok {
-				return newError(
-					"'rest' only accepts an array as an argument. got=%s",
-					args[0].Type(),
-				)
-			}
-
-			length := len(array.Elements)
-			if length == 0 {
-				return NULL
-			}
-
-			elements := make([]object.Object, length-1)
-
-			if length == 1 {
-				return &object.Array{Elements: elements}
-			}
-
-			copy(elements, array.Elements[1:])
-			return &object.Array{Elements: elements}
-		},
-	},
-	"push": {
-		// Return a cloned array with a new value appended to it.
-		Fn: func(args ...object.Object) object.Object {
-			if len(args) < 2 {
-				return newError("wrong number of arguments. got=%d, want=>2", len(args))
-			}
+import "github.com/grantwforsythe/monkeylang/pkg/object"
+
+// builtin contains the builtins that are specific to the evaluator, i.e. `eval`, which evaluates source in the
+// environment of its caller. It takes the place of the `eval` in object.Builtins, which reports that the virtual
+// machine does not support it. All other builtins are shared with the virtual machine and defined in object.Builtins.
+var builtin = map[string]*object.Builtin{}
+
+// The builtins are registered in init because evaluating source would otherwise form an initialization cycle:
+// builtin -> evalSource -> Eval -> evalIdentifier -> builtin.
+func init() {
+	evalBuiltin.Fn = func(rt *object.Runtime, args ...object.Object) object.Object {
+		return evalSource(args, nil, rt)
+	}
+	builtin["eval"] = evalBuiltin
+}
 
-			array, ok := args[0].(*object.Array)
+// evalBuiltin evaluates source code, either in the environment of the caller or in a fresh environment created from a
+// hash of bindings, e.g. `eval("x + 1")` or `eval("x + 1", {"x": 1})`.
+// Calls to `eval` are recognized by the evaluator so that the environment of the caller is available. Calling it
+// indirectly, e.g. `map(sources, eval)`, always uses a fresh environment.
+var evalBuiltin = &object.Builtin{}
+
+// evalSource parses and evaluates source code.
+// The source is evaluated in env unless it is nil or bindings are passed, in which case a fresh environment is used.
+func evalSource(args []object.Object, env *object.Environment, rt *object.Runtime) object.Object {
+	if len(args) != 1 && len(args) != 2 {
+		return newError("wrong number of arguments. got=%d, want=1 or 2", len(args))
+	}
+
+	source, ok := args[0].(*object.String)
+	if !ok {
+		return newError("argument 1 to `eval` must be STRING. got=%s", args[0].Type())
+	}
+
+	if env == nil || len(args) == 2 {
+		env = object.NewEnvironmentWithRuntime(rt)
+	}
+
+	if len(args) == 2 {
+		bindings, ok := args[1].(*object.Hash)
+		if !ok {
+			return newError("argument 2 to `eval` must be HASH. got=%s", args[1].Type())
+		}
+
+		for _, pair := range bindings.Pairs() {
+			name, ok := pair.Key.(*object.String)
 			if !ok {
-				return newError(
-					"the first argument needs to be of type ARRAY. got=%s",
-					args[0].Type(),
-				)
+				return newError("'eval' binding names must be STRING. got=%s", pair.Key.Type())
 			}
+			env.Set(name.Value, pair.Value)
+		}
+	}
 
-			length := len(array.Elements)
+	program, err := object.ParseSource(source.Value)
+	if err != nil {
+		return err
+	}
 
-			elements := make([]object.Object, length)
-			copy(elements, array.Elements)
-			elements = append(elements, args[1:]...)
+	result := Eval(program, env)
+	if result == nil {
+		return NULL
+	}
 
-			return &object.Array{Elements: elements}
-		},
-	},
-	"quit": {
-		Fn: func(args ...object.Object) object.Object {
-			os.Exit(0)
-			return &object.Null{}
-		},
-	},
-	"puts": {
-		Fn: func(args ...object.Object) object.Object {
-			for _, arg := range args {
-				fmt.Println(arg.Inspect())
-			}
-			return NULL
-		},
-	},
+	return result
 }
@@ -7,7 +7,9 @@ package evaluator
 // the returned value. A better approach would be to exit early (how would that work here?) or treeshake the AST after parsing
 
 import (
+	"context"
 	"fmt"
+	"strings"
 
 	"github.com/grantwforsythe/monkeylang/pkg/ast"
 	"github.com/grantwforsythe/monkeylang/pkg/object"
@@ -16,13 +18,29 @@ import (
 // NOTE: env can be refactored into the package scope so it does not need to be passed around
 
 var (
-	TRUE  = &object.Boolean{Value: true}
-	FALSE = &object.Boolean{Value: false}
-	NULL  = &object.Null{}
+	TRUE  = object.TRUE
+	FALSE = object.FALSE
+	NULL  = object.NULL
 )
 
+// EvalContext evaluates an AST the same way as Eval, stopping with an error once the context is done.
+func EvalContext(ctx context.Context, node ast.Node, env *object.Environment) object.Object {
+	rt := env.Runtime()
+
+	previous := rt.Context()
+	rt.SetContext(ctx)
+	defer rt.SetContext(previous)
+
+	return Eval(node, env)
+}
+
 // Eval recursively walks an AST evaluating each node into their respective objects.
+// Every node counts as a step towards the step limit of the runtime.
 func Eval(node ast.Node, env *object.Environment) object.Object {
+	if err := env.Runtime().Step(); err != nil {
+		return err
+	}
+
 	switch node := node.(type) {
 
 	case *ast.Program:
@@ -50,7 +68,7 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 			return right
 		}
 
-		return evalInfixExpression(node.Operator, left, right)
+		return allocate(evalInfixExpression(node.Operator, left, right), env.Runtime())
 
 	case *ast.BlockStatement:
 		return evalBlockStatement(node, env)
@@ -58,29 +76,26 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 	case *ast.IfExpression:
 		return evalIfExpression(node, env)
 
-	case *ast.FunctionLiteral:
-		return &object.Function{Body: node.Body, Env: env, Parameters: node.Parameters}
+	case *ast.WhileExpression:
+		return evalWhileExpression(node, env)
 
-	case *ast.CallExpression:
-		// TODO: Refactor this
-		// Skip evaluation of argument when calling `quote`
-		// Quote only accepts one argument
-		if node.Function.TokenLiteral() == "quote" {
-			return quote(node.Arguments[0], env)
+	case *ast.AssignExpression:
+		value := Eval(node.Value, env)
+		if isError(value) {
+			return value
 		}
 
-		fn := Eval(node.Function, env)
-		if isError(fn) {
-			return fn
+		if !env.Assign(node.Name.Value, value) {
+			return newError("cannot assign to undefined variable %s", node.Name.Value)
 		}
 
-		// Evaluate the arguments
-		args := evalExpressions(node.Arguments, env)
-		if len(args) == 1 && isError(args[0]) {
-			return args[0]
-		}
+		return value
 
-		return applyFunction(fn, args)
+	case *ast.FunctionLiteral:
+		return &object.Function{Body: node.Body, Env: env, Parameters: node.Parameters, Name: node.Name}
+
+	case *ast.CallExpression:
+		return evalCallExpression(node, env, false)
 
 	case *ast.ReturnStatement:
 		value := Eval(node.ReturnValue, env)
@@ -98,6 +113,19 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 
 		env.Set(node.Name.String(), value)
 
+	case *ast.ImportStatement:
+		module := env.Runtime().ImportModule(node.Path.Value, func(program *ast.Program) object.Object {
+			return evalModule(program, env.Runtime())
+		})
+		if isError(module) {
+			return module
+		}
+
+		env.Set(node.Name.Value, module)
+
+	case *ast.ExportStatement:
+		return Eval(node.Statement, env)
+
 	case *ast.Identifier:
 		return evalIdentifier(node, env)
 
@@ -107,9 +135,15 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 	case *ast.IntegerLiteral:
 		return &object.Integer{Value: node.Value}
 
+	case *ast.FloatLiteral:
+		return &object.Float{Value: node.Value}
+
 	case *ast.StringLiteral:
 		return &object.String{Value: node.Value}
 
+	case *ast.TemplateLiteral:
+		return evalTemplateLiteral(node, env)
+
 	case *ast.ArrayLiteral:
 		elements := evalExpressions(node.Elements, env)
 
@@ -117,7 +151,7 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 			return elements[0]
 		}
 
-		return &object.Array{Elements: elements}
+		return allocate(&object.Array{Elements: elements}, env.Runtime())
 
 	case *ast.IndexEpression:
 		left := Eval(node.Left, env)
@@ -132,36 +166,39 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 
 		return evalIndexExpression(left, index)
 
-	case *ast.HashLiteral:
-		hash := &object.Hash{}
-		hash.Pairs = make(map[object.HashKey]object.HashPair)
+	case *ast.MemberExpression:
+		left := Eval(node.Left, env)
+		if isError(left) {
+			return left
+		}
 
-		for key, value := range node.Pairs {
-			keyObj := Eval(key, env)
-			if isError(keyObj) {
-				return keyObj
-			}
+		// `hash.name` is shorthand for `hash["name"]`
+		return evalIndexExpression(left, &object.String{Value: node.Property.Value})
 
-			valueObj := Eval(value, env)
-			if isError(valueObj) {
-				return valueObj
-			}
+	case *ast.HashLiteral:
+		return evalHashLiteral(node, env)
+	}
 
-			keyHash, ok := keyObj.(object.Hashable)
-			if !ok {
-				return newError("unhashable key: %s", keyObj.Type())
-			}
+	return nil
+}
 
-			hash.Pairs[keyHash.HashKey()] = object.HashPair{
-				Key:   keyObj,
-				Value: valueObj,
-			}
-		}
+// evalModule evaluates a module in its own environment.
+// Returns a module of the bindings it exports.
+func evalModule(program *ast.Program, rt *object.Runtime) object.Object {
+	env := object.NewEnvironmentWithRuntime(rt)
 
-		return hash
+	result := Eval(program, env)
+	if isError(result) {
+		return result
 	}
 
-	return nil
+	exports := &object.Hash{}
+	for _, name := range object.Exports(program) {
+		value, _ := env.Get(name)
+		exports.Set(&object.String{Value: name}, value)
+	}
+
+	return &object.Module{Exports: exports}
 }
 
 func evalProgram(program *ast.Program, env *object.Environment) object.Object {
@@ -173,7 +210,7 @@ func evalProgram(program *ast.Program, env *object.Environment) object.Object {
 		switch obj := result.(type) {
 		case *object.ReturnValue:
 			return obj.Value
-		case *object.Error:
+		case *object.Error, *object.Exit:
 			return obj
 		}
 	}
@@ -202,8 +239,11 @@ func evalPrefixExpression(operator string, right object.Object) object.Object {
 			return FALSE
 		}
 	case "-":
-		if right, ok := right.(*object.Integer); ok {
+		switch right := right.(type) {
+		case *object.Integer:
 			return &object.Integer{Value: -1 * right.Value}
+		case *object.Float:
+			return &object.Float{Value: -1 * right.Value}
 		}
 		return newError("unknown operator: -%s", right.Type())
 	default:
@@ -211,6 +251,81 @@ func evalPrefixExpression(operator string, right object.Object) object.Object {
 	}
 }
 
+// evalBody evaluates the body of a function, or a block of an if expression in it, the same way as evalBlockStatement
+// but with the calls in tail position evaluating to tail calls. A call is in tail position if it is returned or if it is
+// the last expression of the body. tail reports whether the value of the block is the value of the function.
+func evalBody(node *ast.BlockStatement, env *object.Environment, tail bool) object.Object {
+	var result object.Object
+
+	for i, stmt := range node.Statements {
+		last := tail && i == len(node.Statements)-1
+
+		switch stmt := stmt.(type) {
+		case *ast.ReturnStatement:
+			result = evalTailExpression(stmt.ReturnValue, env, true)
+			if !isError(result) && !isTailCall(result) {
+				result = &object.ReturnValue{Value: result}
+			}
+		case *ast.ExpressionStatement:
+			result = evalTailExpression(stmt.Expression, env, last)
+		default:
+			result = Eval(stmt, env)
+		}
+
+		if result == nil {
+			continue
+		}
+
+		if result.Type() == object.RETURN_VALUE_OBJ || isError(result) || isTailCall(result) {
+			return result
+		}
+	}
+
+	return result
+}
+
+// evalTailExpression evaluates an expression of the body of a function.
+// Calls in tail position evaluate to tail calls, and so do the ones returned from the blocks of an if expression.
+func evalTailExpression(node ast.Expression, env *object.Environment, tail bool) object.Object {
+	switch node := node.(type) {
+	case *ast.CallExpression:
+		return evalCallExpression(node, env, tail)
+
+	case *ast.IfExpression:
+		condition := Eval(node.Condition, env)
+		if isError(condition) {
+			return condition
+		}
+
+		if isTruthy(condition) {
+			return evalBody(node.Consequence, env, tail)
+		} else if node.Alternative != nil {
+			return evalBody(node.Alternative, env, tail)
+		} else {
+			return NULL
+		}
+
+	default:
+		return Eval(node, env)
+	}
+}
+
+// tailCall is a call in tail position that has not been made yet.
+// It is returned from the body of a function in place of the result of the call, so that applyFunction can make the
+// call after the function has returned.
+type tailCall struct {
+	fn   object.Object
+	args []object.Object
+}
+
+func (tc *tailCall) Type() object.ObjectType { return "TAIL_CALL" }
+func (tc *tailCall) Inspect() string         { return "tail call" }
+
+func isTailCall(obj object.Object) bool {
+	_, ok := obj.(*tailCall)
+	return ok
+}
+
 func evalBlockStatement(node *ast.BlockStatement, env *object.Environment) object.Object {
 	var result object.Object
 
@@ -221,7 +336,7 @@ func evalBlockStatement(node *ast.BlockStatement, env *object.Environment) objec
 			continue
 		}
 
-		if result.Type() == object.RETURN_VALUE_OBJ || result.Type() == object.ERROR_OBJ {
+		if result.Type() == object.RETURN_VALUE_OBJ || isError(result) {
 			return result
 		}
 	}
@@ -234,6 +349,8 @@ func evalInfixExpression(operator string, left, right object.Object) object.Obje
 	switch {
 	case left.Type() == object.INTEGER_OBJ && right.Type() == object.INTEGER_OBJ:
 		return evalIntegerInfixExpression(operator, left, right)
+	case isNumber(left) && isNumber(right):
+		return evalFloatInfixExpression(operator, left, right)
 	case left.Type() == object.STRING_OBJ && right.Type() == object.STRING_OBJ:
 		return evalStringInfixExpression(operator, left, right)
 	case operator == "==":
@@ -260,7 +377,40 @@ func evalIntegerInfixExpression(operator string, left, right object.Object) obje
 	case "*":
 		return &object.Integer{Value: lValue * rValue}
 	case "/":
-		return &object.Integer{Value: lValue / rValue}
+		quotient, err := object.DivideIntegers(lValue, rValue)
+		if err != nil {
+			return err
+		}
+
+		return &object.Integer{Value: quotient}
+	case "<":
+		return evalBooleanExpression(lValue < rValue)
+	case ">":
+		return evalBooleanExpression(lValue > rValue)
+	case "==":
+		return evalBooleanExpression(lValue == rValue)
+	case "!=":
+		return evalBooleanExpression(lValue != rValue)
+	default:
+		return newError("unknown operator: %s %s %s", left.Type(), operator, right.Type())
+	}
+}
+
+// evalFloatInfixExpression evaluates an infix expression where either operand is a float.
+// Integer operands are converted to floats.
+func evalFloatInfixExpression(operator string, left, right object.Object) object.Object {
+	lValue, _ := object.AsFloat(left)
+	rValue, _ := object.AsFloat(right)
+
+	switch operator {
+	case "+":
+		return &object.Float{Value: lValue + rValue}
+	case "-":
+		return &object.Float{Value: lValue - rValue}
+	case "*":
+		return &object.Float{Value: lValue * rValue}
+	case "/":
+		return &object.Float{Value: lValue / rValue}
 	case "<":
 		return evalBooleanExpression(lValue < rValue)
 	case ">":
@@ -274,6 +424,10 @@ func evalIntegerInfixExpression(operator string, left, right object.Object) obje
 	}
 }
 
+func isNumber(obj object.Object) bool {
+	return obj.Type() == object.INTEGER_OBJ || obj.Type() == object.FLOAT_OBJ
+}
+
 func evalStringInfixExpression(operator string, left, right object.Object) object.Object {
 	lValue := left.(*object.String).Value
 	rValue := right.(*object.String).Value
@@ -305,6 +459,26 @@ func evalIfExpression(node *ast.IfExpression, env *object.Environment) object.Ob
 	}
 }
 
+// evalWhileExpression evaluates the body of a loop for as long as its condition is truthy.
+// The body does not have its own scope, so closures created by different iterations share its bindings.
+func evalWhileExpression(node *ast.WhileExpression, env *object.Environment) object.Object {
+	for {
+		condition := Eval(node.Condition, env)
+		if isError(condition) {
+			return condition
+		}
+
+		if !isTruthy(condition) {
+			return NULL
+		}
+
+		result := Eval(node.Body, env)
+		if result != nil && (result.Type() == object.RETURN_VALUE_OBJ || isError(result)) {
+			return result
+		}
+	}
+}
+
 func isTruthy(obj object.Object) bool {
 	switch obj {
 	case NULL:
@@ -314,20 +488,37 @@ func isTruthy(obj object.Object) bool {
 	case FALSE:
 		return false
 	default:
-		// There are currently only 3 object types: Integer, Boolean, and Null
-		// If obj is not of type Boolean or Null, then we know it has to be of type Integer
-		// TODO: Handle the case for more object types
-		return obj.(*object.Integer).Value > 0
+		switch obj := obj.(type) {
+		case *object.Integer:
+			return obj.Value > 0
+		case *object.Float:
+			return obj.Value > 0
+		}
+
+		// Every other object, e.g. strings, arrays, and functions, is truthy
+		return true
 	}
 }
 
+// allocate records an object created by the program in its runtime.
+// Returns an error instead of the object if the program exceeded its allocation limit.
+func allocate(obj object.Object, rt *object.Runtime) object.Object {
+	if err := rt.Allocate(obj); err != nil {
+		return err
+	}
+
+	return obj
+}
+
 func newError(format string, a ...any) *object.Error {
 	return &object.Error{Message: fmt.Sprintf(format, a...)}
 }
 
+// isError checks if an object stops the evaluation of a program.
+// Besides errors, the exit object returned by `quit` unwinds the program the same way.
 func isError(obj object.Object) bool {
 	if obj != nil {
-		return obj.Type() == object.ERROR_OBJ
+		return obj.Type() == object.ERROR_OBJ || obj.Type() == object.EXIT_OBJ
 	}
 	return false
 }
@@ -341,6 +532,10 @@ func evalIdentifier(node *ast.Identifier, env *object.Environment) object.Object
 		return builtin
 	}
 
+	if builtin, ok := env.Runtime().LookupBuiltin(node.Value); ok {
+		return builtin
+	}
+
 	return newError("identifier not found: %s", node.Value)
 }
 
@@ -359,30 +554,147 @@ func evalExpressions(expressions []ast.Expression, env *object.Environment) []ob
 	return result
 }
 
-func applyFunction(fn object.Object, args []object.Object) object.Object {
-	switch fn := fn.(type) {
-	case *object.Function:
+// evalTemplateLiteral concatenates the text of a template literal with the string representation of each interpolated
+// expression.
+func evalTemplateLiteral(node *ast.TemplateLiteral, env *object.Environment) object.Object {
+	var out strings.Builder
 
-		// Assign the arguments to their corresponding parameter
-		enclosedEnv := object.NewEnclosedEnvironment(fn.Env)
-		for paramIdx, param := range fn.Parameters {
-			enclosedEnv.Set(param.Value, args[paramIdx])
+	for _, part := range node.Parts {
+		eval := Eval(part, env)
+		if isError(eval) {
+			return eval
 		}
 
-		eval := Eval(fn.Body, enclosedEnv)
+		out.WriteString(eval.Inspect())
+	}
+
+	return allocate(&object.String{Value: out.String()}, env.Runtime())
+}
+
+// evalCallExpression evaluates a call.
+// A call to a function defined in Monkey in tail position is not made, instead it evaluates to a tail call for
+// applyFunction to make once the function making it has returned.
+func evalCallExpression(node *ast.CallExpression, env *object.Environment, tail bool) object.Object {
+	// TODO: Refactor this
+	// Skip evaluation of argument when calling `quote`
+	// Quote only accepts one argument
+	if node.Function.TokenLiteral() == "quote" {
+		return quote(node.Arguments[0], env)
+	}
+
+	fn := Eval(node.Function, env)
+	if isError(fn) {
+		return fn
+	}
 
-		if result, ok := eval.(*object.ReturnValue); ok {
-			return result.Value
+	// Evaluate the arguments
+	args := evalExpressions(node.Arguments, env)
+	if len(args) == 1 && isError(args[0]) {
+		return args[0]
+	}
+
+	if fn == evalBuiltin {
+		return evalSource(args, env, env.Runtime())
+	}
+
+	if _, ok := fn.(*object.Function); ok && tail {
+		return &tailCall{fn: fn, args: args}
+	}
+
+	return applyFunction(fn, args, env.Runtime())
+}
+
+// Apply calls a function with arguments, such as a function defined by a program evaluated earlier.
+// Builtins are passed the runtime.
+func Apply(fn object.Object, args []object.Object, rt *object.Runtime) object.Object {
+	return applyFunction(fn, args, rt)
+}
+
+// applyFunction calls a function with arguments.
+// Builtins are passed the runtime of the caller.
+//
+// It is a trampoline: when the body of a function evaluates to a tail call, the call is made by the next iteration of
+// the loop instead of a nested call, so recursion in tail position runs in constant Go stack space.
+func applyFunction(fn object.Object, args []object.Object, rt *object.Runtime) object.Object {
+	// calling reports whether a call is recorded in the runtime. A tail call replaces it instead of nesting.
+	calling := false
+	defer func() {
+		if calling {
+			rt.LeaveCall()
 		}
+	}()
 
-		// TODO: Figure out what could be returned here
-		return eval
-	// TODO: Figure out why this works here
-	case *object.Builtin:
-		return fn.Fn(args...)
-	default:
-		return newError("not a function: %s", fn.Type())
+	for {
+		switch callee := fn.(type) {
+		case *object.Function:
+
+			if len(args) != len(callee.Parameters) {
+				return newError("wrong number of arguments. got=%d, want=%d", len(args), len(callee.Parameters))
+			}
+
+			if calling {
+				rt.LeaveCall()
+				calling = false
+			}
+
+			if err := rt.EnterCall(callee.Name); err != nil {
+				return err
+			}
+			calling = true
+
+			// Assign the arguments to their corresponding parameter
+			enclosedEnv := object.NewEnclosedEnvironment(callee.Env)
+			for paramIdx, param := range callee.Parameters {
+				enclosedEnv.Set(param.Value, args[paramIdx])
+			}
+
+			eval := evalBody(callee.Body, enclosedEnv, true)
+
+			if call, ok := eval.(*tailCall); ok {
+				fn, args = call.fn, call.args
+				continue
+			}
+
+			if result, ok := eval.(*object.ReturnValue); ok {
+				return result.Value
+			}
+
+			// TODO: Figure out what could be returned here
+			return eval
+		// TODO: Figure out why this works here
+		case *object.Builtin:
+			rt.SetCaller(applyFunction)
+			return callee.Call(rt, args...)
+		default:
+			return newError("not a function: %s", callee.Type())
+		}
+	}
+}
+
+// evalHashLiteral evaluates the pairs of a hash literal in the order they appear in the source.
+func evalHashLiteral(node *ast.HashLiteral, env *object.Environment) object.Object {
+	hash := &object.Hash{}
+
+	for _, pair := range node.Pairs {
+		key := Eval(pair.Key, env)
+		if isError(key) {
+			return key
+		}
+
+		value := Eval(pair.Value, env)
+		if isError(value) {
+			return value
+		}
+
+		hashKey, ok := key.(object.Hashable)
+		if !ok {
+			return newError("unhashable key: %s", key.Type())
+		}
+
+		hash.Set(hashKey, value)
 	}
+
+	return allocate(hash, env.Runtime())
 }
 
 func evalIndexExpression(left, index object.Object) object.Object {
@@ -405,12 +717,14 @@ func evalIndexExpression(left, index object.Object) object.Object {
 			return newError("unhashable key: %s", index.Type())
 		}
 
-		value, ok := left.Pairs[idx.HashKey()]
+		value, ok := left.Get(idx)
 		if !ok {
 			return NULL
 		}
 
 		return value.Value
+	case left.Type() == object.MODULE_OBJ:
+		return left.(*object.Module).Export(index)
 	default:
 		return newError("index operator not supported: %s", left.Type())
 	}
@@ -1,7 +1,15 @@
 package evaluator
 
 import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/grantwforsythe/monkeylang/pkg/lexer"
 	"github.com/grantwforsythe/monkeylang/pkg/object"
@@ -120,6 +128,39 @@ func TestEvalIfElseExpression(t *testing.T) {
 	}
 }
 
+func TestEvalTruthiness(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected any
+	}{
+		{`if ("") { 10 }`, 10},
+		{"if ([]) { 10 }", 10},
+		{"if ({}) { 10 }", 10},
+		{"if (fn() {}) { 10 }", 10},
+		{"if (len) { 10 }", 10},
+	}
+
+	for _, tt := range tests {
+		testExpected(t, tt.input, testEval(tt.input), tt.expected)
+	}
+}
+
+func TestEvalFunctionArity(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected any
+	}{
+		{"fn(x) { x }(1)", 1},
+		{"fn(x) { x }()", errorMessage("wrong number of arguments. got=0, want=1")},
+		{"fn(x) { x }(1, 2)", errorMessage("wrong number of arguments. got=2, want=1")},
+		{"let add = fn(a, b) { a + b }; add(1)", errorMessage("wrong number of arguments. got=1, want=2")},
+	}
+
+	for _, tt := range tests {
+		testExpected(t, tt.input, testEval(tt.input), tt.expected)
+	}
+}
+
 func TestEvalReturnStatements(t *testing.T) {
 	tests := []struct {
 		input    string
@@ -187,7 +228,10 @@ func TestEvalErrorHandling(t *testing.T) {
 			"unhashable key: NULL",
 		},
 		{`{"a": 1}[fn(x) { x }]`, "unhashable key: FUNCTION"},
+		{"fn() {} + 1", "type mismatch: FUNCTION + INTEGER"},
 		{"1[0]", "index operator not supported: INTEGER"},
+		{"1 / 0", "division by zero"},
+		{"let min = -9223372036854775807 - 1; min / -1", "integer overflow: -9223372036854775808 / -1"},
 	}
 
 	for _, tt := range tests {
@@ -293,6 +337,225 @@ func TestEvalFunctionClosures(t *testing.T) {
 	testIntegerObject(t, testEval(input), 17)
 }
 
+func TestEvalAssignmentsAndClosures(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected any
+	}{
+		{"let counter = fn() { let n = 0; fn() { n = n + 1 } }; let c = counter(); c(); c(); c()", 3},
+		{"let counter = fn() { let n = 0; fn() { n = n + 1 } }; let a = counter(); let b = counter(); a(); a(); b()", 1},
+		{"let pair = fn() { let n = 0; [fn() { n = n + 1 }, fn() { n }] }; let p = pair(); p[0](); p[0](); p[1]()", 2},
+		{"let outer = fn() { let x = 1; let f = fn() { x }; x = 2; f }; outer()()", 2},
+		{"let outer = fn() { let x = 1; let f = fn() { x }; let x = 2; f() }; outer()", 2},
+		{"let x = 1; let f = fn() { x }; let x = 2; f()", 2},
+		{"let total = 0; let add = fn(x) { total = total + x }; add(2); add(3); total", 5},
+		{"let a = fn(x) { fn() { fn() { x = x + 1 } } }; let inc = a(1)(); inc(); inc()", 3},
+		{"let f = fn() { let fact = fn(n) { if (n < 2) { 1 } else { n * fact(n - 1) } }; fact(5) }; f()", 120},
+		{"let a = 1; let b = a = 5; a + b", 10},
+		{"let i = 0; let sum = 0; while (i < 5) { sum = sum + i; i = i + 1 }; sum", 10},
+		{"while (false) { 1 }", nil},
+		{"let f = fn() { let i = 0; while (true) { if (i == 3) { return i; }; i = i + 1 } }; f()", 3},
+		{`
+		let make = fn() {
+			let fns = [];
+			let i = 0;
+			while (i < 3) {
+				let j = i;
+				fns = push(fns, fn() { j });
+				i = i + 1;
+			}
+			fns
+		};
+		let fns = make();
+		fns[0]() + fns[1]() + fns[2]()
+		`, 6},
+		{`
+		let make = fn() {
+			let fns = [];
+			let i = 0;
+			while (i < 3) {
+				fns = push(fns, fn(j) { fn() { j } }(i));
+				i = i + 1;
+			}
+			fns
+		};
+		let fns = make();
+		fns[0]() + fns[1]() + fns[2]()
+		`, 3},
+		{"x = 1", errorMessage("cannot assign to undefined variable x")},
+		{"len = 1", errorMessage("cannot assign to undefined variable len")},
+		{"let x = 1; x = x + true", errorMessage("type mismatch: INTEGER + BOOLEAN")},
+		{"while (1 + true) { 1 }", errorMessage("type mismatch: INTEGER + BOOLEAN")},
+	}
+
+	for _, tt := range tests {
+		testExpected(t, tt.input, testEval(tt.input), tt.expected)
+	}
+}
+
+func TestEvalTailCalls(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected any
+	}{
+		{"let count = fn(n) { if (n == 0) { 0 } else { count(n - 1) } }; count(1000000)", 0},
+		{"let sum = fn(n, acc) { if (n == 0) { return acc; }; return sum(n - 1, acc + n); }; sum(1000000, 0)", 500000500000},
+		{"let loop = fn(n) { if (n > 0) { loop(n - 1) } }; loop(1000000)", nil},
+		{"let last = fn(array) { if (len(array) == 1) { first(array) } else { last(rest(array)) } }; last([1, 2, 3])", 3},
+		{"let f = fn(n, acc) { if (n == 0) { acc } else { let g = fn() { n }; f(n - 1, push(acc, g)) } }; let fs = f(3, []); fs[0]() + fs[1]() + fs[2]()", 6},
+		{"let twice = fn(x) { x * 2 }; let apply = fn(f, x) { f(x) }; apply(twice, 4) + 1", 9},
+		{"let inner = fn() { len([1]) }; inner()", 1},
+		{"let even = fn(n) { if (n == 0) { true } else { odd(n - 1) } }; let odd = fn(n) { if (n == 0) { false } else { even(n - 1) } }; even(1000001)", false},
+		{"let f = fn(n) { if (n == 0) { 1 + true } else { f(n - 1) } }; f(10)", errorMessage("type mismatch: INTEGER + BOOLEAN")},
+	}
+
+	for _, tt := range tests {
+		testExpected(t, tt.input, testEval(tt.input), tt.expected)
+	}
+}
+
+func TestEvalCallDepth(t *testing.T) {
+	tests := []struct {
+		input    string
+		limit    int
+		expected string
+	}{
+		{"let f = fn(n) { f(n + 1) + 1 }; f(0)", 10000, "maximum call depth exceeded. limit=10000, most recent calls: f, f, f, f, f"},
+		{"let f = fn(n) { if (n == 0) { 0 } else { f(n - 1) + 1 } }; f(3)", 3, "maximum call depth exceeded. limit=3, most recent calls: f, f, f, f"},
+		{"let g = fn() { fn() { 1 + 1 }() + 1 }; let f = fn() { g() + 1 }; f()", 2, "maximum call depth exceeded. limit=2, most recent calls: anonymous, g, f"},
+	}
+
+	for _, tt := range tests {
+		rt := object.NewRuntime()
+		rt.MaxCallDepth = tt.limit
+
+		program := parser.New(lexer.New(tt.input)).ParseProgram()
+		testExpected(t, tt.input, Eval(program, object.NewEnvironmentWithRuntime(rt)), errorMessage(tt.expected))
+	}
+
+	// Calls that returned, including the ones that failed, no longer count towards the limit
+	rt := object.NewRuntime()
+	rt.MaxCallDepth = 3
+	env := object.NewEnvironmentWithRuntime(rt)
+	Eval(parser.New(lexer.New("let f = fn(n) { if (n == 0) { 1 + true } else { f(n - 1) + 1 } }; f(2)")).ParseProgram(), env)
+
+	result := Eval(parser.New(lexer.New("f(2)")).ParseProgram(), env)
+	testExpected(t, "f(2)", result, errorMessage("type mismatch: INTEGER + BOOLEAN"))
+}
+
+func TestEvalLimits(t *testing.T) {
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := []struct {
+		input          string
+		ctx            context.Context
+		maxSteps       int64
+		maxAllocations int64
+		expected       error
+	}{
+		{"let x = 0; while (x < 10) { x = x + 1 }; x", context.Background(), 1000, 0, nil},
+		{"while (true) { 1 }", context.Background(), 1000, 0, object.ErrStepLimitExceeded},
+		{"let f = fn() { f() }; f()", context.Background(), 1000, 0, object.ErrStepLimitExceeded},
+		{`let s = ""; while (true) { s = s + "a" }`, context.Background(), 0, 100, object.ErrAllocationLimitExceeded},
+		{"let a = []; while (true) { a = push(a, 1) }", context.Background(), 0, 100, object.ErrAllocationLimitExceeded},
+		{"while (true) { {} }", context.Background(), 0, 100, object.ErrAllocationLimitExceeded},
+		{"while (true) { 1 }", cancelled, 0, 0, context.Canceled},
+	}
+
+	for _, tt := range tests {
+		rt := object.NewRuntime()
+		rt.MaxSteps = tt.maxSteps
+		rt.MaxAllocations = tt.maxAllocations
+
+		program := parser.New(lexer.New(tt.input)).ParseProgram()
+		result := EvalContext(tt.ctx, program, object.NewEnvironmentWithRuntime(rt))
+
+		err, _ := result.(*object.Error)
+		if tt.expected == nil && err != nil {
+			t.Errorf("%s: unexpected error. got=%q", tt.input, err.Message)
+		}
+		if tt.expected != nil && !errors.Is(err, tt.expected) {
+			t.Errorf("%s: wrong error. got=%v, want=%v", tt.input, result, tt.expected)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	result := EvalContext(ctx, parser.New(lexer.New("while (true) { 1 }")).ParseProgram(), object.NewEnvironment())
+	testExpected(t, "while (true) { 1 }", result, errorMessage("program interrupted: context deadline exceeded"))
+}
+
+func TestEvalMemory(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{`"ab" + "cd"`, 36},
+		{"[1, 2]", 56},
+		{`{"a": 1}`, 144},
+		{`let name = "Monkey"; "Hello ${name}!"`, 45},
+		{"push([1], 2)", 96},
+		{"let a = [1]; let b = a; b", 40},
+		{"let f = fn(x) { x * 2 }; f(2)", 0},
+		{`entries({"a": 1})`, 240},
+		{`split("a b", " ")`, 122},
+		{`json_parse("[[1]]")`, 80},
+	}
+
+	for _, tt := range tests {
+		rt := object.NewRuntime()
+		Eval(parser.New(lexer.New(tt.input)).ParseProgram(), object.NewEnvironmentWithRuntime(rt))
+
+		if rt.Memory() != tt.expected {
+			t.Errorf("%s: wrong memory. got=%d, want=%d", tt.input, rt.Memory(), tt.expected)
+		}
+	}
+
+	// Programs that allocate without bound
+	limits := []string{
+		`let s = "a"; while (true) { s = s + s }`,
+		"let a = []; while (true) { a = push(a, 1) }",
+		`while (true) { {"a": 1} }`,
+	}
+
+	for _, input := range limits {
+		rt := object.NewRuntime()
+		rt.MaxMemory = 1 << 20
+
+		result := Eval(parser.New(lexer.New(input)).ParseProgram(), object.NewEnvironmentWithRuntime(rt))
+		if err, ok := result.(*object.Error); !ok || !errors.Is(err, object.ErrMemoryLimitExceeded) {
+			t.Errorf("%s: wrong error. got=%v, want=%v", input, result, object.ErrMemoryLimitExceeded)
+		}
+		testExpected(t, input, result, errorMessage("memory limit exceeded. limit=1048576"))
+	}
+
+	// Builtins that would create a result past the limit are stopped before they create it
+	builtins := []string{
+		"range(0, 300000000)",
+		`repeat("ab", 300000000)`,
+		"let a = range(0, 50000); concat(a, a)",
+		"flatten([range(0, 40000), range(0, 40000)])",
+		"zip(range(0, 20000), range(0, 20000))",
+		`join(range(0, 40000), repeat(" ", 20))`,
+		`split(repeat("a", 40000), "")`,
+		`json_parse("[" + repeat("[],", 40000) + "[]]")`,
+	}
+
+	for _, input := range builtins {
+		rt := object.NewRuntime()
+		rt.MaxMemory = 1 << 20
+
+		result := Eval(parser.New(lexer.New(input)).ParseProgram(), object.NewEnvironmentWithRuntime(rt))
+		testExpected(t, input, result, errorMessage("memory limit exceeded. limit=1048576"))
+
+		if rt.Memory() > rt.MaxMemory {
+			t.Errorf("%s: allocated past the limit. got=%d", input, rt.Memory())
+		}
+	}
+}
+
 func TestEvalStringObject(t *testing.T) {
 	tests := []struct {
 		input    string
@@ -668,26 +931,40 @@ func TestEvalHashLiterals(t *testing.T) {
 		t.Fatalf("Eval didn't return Hash. got=%T (%+v)", evaluated, evaluated)
 	}
 
-	expected := map[object.HashKey]int64{
-		(&object.String{Value: "one"}).HashKey():   1,
-		(&object.String{Value: "two"}).HashKey():   2,
-		(&object.String{Value: "three"}).HashKey(): 3,
-		(&object.Integer{Value: 4}).HashKey():      4,
-		TRUE.HashKey():                             5,
-		FALSE.HashKey():                            6,
+	expected := []struct {
+		key   object.Hashable
+		value int64
+	}{
+		{&object.String{Value: "one"}, 1},
+		{&object.String{Value: "two"}, 2},
+		{&object.String{Value: "three"}, 3},
+		{&object.Integer{Value: 4}, 4},
+		{TRUE, 5},
+		{FALSE, 6},
 	}
 
-	if len(result.Pairs) != len(expected) {
-		t.Fatalf("Hash has wrong num of pairs. got=%d", len(result.Pairs))
+	if result.Len() != len(expected) {
+		t.Fatalf("Hash has wrong num of pairs. got=%d", result.Len())
 	}
 
-	for expectedKey, expectedValue := range expected {
-		pair, ok := result.Pairs[expectedKey]
+	for i, expectedPair := range expected {
+		pair, ok := result.Get(expectedPair.key)
 		if !ok {
 			t.Errorf("no pair for given key in Pairs")
+			continue
+		}
+
+		testIntegerObject(t, pair.Value, expectedPair.value)
+
+		// Pairs are kept in the order they were written
+		if result.Pairs()[i].Key.Inspect() != expectedPair.key.Inspect() {
+			t.Errorf("pair %d has wrong key. got=%s, expected=%s",
+				i, result.Pairs()[i].Key.Inspect(), expectedPair.key.Inspect())
 		}
+	}
 
-		testIntegerObject(t, pair.Value, expectedValue)
+	if result.Inspect() != "{one: 1, two: 2, three: 3, 4: 4, true: 5, false: 6}" {
+		t.Errorf("hash inspected in the wrong order. got=%s", result.Inspect())
 	}
 }
 
@@ -737,6 +1014,640 @@ func TestHashIndexExpressions(t *testing.T) {
 	}
 }
 
+func TestEvalBuiltinHashFunctions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected any
+	}{
+		{`keys({"b": 1, "a": 2})`, "[b, a]"},
+		{`values({"b": 1, "a": 2})`, "[1, 2]"},
+		{`entries({"b": 1, "a": 2})`, "[[b, 1], [a, 2]]"},
+		{`keys({})`, "[]"},
+		{`has({"a": 1}, "a")`, true},
+		{`has({"a": 1}, "b")`, false},
+		{`delete({"a": 1, "b": 2, "c": 3}, "b")`, "{a: 1, c: 3}"},
+		{`delete({"a": 1}, "b")`, "{a: 1}"},
+		{`let h = {"a": 1, "b": 2}; delete(h, "a"); h`, "{a: 1, b: 2}"},
+		{`delete({"a": 1, "b": 2, "c": 3}, "a")["c"]`, 3},
+		{`merge({"a": 1, "b": 2}, {"b": 3, "c": 4})`, "{a: 1, b: 3, c: 4}"},
+		{`merge({}, {"a": 1}, {"a": 2})`, "{a: 2}"},
+		{`keys(1)`, errorMessage("'keys' only accepts a hash as an argument. got=INTEGER")},
+		{`values()`, errorMessage("wrong number of arguments. got=0, want=1")},
+		{`values(1)`, errorMessage("'values' only accepts a hash or set as an argument. got=INTEGER")},
+		{`has([], 1)`, errorMessage("the first argument needs to be of type HASH or SET. got=ARRAY")},
+		{`has({}, fn(x) { x })`, errorMessage("unhashable key: FUNCTION")},
+		{`delete({}, [])`, errorMessage("unhashable key: ARRAY")},
+		{`merge({})`, errorMessage("wrong number of arguments. got=1, want=>2")},
+		{`merge({}, 1)`, errorMessage("argument 2 to `merge` must be HASH. got=INTEGER")},
+	}
+
+	for _, tt := range tests {
+		testExpected(t, tt.input, testEval(tt.input), tt.expected)
+	}
+}
+
+func TestEvalBuiltinSetFunctions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected any
+	}{
+		{`set()`, "set([])"},
+		{`set([1, "a", true, 1, "a"])`, "set([1, a, true])"},
+		{`len(set([1, 2, 2, 3]))`, 3},
+		{`add(set([1]), 2, 1, 3)`, "set([1, 2, 3])"},
+		{`let s = set([1]); add(s, 2); s`, "set([1])"},
+		{`remove(set([1, 2, 3]), 2)`, "set([1, 3])"},
+		{`remove(set([1]), 2)`, "set([1])"},
+		{`has(set([1, 2]), 2)`, true},
+		{`has(set([1, 2]), "2")`, false},
+		{`values(set([3, 1, 2]))`, "[3, 1, 2]"},
+		{`union(set([1, 2]), set([2, 3]))`, "set([1, 2, 3])"},
+		{`intersection(set([1, 2, 3]), set([3, 2, 4]))`, "set([2, 3])"},
+		{`difference(set([1, 2, 3]), set([2]))`, "set([1, 3])"},
+		{`set(1)`, errorMessage("'set' only accepts an array as an argument. got=INTEGER")},
+		{`set([], [])`, errorMessage("wrong number of arguments. got=2, want=0 or 1")},
+		{`set([[1]])`, errorMessage("unhashable element: ARRAY")},
+		{`add(set(), {})`, errorMessage("unhashable element: HASH")},
+		{`add([], 1)`, errorMessage("the first argument needs to be of type SET. got=ARRAY")},
+		{`remove(set(), [])`, errorMessage("unhashable element: ARRAY")},
+		{`union(set(), [])`, errorMessage("argument 2 to `union` must be SET. got=ARRAY")},
+		{`difference(1, set())`, errorMessage("argument 1 to `difference` must be SET. got=INTEGER")},
+		{`intersection(set())`, errorMessage("wrong number of arguments. got=1, want=2")},
+	}
+
+	for _, tt := range tests {
+		testExpected(t, tt.input, testEval(tt.input), tt.expected)
+	}
+}
+
+func TestEvalBuiltinArrayFunctions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected any
+	}{
+		{`map([1, 2, 3], fn(x) { x * 2 })`, "[2, 4, 6]"},
+		{`map([], fn(x) { x })`, "[]"},
+		{`map([[1], [1, 2]], len)`, "[1, 2]"},
+		{`map([1], fn(x) { x + true })`, errorMessage("type mismatch: INTEGER + BOOLEAN")},
+		{`map([1], fn(x, y) { x })`, errorMessage("wrong number of arguments. got=1, want=2")},
+		{`map(1, fn(x) { x })`, errorMessage("argument 1 to `map` must be ARRAY. got=INTEGER")},
+		{`map([1], 1)`, errorMessage("argument 2 to `map` must be FUNCTION. got=INTEGER")},
+		{`filter([1, 2, 3, 4], fn(x) { x > 2 })`, "[3, 4]"},
+		{`filter(["a", "", "b"], fn(x) { len(x) })`, "[a, b]"},
+		{`reduce([1, 2, 3], fn(acc, x) { acc + x })`, 6},
+		{`reduce([1, 2, 3], fn(acc, x) { acc + x }, 10)`, 16},
+		{`reduce([], fn(acc, x) { acc + x }, 0)`, 0},
+		{`reduce([], fn(acc, x) { acc + x })`, errorMessage("'reduce' of an empty array with no initial value")},
+		{`reduce([1])`, errorMessage("wrong number of arguments. got=1, want=2 or 3")},
+		{`sort([3, 1, 2])`, "[1, 2, 3]"},
+		{`sort(["b", "c", "a"])`, "[a, b, c]"},
+		{`sort([3, 1, 2], fn(a, b) { b - a })`, "[3, 2, 1]"},
+		{`let a = [2, 1]; sort(a); a`, "[2, 1]"},
+		{`sort([[1, "b"], [1, "a"], [0, "c"]], fn(a, b) { a[0] - b[0] })`, "[[0, c], [1, b], [1, a]]"},
+		{`sort([[1], [2]])`, errorMessage("cannot compare ARRAY and ARRAY without a comparator")},
+		{`sort([1, 2], fn(a, b) { true })`, errorMessage("comparator for `sort` must return INTEGER. got=BOOLEAN")},
+		{`sort(1)`, errorMessage("argument 1 to `sort` must be ARRAY. got=INTEGER")},
+		{`reverse([1, 2, 3])`, "[3, 2, 1]"},
+		{`reverse(1)`, errorMessage("'reverse' only accepts an array as an argument. got=INTEGER")},
+		{`contains([1, "a", [2]], [2])`, true},
+		{`contains([1, "a"], "b")`, false},
+		{`contains(1, 1)`, errorMessage("the first argument needs to be of type ARRAY or STRING. got=INTEGER")},
+		{`index_of([1, 2, 3], 3)`, 2},
+		{`index_of([1, 2, 3], 4)`, -1},
+		{`index_of([{"a": 1}], {"a": 1})`, 0},
+		{`range(3)`, "[0, 1, 2]"},
+		{`range(2, 5)`, "[2, 3, 4]"},
+		{`range(5, 0, -2)`, "[5, 3, 1]"},
+		{`range(0)`, "[]"},
+		{`range(0, 1, 0)`, errorMessage("'range' step cannot be zero")},
+		{`range("a")`, errorMessage("argument 1 to `range` must be INTEGER. got=STRING")},
+		{`zip([1, 2, 3], ["a", "b"])`, "[[1, a], [2, b]]"},
+		{`zip([1], 2)`, errorMessage("argument 2 to `zip` must be ARRAY. got=INTEGER")},
+		{`flatten([1, [2, [3]], []])`, "[1, 2, [3]]"},
+		{`join([1, "a", true], ", ")`, "1, a, true"},
+		{`join(["a", "b"])`, "ab"},
+		{`join(["a"], 1)`, errorMessage("argument 2 to `join` must be STRING. got=INTEGER")},
+		{`concat([1], [], [2, 3])`, "[1, 2, 3]"},
+		{`concat()`, "[]"},
+		{`concat([1], 2)`, errorMessage("argument 2 to `concat` must be ARRAY. got=INTEGER")},
+		{`slice([1, 2, 3, 4], 1)`, "[2, 3, 4]"},
+		{`slice([1, 2, 3, 4], 1, 3)`, "[2, 3]"},
+		{`slice([1, 2, 3, 4], -2)`, "[3, 4]"},
+		{`slice([1, 2, 3, 4], 3, 1)`, "[]"},
+		{`slice([1, 2], 0, 10)`, "[1, 2]"},
+		{`slice([1, 2], "a")`, errorMessage("argument 2 to `slice` must be INTEGER. got=STRING")},
+	}
+
+	for _, tt := range tests {
+		testExpected(t, tt.input, testEval(tt.input), tt.expected)
+	}
+}
+
+func TestEvalBuiltinMapDoesNotGrowTheStack(t *testing.T) {
+	input := `reduce(map(range(100000), fn(x) { x * 2 }), fn(acc, x) { acc + x })`
+
+	testIntegerObject(t, testEval(input), 9999900000)
+}
+
+func TestEvalBuiltinStringFunctions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected any
+	}{
+		{`split("a,b,,c", ",")`, "[a, b, , c]"},
+		{`split("  a b   c ")`, "[a, b, c]"},
+		{`len(split("", ","))`, 1},
+		{`join(split("a b c"), "-")`, "a-b-c"},
+		{`trim("  monkey ")`, "monkey"},
+		{`trim("--monkey-", "-")`, "monkey"},
+		{`upper("Monkey")`, "MONKEY"},
+		{`lower("Monkey")`, "monkey"},
+		{`replace("a.b.c", ".", "/")`, "a/b/c"},
+		{`contains("monkey", "key")`, true},
+		{`contains("monkey", "ape")`, false},
+		{`starts_with("monkey", "mon")`, true},
+		{`ends_with("monkey", "mon")`, false},
+		{`index_of("monkey", "key")`, 3},
+		{`index_of("monkey", "ape")`, -1},
+		{`repeat("ab", 3)`, "ababab"},
+		{`repeat("ab", 0)`, ""},
+		{`substr("monkey", 1, 3)`, "on"},
+		{`substr("monkey", -3)`, "key"},
+		{`substr("monkey", 4, 100)`, "ey"},
+		{`substr("héllo", 1, 2)`, "é"},
+		{`substr("héllo", -3)`, "llo"},
+		{`index_of("héllo", "l")`, 2},
+		{`len(chars("héllo"))`, 5},
+		{`chars("abc")`, "[a, b, c]"},
+		{`chars("")`, "[]"},
+		{`to_int("42") + 1`, 43},
+		{`to_int(" -7 ")`, -7},
+		{`to_int(5)`, 5},
+		{`to_string(42) + "!"`, "42!"},
+		{`to_string([1, "a"])`, "[1, a]"},
+		{`let line = "1, 2, 3"; reduce(map(split(line, ","), fn(x) { to_int(trim(x)) }), fn(a, b) { a + b })`, 6},
+		{`split(1)`, errorMessage("argument 1 to `split` must be STRING. got=INTEGER")},
+		{`split("a", 1)`, errorMessage("argument 2 to `split` must be STRING. got=INTEGER")},
+		{`upper(1)`, errorMessage("'upper' only accepts a string as an argument. got=INTEGER")},
+		{`lower()`, errorMessage("wrong number of arguments. got=0, want=1")},
+		{`replace("a", "b")`, errorMessage("wrong number of arguments. got=2, want=3")},
+		{`starts_with("a", 1)`, errorMessage("argument 2 to `starts_with` must be STRING. got=INTEGER")},
+		{`contains("a", 1)`, errorMessage("argument 2 to `contains` must be STRING. got=INTEGER")},
+		{`repeat("a", -1)`, errorMessage("'repeat' count cannot be negative. got=-1")},
+		{`substr("a", "b")`, errorMessage("argument 2 to `substr` must be INTEGER. got=STRING")},
+		{`to_int("a")`, errorMessage(`could not convert "a" to an integer`)},
+		{`to_int(true)`, errorMessage("argument to `to_int` not supported. got=BOOLEAN")},
+	}
+
+	for _, tt := range tests {
+		testExpected(t, tt.input, testEval(tt.input), tt.expected)
+	}
+}
+
+func TestEvalFormatAndInterpolation(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected any
+	}{
+		{`format("%d apples", 3)`, "3 apples"},
+		{`format("[%5d|%-5d|%05d]", 42, 42, 42)`, "[   42|42   |00042]"},
+		{`format("%+d", 7)`, "+7"},
+		{`format("%.2f", 3.14159)`, "3.14"},
+		{`format("%8.3f", 2)`, "   2.000"},
+		{`format("%s and %v", "monkey", [1, "a"])`, "monkey and [1, a]"},
+		{`format("[%-6s][%6s]", "ab", "cd")`, "[ab    ][    cd]"},
+		{`format("%.3s", "monkey")`, "mon"},
+		{`format("100%%")`, "100%"},
+		{`format("no verbs")`, "no verbs"},
+		{`format()`, errorMessage("wrong number of arguments. got=0, want=1 or more")},
+		{`format(1)`, errorMessage("argument 1 to `format` must be STRING. got=INTEGER")},
+		{`format("%d", "a")`, errorMessage("argument 2 to `format` must be INTEGER for %d. got=STRING")},
+		{`format("%s %f", "a", true)`, errorMessage("argument 3 to `format` must be INTEGER or FLOAT for %f. got=BOOLEAN")},
+		{`format("%d %d", 1)`, errorMessage(`wrong number of arguments for format "%d %d". got=1, want=2`)},
+		{`format("%d", 1, 2)`, errorMessage(`wrong number of arguments for format "%d". got=2, want=1`)},
+		{`format("%x", 1)`, errorMessage(`unknown verb %x in format "%x"`)},
+		{`format("50%")`, errorMessage(`format "50%" ends with an incomplete verb`)},
+		{`let name = "Monkey"; "Hello ${name}!"`, "Hello Monkey!"},
+		{`let x = 2; "${x} + ${x} = ${x + x}"`, "2 + 2 = 4"},
+		{`"${[1, 2]} ${ {"a": true}["a"] } ${1.5}"`, "[1, 2] true 1.5"},
+		{`"${"nested ${upper("x")}"}"`, "nested X"},
+		{`let f = fn(n) { "n=${n}" }; f(3)`, "n=3"},
+		{`"${missing}"`, errorMessage("identifier not found: missing")},
+		{`"a $ b {c}"`, "a $ b {c}"},
+		{`let x = 1; "$${x} is ${x}"`, "${x} is 1"},
+	}
+
+	for _, tt := range tests {
+		testExpected(t, tt.input, testEval(tt.input), tt.expected)
+	}
+}
+
+func TestEvalModules(t *testing.T) {
+	loader := object.MapLoader{
+		"util.mk": `
+			let secret = 40;
+			export let answer = secret + 2;
+			export let double = fn(x) { x * 2 };
+			export let greet = fn(name) { "Hello ${name}" };
+			puts("loading util");
+		`,
+		"lib/counter.mk": `import "util.mk" as util; export let doubled = util.double(util.answer);`,
+		"a.mk":           `import "b.mk" as b;`,
+		"b.mk":           `import "./a.mk" as a;`,
+		"self.mk":        `import "self.mk" as me;`,
+		"broken.mk":      `let = 1;`,
+		"failing.mk":     `export let x = 1 + true;`,
+	}
+
+	tests := []struct {
+		input          string
+		expected       any
+		expectedStdout string
+	}{
+		{`import "util.mk" as util; util.answer`, 42, "loading util\n"},
+		{`import "util.mk" as util; [util.double(4), util["greet"]("monkey")]`, "[8, Hello monkey]", "loading util\n"},
+		{`import "util.mk" as util; import "./util.mk" as again; import "lib/counter.mk" as counter; [util == again, counter.doubled]`, "[true, 84]", "loading util\n"},
+		{`import "util.mk" as util; util`, "module(util.mk)", "loading util\n"},
+		{`import "util.mk" as util; util.secret`, errorMessage(`module "util.mk" has no export "secret"`), "loading util\n"},
+		{`import "util.mk" as util; util[1]`, errorMessage("module member must be STRING. got=INTEGER"), "loading util\n"},
+		{`import "a.mk" as a; 1`, errorMessage("import cycle: a.mk -> b.mk -> a.mk"), ""},
+		{`import "self.mk" as me; 1`, errorMessage("import cycle: self.mk -> self.mk"), ""},
+		{`import "missing.mk" as m; 1`, errorMessage(`could not import "missing.mk": file does not exist`), ""},
+		{`import "broken.mk" as m; 1`, errorMessage(`could not import "broken.mk": could not parse source: expected next token to be IDENT. got==; no prefix parse function for =`), ""},
+		{`import "failing.mk" as m; 1`, errorMessage("type mismatch: INTEGER + BOOLEAN"), ""},
+		{`export let x = 5; x`, 5, ""},
+	}
+
+	for _, tt := range tests {
+		var stdout bytes.Buffer
+		rt := object.NewRuntime()
+		rt.Loader = loader
+		rt.Stdout = &stdout
+
+		result := Eval(parser.New(lexer.New(tt.input)).ParseProgram(), object.NewEnvironmentWithRuntime(rt))
+		testExpected(t, tt.input, result, tt.expected)
+
+		if stdout.String() != tt.expectedStdout {
+			t.Errorf("%s: wrong output. got=%q, expected=%q", tt.input, stdout.String(), tt.expectedStdout)
+		}
+	}
+
+	// Imports are not permitted without a loader
+	testExpected(t, "import", testEval(`import "util.mk" as util; 1`), errorMessage(`could not import "util.mk": modules are not enabled`))
+}
+
+func TestEvalFloatExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected any
+	}{
+		{"3.5", "3.5"},
+		{"-2.25", "-2.25"},
+		{"1.5 + 1.5", "3.0"},
+		{"1 + 0.5", "1.5"},
+		{"0.5 * 4", "2.0"},
+		{"7 / 2", 3},
+		{"7 / 2.0", "3.5"},
+		{"1.0 / 0", "+Inf"},
+		{"0.1 + 0.2 > 0.3", true},
+		{"1.5 < 2", true},
+		{"2.0 == 2", true},
+		{"2.5 != 2.5", false},
+		{"if (0.0) { 1 } else { 2 }", 2},
+		{"{1.5: \"a\"}[1.5]", "a"},
+		{`{0.0: "a"}[-0.0]`, "a"},
+		{`{1: "a"}[1.0]`, "a"},
+		{`len(keys({1: "a", 1.0: "b", -0.0: "c", 0: "d"}))`, 2},
+		{`{1: "a", 1.0: "b"}[1]`, "b"},
+		{"to_float(3)", "3.0"},
+		{`to_float(" 2.5 ")`, "2.5"},
+		{"to_int(-2.7)", -2},
+		{"sort([2, 0.5, 1])", "[0.5, 1, 2]"},
+		{"contains([1.5], 1.5)", true},
+		{"1.5 + true", errorMessage("type mismatch: FLOAT + BOOLEAN")},
+		{`to_float("a")`, errorMessage(`could not convert "a" to a float`)},
+	}
+
+	for _, tt := range tests {
+		testExpected(t, tt.input, testEval(tt.input), tt.expected)
+	}
+}
+
+func TestEvalBuiltinMathFunctions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected any
+	}{
+		{"math.abs(-3)", 3},
+		{"math.abs(-1.5)", "1.5"},
+		{"math.min(3, 1, 2)", 1},
+		{"math.max([1, 2.5, 2])", "2.5"},
+		{"math.pow(2, 10)", 1024},
+		{"math.pow(2, -1)", "0.5"},
+		{"math.pow(4, 0.5)", "2.0"},
+		{"math.pow(2, 62)", 4611686018427387904},
+		{"math.pow(-2, 63)", -9223372036854775808},
+		{"math.pow(1, 3000000000)", 1},
+		{"math.pow(-1, 3000000001)", -1},
+		{"math.pow(0, 0)", 1},
+		{"math.sqrt(16)", "4.0"},
+		{"math.floor(2.7)", 2},
+		{"math.floor(-2.5)", -3},
+		{"math.ceil(2.1)", 3},
+		{"math.round(2.5)", 3},
+		{"math.round(7)", 7},
+		{"math.sign(-0.5)", -1},
+		{"math.sign(0)", 0},
+		{"math.gcd(12, -18)", 6},
+		{"math.sin(0)", "0.0"},
+		{"math.cos(math.PI)", "-1.0"},
+		{"math.atan2(1, 1) * 4 == math.PI", true},
+		{"math.log(math.E)", "1.0"},
+		{"math.log10(1000)", "3.0"},
+		{"let m = math; m.abs(-1)", 1},
+		{`math["PI"] == math.PI`, true},
+		{"math.missing", nil},
+		{"math.abs(true)", errorMessage("argument to `abs` must be INTEGER or FLOAT. got=BOOLEAN")},
+		{`math.pow(2, "a")`, errorMessage("argument 2 to `pow` must be INTEGER or FLOAT. got=STRING")},
+		{"math.pow(2, 64)", errorMessage("integer overflow: pow(2, 64)")},
+		{"math.pow(3, 3000000000)", errorMessage("integer overflow: pow(3, 3000000000)")},
+		{"math.floor(100000000000000000000000.5)", errorMessage("cannot convert 1.0000000000000001e+23 to an integer")},
+		{"math.ceil(9223372036854775808.0)", errorMessage("cannot convert 9223372036854776000.0 to an integer")},
+		{"math.floor(-9223372036854775808.0)", -9223372036854775808},
+		{"math.min()", errorMessage("'min' needs at least one number")},
+		{"math.gcd(1.5, 2)", errorMessage("argument 1 to `gcd` must be INTEGER. got=FLOAT")},
+		{"math.sqrt()", errorMessage("wrong number of arguments. got=0, want=1")},
+		{"1.abs", errorMessage("index operator not supported: INTEGER")},
+		{"abs(1)", errorMessage("identifier not found: abs")},
+	}
+
+	for _, tt := range tests {
+		testExpected(t, tt.input, testEval(tt.input), tt.expected)
+	}
+}
+
+func TestEvalBuiltinRandomFunctions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected any
+	}{
+		{"contains(range(1, 7), random_int(1, 6))", true},
+		{"random_int(3, 3)", 3},
+		{"len(to_string(random_int(-9223372036854775807 - 1, 9223372036854775807))) > 0", true},
+		{"contains([1, 2, 3], random_choice([1, 2, 3]))", true},
+		{"random_choice([])", nil},
+		{"sort(shuffle([3, 1, 2]))", "[1, 2, 3]"},
+		{"let a = [1, 2]; shuffle(a); a", "[1, 2]"},
+		{"seed(1)", nil},
+		{"random_int(2, 1)", errorMessage("'random_int' upper bound 1 is less than lower bound 2")},
+		{"random_int(1, 2.5)", errorMessage("argument 2 to `random_int` must be INTEGER. got=FLOAT")},
+		{"shuffle(1)", errorMessage("'shuffle' only accepts an array as an argument. got=INTEGER")},
+		{`seed("a")`, errorMessage("argument to `seed` must be INTEGER. got=STRING")},
+	}
+
+	for _, tt := range tests {
+		testExpected(t, tt.input, testEval(tt.input), tt.expected)
+	}
+}
+
+func TestEvalSeededRandomIsReproducible(t *testing.T) {
+	input := `seed(42); [random_int(0, 1000), random_choice(range(100)), shuffle(range(10))]`
+
+	first := testEval(input).Inspect()
+	for range 3 {
+		if again := testEval(input).Inspect(); again != first {
+			t.Fatalf("seeded runs differ. first=%s, again=%s", first, again)
+		}
+	}
+
+	// Seeding one interpreter does not affect the random numbers of another
+	seeded := object.NewEnvironment()
+	other := object.NewEnvironment()
+	Eval(parser.New(lexer.New("seed(42)")).ParseProgram(), seeded)
+	if seeded.Runtime().Rand == other.Runtime().Rand {
+		t.Fatalf("environments share a random number generator")
+	}
+}
+
+func TestEvalBuiltinJSONFunctions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected any
+	}{
+		{`json_stringify({"name": "monkey", "tags": ["a", "b"], "version": 1.5, "stable": false})`, `{"name":"monkey","tags":["a","b"],"version":1.5,"stable":false}`},
+		{`json_stringify(first([]))`, "null"},
+		{`let config = json_parse(json_stringify({"port": 8080, "hosts": ["a"]})); config["port"] + 1`, 8081},
+		{`json_parse(json_stringify({"z": 1, "a": 2}))`, "{z: 1, a: 2}"},
+		{`json_parse("[1, 2.5, true]")`, "[1, 2.5, true]"},
+		{`json_stringify(fn(x) { x })`, errorMessage("could not convert to JSON: unsupported type FUNCTION")},
+		{`json_stringify(set([1]))`, errorMessage("could not convert to JSON: unsupported type SET")},
+		{`json_parse("[1,")`, errorMessage("could not parse JSON: unexpected end of JSON input")},
+		{`json_parse(1)`, errorMessage("'json_parse' only accepts a string as an argument. got=INTEGER")},
+	}
+
+	for _, tt := range tests {
+		testExpected(t, tt.input, testEval(tt.input), tt.expected)
+	}
+}
+
+func TestEvalBuiltinFileFunctions(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		"config.json": `{"name": "monkey", "version": 2}`,
+		"ports.txt":   "80\n443\n",
+	}
+	for name, contents := range files {
+		if err := os.WriteFile(filepath.Join(root, name), []byte(contents), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	rt := object.NewRuntime()
+	rt.FileSystem = object.FileSystem{Root: root, Read: true, Write: true}
+
+	input := `
+	let config = json_parse(read_file("config.json"));
+	write_file("name.txt", config["name"]);
+	[read_file("name.txt"), map(read_lines("ports.txt"), to_int), list_dir(), exists("missing.txt")]
+	`
+
+	result := Eval(parser.New(lexer.New(input)).ParseProgram(), object.NewEnvironmentWithRuntime(rt))
+	testExpected(t, input, result, "[monkey, [80, 443], [config.json, name.txt, ports.txt], false]")
+
+	// File access is not permitted by default
+	testExpected(t, "read_file", testEval(`read_file("config.json")`), errorMessage(`could not read "config.json": file access is not permitted`))
+}
+
+func TestEvalBuiltinIOFunctions(t *testing.T) {
+	tests := []struct {
+		input          string
+		stdin          string
+		expected       any
+		expectedStdout string
+		expectedCode   int
+		expectedExit   bool
+	}{
+		{`puts("a", 1); puts([1])`, "", nil, "a\n1\n[1]\n", 0, false},
+		{`[gets(), read_line(), gets()]`, "one\r\ntwo", "[one, two, null]", "", 0, false},
+		{`let name = gets(); puts("Hello " + name)`, "monkey\n", nil, "Hello monkey\n", 0, false},
+		{`puts(1); quit(); puts(2)`, "", "exit(0)", "1\n", 0, true},
+		{`let f = fn() { if (true) { quit(3); } puts("unreachable") }; f(); 5`, "", "exit(3)", "", 3, true},
+		{`map([1, 2], fn(x) { quit(x + 40) })`, "", "exit(41)", "", 41, true},
+		{`[1, quit(2), puts(3)]`, "", "exit(2)", "", 2, true},
+		{`quit("a")`, "", errorMessage("argument to `quit` must be INTEGER. got=STRING"), "", 0, false},
+		{`gets(1)`, "", errorMessage("wrong number of arguments. got=1, want=0"), "", 0, false},
+	}
+
+	for _, tt := range tests {
+		var stdout bytes.Buffer
+		rt := object.NewRuntime()
+		rt.Stdout = &stdout
+		rt.Stdin = bufio.NewReader(strings.NewReader(tt.stdin))
+
+		result := Eval(parser.New(lexer.New(tt.input)).ParseProgram(), object.NewEnvironmentWithRuntime(rt))
+		testExpected(t, tt.input, result, tt.expected)
+
+		if stdout.String() != tt.expectedStdout {
+			t.Errorf("%s: wrong output. got=%q, expected=%q", tt.input, stdout.String(), tt.expectedStdout)
+		}
+
+		code, exited := rt.ExitCode()
+		if exited != tt.expectedExit || code != tt.expectedCode {
+			t.Errorf("%s: wrong exit. got=(%d, %t), expected=(%d, %t)", tt.input, code, exited, tt.expectedCode, tt.expectedExit)
+		}
+	}
+}
+
+func TestEvalBuiltinTimeFunctions(t *testing.T) {
+	// 2024-03-01T12:30:00Z
+	start := time.Date(2024, time.March, 1, 12, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		input    string
+		expected any
+	}{
+		{"now()", int(start.UnixMilli())},
+		{"let before = now(); sleep(1500); now() - before", 1500},
+		{`format_time(now(), "RFC3339")`, "2024-03-01T12:30:00Z"},
+		{`format_time(now() + duration("36h"), "2006-01-02 15:04")`, "2024-03-03 00:30"},
+		{`format_time(now(), "Kitchen", "America/Toronto")`, "7:30AM"},
+		{`parse_time("2024-03-01T12:30:00Z", "RFC3339") == now()`, true},
+		{`parse_time("2024-03-02", "DateOnly") - parse_time("2024-03-01", "DateOnly")`, 86400000},
+		{`format_duration(parse_time("13:45:00", "TimeOnly") - parse_time("12:00:00", "TimeOnly"))`, "1h45m0s"},
+		{`duration("1h30m") == duration("90m")`, true},
+		{`duration("250ms")`, 250},
+		{`sleep(-1)`, errorMessage("'sleep' duration cannot be negative. got=-1")},
+		{`duration("soon")`, errorMessage(`could not parse "soon" as a duration`)},
+		{`parse_time("March", "DateOnly")`, errorMessage(`could not parse "March" as a time with layout "2006-01-02"`)},
+		{`format_time(0, "RFC3339", "Mars/Olympus")`, errorMessage(`unknown time zone "Mars/Olympus"`)},
+		{`format_time("0", "RFC3339")`, errorMessage("argument 1 to `format_time` must be INTEGER. got=STRING")},
+	}
+
+	for _, tt := range tests {
+		rt := object.NewRuntime()
+		rt.Clock = object.NewFakeClock(start)
+
+		result := Eval(parser.New(lexer.New(tt.input)).ParseProgram(), object.NewEnvironmentWithRuntime(rt))
+		testExpected(t, tt.input, result, tt.expected)
+	}
+}
+
+func TestEvalBuiltinRegexpFunctions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected any
+	}{
+		{`re_match("(\w+)@(\w+)\.com", "mail monkey@example.com now")`, "[monkey@example.com, monkey, example]"},
+		{`re_match("a(x)?b", "ab")`, "[ab, null]"},
+		{`re_match("\d+", "none")`, nil},
+		{`if (re_match("^ERROR", "ERROR disk full")) { 1 } else { 2 }`, 1},
+		{`re_groups("(?P<level>[A-Z]+) (?P<message>.*)", "WARN low memory")`, "{level: WARN, message: low memory}"},
+		{`re_groups("(?P<n>\d+)", "none")`, nil},
+		{`re_find_all("(\d+)ms", "took 12ms then 340ms")`, "[[12ms, 12], [340ms, 340]]"},
+		{`map(re_find_all("\d+", "1 22 333"), first)`, "[1, 22, 333]"},
+		{`re_find_all("x", "abc")`, "[]"},
+		{`re_replace("(\w+)@(\w+)", "monkey@example", "$2 at $${1}")`, "example at monkey"},
+		{`re_split("\s*,\s*", "a , b,c")`, "[a, b, c]"},
+		{`re_match("(", "a")`, errorMessage(`invalid regular expression "(": missing closing )`)},
+		{`re_match(1, "a")`, errorMessage("argument 1 to `re_match` must be STRING. got=INTEGER")},
+		{`re_replace("a", "b")`, errorMessage("wrong number of arguments. got=2, want=3")},
+	}
+
+	for _, tt := range tests {
+		testExpected(t, tt.input, testEval(tt.input), tt.expected)
+	}
+}
+
+func TestEvalBuiltinReflectionFunctions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected any
+	}{
+		{`type(1)`, "INTEGER"},
+		{`type(1.5)`, "FLOAT"},
+		{`type("a")`, "STRING"},
+		{`type([])`, "ARRAY"},
+		{`type({})`, "HASH"},
+		{`type(set([]))`, "SET"},
+		{`type(first([]))`, "NULL"},
+		{`type(fn() {})`, "FUNCTION"},
+		{`type(len)`, "BUILTIN"},
+		{`type(math)`, "HASH"},
+		{`is_int(1)`, true},
+		{`is_int(1.0)`, false},
+		{`is_number(1.0)`, true},
+		{`is_string("a")`, true},
+		{`is_bool(false)`, true},
+		{`is_null(first([]))`, true},
+		{`is_array([])`, true},
+		{`is_hash([])`, false},
+		{`is_set(set([]))`, true},
+		{`is_function(len)`, true},
+		{`is_function(fn(x) { x })`, true},
+		{`let describe = fn(x) { if (is_array(x)) { "many" } else { "one" } }; [describe([1]), describe(1)]`, "[many, one]"},
+		{`fn_arity(fn(a, b, c) { a })`, 3},
+		{`fn_arity(fn() { 1 })`, 0},
+		{`fn_params(fn(left, right) { left })`, "[left, right]"},
+		{`fn_source(fn(x, y) { x + y })`, "fn(x, y) { (x + y) }"},
+		{`fn_arity(len)`, errorMessage("'fn_arity' only accepts a function defined in Monkey as an argument. got=BUILTIN")},
+		{`is_int()`, errorMessage("wrong number of arguments. got=0, want=1")},
+	}
+
+	for _, tt := range tests {
+		testExpected(t, tt.input, testEval(tt.input), tt.expected)
+	}
+}
+
+func TestEvalBuiltinEvalAndParse(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected any
+	}{
+		{`eval("1 + 2")`, 3},
+		{`let x = 10; eval("x * 2")`, 20},
+		{`eval("let y = 5"); y`, 5},
+		{`let f = fn(a) { eval("a + 1") }; f(1)`, 2},
+		{`eval("x + y", {"x": 1, "y": 2})`, 3},
+		{`let x = 1; eval("x", {})`, errorMessage("identifier not found: x")},
+		{`let x = 1; map(["x"], eval)`, errorMessage("identifier not found: x")},
+		{`map(["1 + 1", "2 * 3"], eval)`, "[2, 6]"},
+		{`eval("return 4; 5")`, 4},
+		{`eval("let z = 1;")`, nil},
+		{`eval("")`, nil},
+		{`eval("let = 1")`, errorMessage("could not parse source: expected next token to be IDENT. got==; no prefix parse function for =")},
+		{`eval(1)`, errorMessage("argument 1 to `eval` must be STRING. got=INTEGER")},
+		{`eval("1", {1: 2})`, errorMessage("'eval' binding names must be STRING. got=INTEGER")},
+		{`let eval = fn(x) { x }; eval("1 + 1")`, "1 + 1"},
+		{`parse("1 + 2")`, "QUOTE((1 + 2))"},
+		{`type(parse("let a = 1;"))`, "QUOTE"},
+		{`parse("fn(")`, errorMessage("could not parse source: expected next token to be ). got=EOF; expected next token to be {. got=EOF")},
+	}
+
+	for _, tt := range tests {
+		testExpected(t, tt.input, testEval(tt.input), tt.expected)
+	}
+}
+
 func testEval(input string) object.Object {
 	l := lexer.New(input)
 	p := parser.New(l)
@@ -799,3 +1710,37 @@ func testStringObject(t *testing.T, obj object.Object, expected string) bool {
 
 	return true
 }
+
+// errorMessage is the expected message of an *object.Error in table driven tests.
+type errorMessage string
+
+// testExpected compares an evaluated object against an expected value.
+// Strings are compared against the inspected object and errorMessages against the message of an *object.Error.
+func testExpected(t *testing.T, input string, obj object.Object, expected any) {
+	t.Helper()
+
+	switch expected := expected.(type) {
+	case int:
+		testIntegerObject(t, obj, int64(expected))
+	case bool:
+		testBooleanObject(t, obj, expected)
+	case nil:
+		testNullObject(t, obj)
+	case string:
+		if obj == nil || obj.Inspect() != expected {
+			t.Errorf("%s: wrong result. got=%v, expected=%s", input, obj, expected)
+		}
+	case errorMessage:
+		err, ok := obj.(*object.Error)
+		if !ok {
+			t.Errorf("%s: object is not Error. got=%T (%+v)", input, obj, obj)
+			return
+		}
+
+		if err.Message != string(expected) {
+			t.Errorf("%s: wrong error message. got=%s, expected=%s", input, err.Message, expected)
+		}
+	default:
+		t.Errorf("unsupported expected type %T", expected)
+	}
+}
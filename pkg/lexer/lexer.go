@@ -64,10 +64,28 @@ func (l *Lexer) readDigit() string {
 	return l.input[position:l.position]
 }
 
+// Read an integer or float literal.
+// A '.' is only part of the number when it is followed by a digit so that `1.foo` is still member access.
+func (l *Lexer) readNumber() token.Token {
+	position := l.position
+	l.readDigit()
+
+	if l.ch != '.' || !strings.IsDigit(l.peekChar()) {
+		return token.Token{Type: token.INT, Literal: l.input[position:l.position]}
+	}
+
+	// Skip over the '.'
+	l.readChar()
+	l.readDigit()
+
+	return token.Token{Type: token.FLOAT, Literal: l.input[position:l.position]}
+}
+
 // Read an identifier and advance the lexer's postions until it encounters a non-letter character.
+// Digits are allowed after the first character, e.g. log10.
 func (l *Lexer) readIdentifier() string {
 	position := l.position
-	for strings.IsLetter(l.ch) {
+	for strings.IsLetter(l.ch) || strings.IsDigit(l.ch) {
 		l.readChar()
 	}
 	return l.input[position:l.position]
@@ -75,17 +93,47 @@ func (l *Lexer) readIdentifier() string {
 
 // TODO: Add support for character escaping, e.g. \", \n, etc
 
-// Read the contents of a string.
-func (l *Lexer) readString() string {
+// readString reads a string literal.
+// Returns the contents of the string and whether it interpolates any expressions with `${...}`.
+// Quotes inside an interpolated expression do not end the string so that expressions can contain string literals.
+// A literal `${` is written as `$${`.
+func (l *Lexer) readString() (string, bool) {
 	// Skip over the first '"'
 	l.readChar()
 
 	position := l.position
-	for l.ch != '"' && l.ch != 0 {
-		l.readChar()
+	template := false
+	// depth is the number of unclosed braces in the interpolated expression being read
+	depth := 0
+	for l.ch != 0 && (l.ch != '"' || depth > 0) {
+		switch {
+		case depth == 0 && l.ch == '$' && l.peekChar() == '$' && l.readPosition+1 < len(l.input) && l.input[l.readPosition+1] == '{':
+			// An escaped `${` is still a template so the parser can remove the escape
+			template = true
+			l.readChar()
+			l.readChar()
+		case l.ch == '$' && l.peekChar() == '{':
+			template = true
+			depth++
+			l.readChar()
+		case depth > 0 && l.ch == '{':
+			depth++
+		case depth > 0 && l.ch == '}':
+			depth--
+		case depth > 0 && l.ch == '"':
+			// Skip over a string literal inside the expression
+			l.readChar()
+			for l.ch != '"' && l.ch != 0 {
+				l.readChar()
+			}
+		}
+
+		if l.ch != 0 {
+			l.readChar()
+		}
 	}
 
-	return l.input[position:l.position]
+	return l.input[position:l.position], template
 }
 
 // Iterate to the next token.
@@ -104,6 +152,8 @@ func (l *Lexer) NextToken() token.Token {
 		tok = newToken(token.RPAREN, l.ch)
 	case ',':
 		tok = newToken(token.COMMA, l.ch)
+	case '.':
+		tok = newToken(token.DOT, l.ch)
 	case '{':
 		tok = newToken(token.LBRACE, l.ch)
 	case '}':
@@ -135,8 +185,12 @@ func (l *Lexer) NextToken() token.Token {
 	case '-':
 		tok = newToken(token.MINUS, l.ch)
 	case '"':
+		literal, template := l.readString()
 		tok.Type = token.STRING
-		tok.Literal = l.readString()
+		if template {
+			tok.Type = token.TEMPLATE
+		}
+		tok.Literal = literal
 	case '[':
 		tok = newToken(token.LBRACKET, l.ch)
 	case ']':
@@ -151,9 +205,7 @@ func (l *Lexer) NextToken() token.Token {
 			// Exit early because we do not want to call readChar twice
 			return tok
 		} else if strings.IsDigit(l.ch) {
-			tok.Literal = l.readDigit()
-			tok.Type = token.INT
-			return tok
+			return l.readNumber()
 		} else {
 			tok = newToken(token.ILLEGAL, l.ch)
 		}
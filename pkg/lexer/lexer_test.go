@@ -30,6 +30,13 @@ let result = add(five, ten);
 	[1, 2];
 	{"test": 42};
 	macro(x, y) { x + y };
+	3.14;
+	math.pi;
+	1.x;
+	log10;
+	"Hello ${name}!";
+	"${"}"} $ {";
+	"$${}";
 `
 
 	tests := []struct {
@@ -137,6 +144,24 @@ let result = add(five, ten);
 		{token.IDENT, "y"},
 		{token.RBRACE, "}"},
 		{token.SEMICOLON, ";"},
+		{token.FLOAT, "3.14"},
+		{token.SEMICOLON, ";"},
+		{token.IDENT, "math"},
+		{token.DOT, "."},
+		{token.IDENT, "pi"},
+		{token.SEMICOLON, ";"},
+		{token.INT, "1"},
+		{token.DOT, "."},
+		{token.IDENT, "x"},
+		{token.SEMICOLON, ";"},
+		{token.IDENT, "log10"},
+		{token.SEMICOLON, ";"},
+		{token.TEMPLATE, "Hello ${name}!"},
+		{token.SEMICOLON, ";"},
+		{token.TEMPLATE, `${"}"} $ {`},
+		{token.SEMICOLON, ";"},
+		{token.TEMPLATE, "$${}"},
+		{token.SEMICOLON, ";"},
 		{token.EOF, ""},
 	}
 
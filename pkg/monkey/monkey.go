@@ -0,0 +1,342 @@
+// Package monkey embeds the Monkey programming language in Go programs.
+//
+// An Interpreter runs programs one after another, each of which can use the bindings of the programs run before it,
+// the same way the lines of the REPL do:
+//
+//	interpreter, err := monkey.New(monkey.WithEngine(monkey.VM), monkey.WithMaxSteps(100000))
+//	if err != nil {
+//		return err
+//	}
+//
+//	interpreter.Set("limit", &object.Integer{Value: 10})
+//	if _, err := interpreter.Run(ctx, "let allowed = fn(n) { n < limit };"); err != nil {
+//		return err
+//	}
+//
+//	allowed, err := interpreter.Call("allowed", &object.Integer{Value: 5})
+package monkey
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/grantwforsythe/monkeylang/pkg/ast"
+	"github.com/grantwforsythe/monkeylang/pkg/compiler"
+	"github.com/grantwforsythe/monkeylang/pkg/evaluator"
+	"github.com/grantwforsythe/monkeylang/pkg/object"
+	"github.com/grantwforsythe/monkeylang/pkg/prelude"
+	"github.com/grantwforsythe/monkeylang/pkg/vm"
+)
+
+// Engine represents the way an interpreter runs programs.
+// The engines run programs the same way, except that only the evaluator supports `eval`, which needs the environment of
+// its caller. Calling `eval` on the virtual machine is an error.
+type Engine int
+
+const (
+	Evaluator Engine = iota // Evaluator runs programs by walking their AST.
+	VM                      // VM compiles programs into bytecode and runs them in the virtual machine.
+)
+
+// ErrExited is returned when running a program with an interpreter whose previous program called `quit`.
+var ErrExited = errors.New("interpreter has exited")
+
+// Interpreter runs Monkey programs with its own globals, builtins and runtime.
+// An interpreter must not be used by multiple goroutines at once.
+type Interpreter struct {
+	engine  Engine
+	runtime *object.Runtime
+	files   []prelude.File
+
+	// builtins are the builtins defined for this interpreter only, by name.
+	builtins map[string]object.BuiltinFunction
+
+	// macroEnv holds the macros defined by the programs, which are expanded before a program runs with either engine.
+	macroEnv *object.Environment
+
+	// env holds the globals of the evaluator.
+	env *object.Environment
+
+	// symbolTable, constants and globals hold the state of the virtual machine that carries over between programs.
+	symbolTable *compiler.SymbolTable
+	constants   []object.Object
+	globals     []object.Object
+}
+
+// Option configures an interpreter.
+type Option func(*Interpreter)
+
+// WithEngine sets the engine that runs the programs. The evaluator is used by default.
+func WithEngine(engine Engine) Option {
+	return func(in *Interpreter) { in.engine = engine }
+}
+
+// WithStdout sets where builtins such as `puts` write their output.
+func WithStdout(w io.Writer) Option {
+	return func(in *Interpreter) { in.runtime.Stdout = w }
+}
+
+// WithStdin sets where builtins such as `gets` read their input from.
+func WithStdin(r io.Reader) Option {
+	return func(in *Interpreter) { in.runtime.Stdin = bufio.NewReader(r) }
+}
+
+// WithFileSystem sets the part of the host file system the file builtins can access.
+func WithFileSystem(fsys object.FileSystem) Option {
+	return func(in *Interpreter) { in.runtime.FileSystem = fsys }
+}
+
+// WithLoader sets where imported modules are loaded from. Programs cannot import modules without one.
+func WithLoader(loader object.ModuleLoader) Option {
+	return func(in *Interpreter) { in.runtime.Loader = loader }
+}
+
+// WithPrelude adds files to evaluate after the standard prelude, before the first program runs.
+func WithPrelude(files ...prelude.File) Option {
+	return func(in *Interpreter) { in.files = append(in.files, files...) }
+}
+
+// WithBuiltin defines a builtin for this interpreter only. It is a global, so programs can shadow it.
+func WithBuiltin(name string, fn object.BuiltinFunction) Option {
+	return func(in *Interpreter) { in.builtins[name] = fn }
+}
+
+// WithMaxSteps sets the maximum number of steps each program can take. See object.Runtime.
+func WithMaxSteps(limit int64) Option {
+	return func(in *Interpreter) { in.runtime.MaxSteps = limit }
+}
+
+// WithMaxAllocations sets the maximum number of strings, arrays and hashes each program can create.
+func WithMaxAllocations(limit int64) Option {
+	return func(in *Interpreter) { in.runtime.MaxAllocations = limit }
+}
+
+// WithMaxMemory sets the maximum number of bytes the strings, arrays and hashes each program creates can take up.
+func WithMaxMemory(limit int64) Option {
+	return func(in *Interpreter) { in.runtime.MaxMemory = limit }
+}
+
+// WithMaxCallDepth sets the maximum number of function calls that can be in progress at once.
+func WithMaxCallDepth(limit int) Option {
+	return func(in *Interpreter) { in.runtime.MaxCallDepth = limit }
+}
+
+// New creates an interpreter and evaluates the standard prelude and the prelude files given as options.
+// Builtins read from and write to the standard input and output of the process unless configured otherwise.
+func New(opts ...Option) (*Interpreter, error) {
+	in := &Interpreter{runtime: object.NewRuntime(), builtins: make(map[string]object.BuiltinFunction)}
+	for _, opt := range opts {
+		opt(in)
+	}
+
+	in.macroEnv = object.NewEnvironmentWithRuntime(in.runtime)
+	files := append(prelude.Standard(), in.files...)
+
+	switch in.engine {
+	case Evaluator:
+		in.env = object.NewEnvironmentWithRuntime(in.runtime)
+		if err := prelude.Eval(in.env, files...); err != nil {
+			return nil, fmt.Errorf("could not load the prelude: %w", err)
+		}
+	case VM:
+		compiled, err := prelude.Compile(files...)
+		if err != nil {
+			return nil, fmt.Errorf("could not load the prelude: %w", err)
+		}
+
+		in.globals, err = compiled.Run(in.runtime)
+		if err != nil {
+			return nil, fmt.Errorf("could not load the prelude: %w", err)
+		}
+
+		comp := compiled.Compiler(in.runtime.Loader)
+		in.symbolTable = comp.SymbolTable()
+		in.constants = comp.ByteCode().Constants
+	default:
+		return nil, fmt.Errorf("unknown engine: %d", in.engine)
+	}
+
+	for name, fn := range in.builtins {
+		in.Set(name, &object.Builtin{Fn: fn})
+	}
+
+	return in, nil
+}
+
+// Runtime gets the runtime shared by the programs the interpreter runs, such as to get the exit code passed to `quit`.
+func (in *Interpreter) Runtime() *object.Runtime {
+	return in.runtime
+}
+
+// Run runs a program, which can use the globals defined by the programs run before it.
+// Returns the value of the last statement of the program, or an error if it fails or the context is done first.
+// Errors of the program are *object.Error values. Every program has the full limits of the interpreter.
+// A panic in the interpreter or in a builtin, such as a function registered with RegisterFunc, is returned as an error.
+func (in *Interpreter) Run(ctx context.Context, source string) (result object.Object, err error) {
+	defer in.recover(&result, &err)
+
+	if _, exited := in.runtime.ExitCode(); exited {
+		return nil, ErrExited
+	}
+
+	program, parseErr := object.ParseSource(source)
+	if parseErr != nil {
+		return nil, parseErr
+	}
+
+	evaluator.DefineMacros(program, in.macroEnv)
+	expanded := evaluator.ExpandMacros(program, in.macroEnv).(*ast.Program)
+
+	in.runtime.ResetUsage()
+	switch in.engine {
+	case VM:
+		return in.runCompiled(ctx, expanded)
+	default:
+		return in.result(evaluator.EvalContext(ctx, expanded, in.env))
+	}
+}
+
+// runCompiled compiles a program and runs it in a virtual machine with the globals of the interpreter.
+// The bindings of a program that does not compile are discarded, as are the ones a program that fails did not get to.
+func (in *Interpreter) runCompiled(ctx context.Context, program *ast.Program) (object.Object, error) {
+	comp := compiler.NewWithState(in.symbolTable.Copy(), in.constants, in.runtime.Loader)
+	if err := comp.Compile(program); err != nil {
+		return nil, err
+	}
+
+	bytecode := comp.ByteCode()
+	in.symbolTable = comp.SymbolTable()
+	in.constants = bytecode.Constants
+
+	machine := vm.NewWithGlobals(bytecode, in.runtime, in.globals)
+	if err := machine.RunContext(ctx); err != nil {
+		in.symbolTable.ForgetUnset(in.globals)
+		return nil, err
+	}
+
+	// The virtual machine leaves the value of a statement that is not an expression on the stack
+	if len(program.Statements) == 0 {
+		return object.NULL, nil
+	}
+
+	switch program.Statements[len(program.Statements)-1].(type) {
+	case *ast.ExpressionStatement, *ast.ReturnStatement:
+		return in.result(machine.LastPoppedStackElem())
+	default:
+		return object.NULL, nil
+	}
+}
+
+// Call calls a global function, such as one defined by a program run earlier, or a builtin.
+// Returns the result of the call, or an error if it fails. Panics are returned as errors the same way as by Run.
+func (in *Interpreter) Call(name string, args ...object.Object) (object.Object, error) {
+	return in.CallContext(context.Background(), name, args...)
+}
+
+// CallContext calls a global function the same way as Call, stopping with an error once the context is done.
+func (in *Interpreter) CallContext(
+	ctx context.Context, name string, args ...object.Object,
+) (result object.Object, err error) {
+	defer in.recover(&result, &err)
+
+	if _, exited := in.runtime.ExitCode(); exited {
+		return nil, ErrExited
+	}
+
+	fn, ok := in.Get(name)
+	if !ok {
+		fn, ok = in.runtime.LookupBuiltin(name)
+	}
+	if !ok {
+		return nil, fmt.Errorf("undefined variable %s", name)
+	}
+
+	previous := in.runtime.Context()
+	in.runtime.SetContext(ctx)
+	defer in.runtime.SetContext(previous)
+
+	in.runtime.ResetUsage()
+	switch in.engine {
+	case VM:
+		machine := vm.NewWithGlobals(&compiler.ByteCode{Constants: in.constants}, in.runtime, in.globals)
+		result, err := machine.Call(fn, args...)
+		if err != nil {
+			return nil, err
+		}
+
+		return in.result(result)
+	default:
+		return in.result(evaluator.Apply(fn, args, in.runtime))
+	}
+}
+
+// RegisterFunc defines a builtin for this interpreter that calls a Go function, converting its arguments and result
+// between Monkey objects and Go values. See object.WrapFunc for the functions that can be registered.
+// Returns an error if fn cannot be wrapped as a builtin.
+func (in *Interpreter) RegisterFunc(name string, fn any) error {
+	builtin, err := object.WrapFunc(name, fn)
+	if err != nil {
+		return err
+	}
+
+	in.Set(name, builtin)
+	return nil
+}
+
+// Get gets the value of a global.
+func (in *Interpreter) Get(name string) (object.Object, bool) {
+	if in.engine != VM {
+		return in.env.Get(name)
+	}
+
+	symbol, ok := in.symbolTable.Resolve(name)
+	if !ok || symbol.Scope != compiler.GlobalScope {
+		return nil, false
+	}
+
+	return in.globals[symbol.Index], true
+}
+
+// Set defines a global, or changes its value if it is already defined.
+func (in *Interpreter) Set(name string, value object.Object) {
+	if in.engine != VM {
+		in.env.Set(name, value)
+		return
+	}
+
+	symbol := in.symbolTable.Define(name)
+	in.globals[symbol.Index] = value
+}
+
+// result converts the result of a program into the values returned by the interpreter.
+func (in *Interpreter) result(obj object.Object) (object.Object, error) {
+	// A program that calls `quit` stops without failing
+	if _, exited := in.runtime.ExitCode(); exited {
+		return object.NULL, nil
+	}
+
+	switch obj := obj.(type) {
+	case nil:
+		return object.NULL, nil
+	case *object.Error:
+		return nil, obj
+	default:
+		return obj, nil
+	}
+}
+
+// recover turns a panic of the program being run into its error, so that a bug in the interpreter or a builtin does not
+// crash the host. The calls and imports the program was in the middle of are forgotten.
+func (in *Interpreter) recover(result *object.Object, err *error) {
+	if r := recover(); r != nil {
+		in.runtime.Unwind()
+		if in.engine == VM {
+			in.symbolTable.ForgetUnset(in.globals)
+		}
+
+		*result, *err = nil, fmt.Errorf("panic: %v", r)
+	}
+}
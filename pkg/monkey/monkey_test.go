@@ -0,0 +1,463 @@
+package monkey
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/grantwforsythe/monkeylang/pkg/object"
+	"github.com/grantwforsythe/monkeylang/pkg/prelude"
+)
+
+var engines = []struct {
+	name   string
+	engine Engine
+}{
+	{"evaluator", Evaluator},
+	{"vm", VM},
+}
+
+func TestRun(t *testing.T) {
+	tests := []struct {
+		inputs   []string
+		expected string
+	}{
+		{[]string{"1 + 1"}, "2"},
+		{[]string{"let x = 5;", "x * 2"}, "10"},
+		{[]string{"let x = 5;"}, "null"},
+		{[]string{""}, "null"},
+		{[]string{"let add = fn(a, b) { a + b };", "add(1, 2)"}, "3"},
+		{[]string{"let counter = 0;", "counter = counter + 1;", "counter"}, "1"},
+		{[]string{"let m = macro(a) { quote(unquote(a) + 1) };", "m(1)"}, "2"},
+		{[]string{"identity(7)"}, "7"},
+		{[]string{"undefined", "1"}, "1"},
+		{[]string{"let double = fn(x) { x * 2 };", "map([1, 2], double)"}, "[2, 4]"},
+		{[]string{"sort(filter([3, 1, 2], fn(x) { x > 1 }), fn(a, b) { b - a })"}, "[3, 2]"},
+	}
+
+	for _, engine := range engines {
+		for _, tt := range tests {
+			interpreter, err := New(WithEngine(engine.engine))
+			if err != nil {
+				t.Fatalf("%s: could not create an interpreter: %s", engine.name, err)
+			}
+
+			var result object.Object
+			for _, input := range tt.inputs {
+				result, _ = interpreter.Run(context.Background(), input)
+			}
+
+			if result == nil || result.Inspect() != tt.expected {
+				t.Errorf("%s: %q: wrong result. got=%v, want=%s", engine.name, tt.inputs, result, tt.expected)
+			}
+		}
+	}
+}
+
+func TestEvalIsOnlySupportedByTheEvaluator(t *testing.T) {
+	interpreter, err := New(WithEngine(Evaluator))
+	if err != nil {
+		t.Fatalf("could not create an interpreter: %s", err)
+	}
+
+	result, err := interpreter.Run(context.Background(), `eval("1 + 2")`)
+	if err != nil || result.Inspect() != "3" {
+		t.Errorf("evaluator: wrong result. got=%v (%v), want=3", result, err)
+	}
+
+	interpreter, err = New(WithEngine(VM))
+	if err != nil {
+		t.Fatalf("could not create an interpreter: %s", err)
+	}
+
+	result, err = interpreter.Run(context.Background(), `eval("1 + 2")`)
+	if result != nil || err == nil || err.Error() != "`eval` is not supported by the virtual machine" {
+		t.Errorf("vm: wrong error. got=%v (%v)", err, result)
+	}
+}
+
+func TestRunErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let = 1;", "could not parse source: expected next token to be IDENT. got==; no prefix parse function for ="},
+		{"1 + true", "type mismatch: INTEGER + BOOLEAN"},
+		{"len(1)", "argument to `len` not supported. got=INTEGER"},
+		{"1 / 0", "division by zero"},
+		{"let div = fn(a, b) { a / b }; div(1, 0)", "division by zero"},
+		{"let min = -9223372036854775807 - 1; min / -1", "integer overflow: -9223372036854775808 / -1"},
+	}
+
+	for _, engine := range engines {
+		for _, tt := range tests {
+			interpreter, err := New(WithEngine(engine.engine))
+			if err != nil {
+				t.Fatalf("%s: could not create an interpreter: %s", engine.name, err)
+			}
+
+			result, err := interpreter.Run(context.Background(), tt.input)
+			if result != nil || err == nil || err.Error() != tt.expected {
+				t.Errorf("%s: %s: wrong error. got=%v (%v), want=%q", engine.name, tt.input, err, result, tt.expected)
+			}
+		}
+	}
+}
+
+func TestPanics(t *testing.T) {
+	for _, engine := range engines {
+		interpreter, err := New(WithEngine(engine.engine))
+		if err != nil {
+			t.Fatalf("%s: could not create an interpreter: %s", engine.name, err)
+		}
+
+		if err := interpreter.RegisterFunc("explode", func() { panic("boom") }); err != nil {
+			t.Fatalf("%s: could not register explode: %s", engine.name, err)
+		}
+
+		result, err := interpreter.Run(context.Background(), "let f = fn() { explode() }; f()")
+		if result != nil || err == nil || err.Error() != "panic: boom" {
+			t.Errorf("%s: wrong error of a program that panics. got=%v (%v)", engine.name, err, result)
+		}
+
+		if _, err := interpreter.Call("f"); err == nil || err.Error() != "panic: boom" {
+			t.Errorf("%s: wrong error of a function that panics. got=%v", engine.name, err)
+		}
+
+		// The interpreter keeps working after a panic
+		result, err = interpreter.Run(context.Background(), "let g = fn(n) { if (n == 0) { 0 } else { g(n - 1) } }; g(10)")
+		if err != nil || result.Inspect() != "0" {
+			t.Errorf("%s: wrong result after a panic. got=%v (%v), want=0", engine.name, result, err)
+		}
+	}
+}
+
+func TestCall(t *testing.T) {
+	for _, engine := range engines {
+		interpreter, err := New(WithEngine(engine.engine))
+		if err != nil {
+			t.Fatalf("%s: could not create an interpreter: %s", engine.name, err)
+		}
+
+		source := "let offset = 10; let add = fn(a, b) { a + b + offset }; let fail = fn() { 1 + true }; " +
+			"let shift = fn(x) { x + offset + 1 };"
+		if _, err := interpreter.Run(context.Background(), source); err != nil {
+			t.Fatalf("%s: could not run the program: %s", engine.name, err)
+		}
+
+		result, err := interpreter.Call("add", &object.Integer{Value: 1}, &object.Integer{Value: 2})
+		if err != nil || result.Inspect() != "13" {
+			t.Errorf("%s: wrong result of a function. got=%v (%v), want=13", engine.name, result, err)
+		}
+
+		shift, _ := interpreter.Get("shift")
+		result, err = interpreter.Call("map", &object.Array{Elements: []object.Object{&object.Integer{Value: 1}}}, shift)
+		if err != nil || result.Inspect() != "[12]" {
+			t.Errorf("%s: wrong result of a builtin calling a function. got=%v (%v), want=[12]", engine.name, result, err)
+		}
+
+		result, err = interpreter.Call("len", &object.String{Value: "monkey"})
+		if err != nil || result.Inspect() != "6" {
+			t.Errorf("%s: wrong result of a builtin. got=%v (%v), want=6", engine.name, result, err)
+		}
+
+		if _, err := interpreter.Call("fail"); err == nil || err.Error() != "type mismatch: INTEGER + BOOLEAN" {
+			t.Errorf("%s: wrong error of a failing function. got=%v", engine.name, err)
+		}
+
+		if _, err := interpreter.Call("add", &object.Integer{Value: 1}); err == nil || err.Error() != "wrong number of arguments. got=1, want=2" {
+			t.Errorf("%s: wrong error for the wrong number of arguments. got=%v", engine.name, err)
+		}
+
+		if _, err := interpreter.Call("missing"); err == nil || err.Error() != "undefined variable missing" {
+			t.Errorf("%s: wrong error for an undefined function. got=%v", engine.name, err)
+		}
+
+		if _, err := interpreter.Call("offset"); err == nil || err.Error() != "not a function: INTEGER" {
+			t.Errorf("%s: wrong error for a value that is not a function. got=%v", engine.name, err)
+		}
+	}
+}
+
+func TestImportAfterFailure(t *testing.T) {
+	for _, engine := range engines {
+		root := t.TempDir()
+		interpreter, err := New(
+			WithEngine(engine.engine),
+			WithLoader(object.MapLoader{"config.mk": `export let size = len(read_file("config.txt"));`}),
+			WithFileSystem(object.FileSystem{Root: root, Read: true}),
+		)
+		if err != nil {
+			t.Fatalf("%s: could not create an interpreter: %s", engine.name, err)
+		}
+
+		source := `let before = 1; import "config.mk" as config; let after = 2; config.size`
+		if _, err := interpreter.Run(context.Background(), source); err == nil {
+			t.Fatalf("%s: expected an error importing a module that fails", engine.name)
+		}
+
+		if value, ok := interpreter.Get("before"); !ok || value.Inspect() != "1" {
+			t.Errorf("%s: wrong global defined before the error. got=%v (%t), want=1", engine.name, value, ok)
+		}
+		if _, ok := interpreter.Get("after"); ok {
+			t.Errorf("%s: got a global defined after the error", engine.name)
+		}
+
+		// A module that failed runs again the next time it is imported
+		if err := os.WriteFile(filepath.Join(root, "config.txt"), []byte("abc"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+
+		result, err := interpreter.Run(context.Background(), source)
+		if err != nil || result.Inspect() != "3" {
+			t.Errorf("%s: wrong result of importing a module again. got=%v (%v), want=3", engine.name, result, err)
+		}
+	}
+}
+
+func TestConcurrentInterpreters(t *testing.T) {
+	// The interpreters share a string, which is hashed by both when it is used as a key
+	key := &object.String{Value: "shared"}
+	source := `let count = fn(n, hash) { if (n == 0) { hash[key] } else { count(n - 1, {key: n}) } }; count(100, {})`
+
+	for _, engine := range engines {
+		var wg sync.WaitGroup
+		results := make([]string, 2)
+		for i := range results {
+			interpreter, err := New(WithEngine(engine.engine))
+			if err != nil {
+				t.Fatalf("%s: could not create an interpreter: %s", engine.name, err)
+			}
+			interpreter.Set("key", key)
+
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+
+				result, err := interpreter.Run(context.Background(), source)
+				if err != nil {
+					results[i] = err.Error()
+					return
+				}
+				results[i] = result.Inspect()
+			}()
+		}
+		wg.Wait()
+
+		for _, result := range results {
+			if result != "1" {
+				t.Errorf("%s: wrong result of a concurrent interpreter. got=%s, want=1", engine.name, result)
+			}
+		}
+	}
+}
+
+func TestSetAndGet(t *testing.T) {
+	for _, engine := range engines {
+		interpreter, err := New(WithEngine(engine.engine))
+		if err != nil {
+			t.Fatalf("%s: could not create an interpreter: %s", engine.name, err)
+		}
+
+		interpreter.Set("limit", &object.Integer{Value: 3})
+		if _, err := interpreter.Run(context.Background(), "let doubled = limit * 2;"); err != nil {
+			t.Fatalf("%s: could not run the program: %s", engine.name, err)
+		}
+
+		if doubled, ok := interpreter.Get("doubled"); !ok || doubled.Inspect() != "6" {
+			t.Errorf("%s: wrong global. got=%v (%t), want=6", engine.name, doubled, ok)
+		}
+
+		// Changing a global is seen by the functions that use it
+		interpreter.Set("limit", &object.Integer{Value: 4})
+		result, err := interpreter.Run(context.Background(), "limit * 2")
+		if err != nil || result.Inspect() != "8" {
+			t.Errorf("%s: wrong result after changing a global. got=%v (%v), want=8", engine.name, result, err)
+		}
+
+		if _, ok := interpreter.Get("missing"); ok {
+			t.Errorf("%s: got a global that is not defined", engine.name)
+		}
+	}
+}
+
+func TestOptions(t *testing.T) {
+	double := func(rt *object.Runtime, args ...object.Object) object.Object {
+		return &object.Integer{Value: args[0].(*object.Integer).Value * 2}
+	}
+
+	for _, engine := range engines {
+		var out bytes.Buffer
+		interpreter, err := New(
+			WithEngine(engine.engine),
+			WithStdout(&out),
+			WithStdin(strings.NewReader("monkey\n")),
+			WithBuiltin("double", double),
+			WithPrelude(prelude.File{Name: "host.mk", Source: `let greeting = "hello";`}),
+		)
+		if err != nil {
+			t.Fatalf("%s: could not create an interpreter: %s", engine.name, err)
+		}
+
+		result, err := interpreter.Run(context.Background(), `puts(greeting + " " + gets()); double(21)`)
+		if err != nil || result.Inspect() != "42" {
+			t.Errorf("%s: wrong result. got=%v (%v), want=42", engine.name, result, err)
+		}
+
+		if out.String() != "hello monkey\n" {
+			t.Errorf("%s: wrong output. got=%q, want=%q", engine.name, out.String(), "hello monkey\n")
+		}
+
+		// Builtins defined with an option belong to the interpreter
+		other, err := New(WithEngine(engine.engine))
+		if err != nil {
+			t.Fatalf("%s: could not create an interpreter: %s", engine.name, err)
+		}
+
+		if _, err := other.Run(context.Background(), "double(1)"); err == nil {
+			t.Errorf("%s: builtin leaked into another interpreter", engine.name)
+		}
+
+		// The virtual machine runs the prelude files together, so its errors do not name the file
+		_, err = New(WithEngine(engine.engine), WithPrelude(prelude.File{Name: "broken.mk", Source: "1 + true"}))
+		if err == nil || !strings.HasPrefix(err.Error(), "could not load the prelude: ") || !strings.HasSuffix(err.Error(), "type mismatch: INTEGER + BOOLEAN") {
+			t.Errorf("%s: wrong error for a broken prelude. got=%v", engine.name, err)
+		}
+	}
+}
+
+func TestLimits(t *testing.T) {
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	for _, engine := range engines {
+		interpreter, err := New(WithEngine(engine.engine), WithMaxSteps(10000), WithMaxMemory(1<<20))
+		if err != nil {
+			t.Fatalf("%s: could not create an interpreter: %s", engine.name, err)
+		}
+
+		if _, err := interpreter.Run(context.Background(), "while (true) { 1 }"); !errors.Is(err, object.ErrStepLimitExceeded) {
+			t.Errorf("%s: wrong error for too many steps. got=%v", engine.name, err)
+		}
+
+		// Every program has the full limits
+		result, err := interpreter.Run(context.Background(), "let x = 0; while (x < 100) { x = x + 1 }; x")
+		if err != nil || result.Inspect() != "100" {
+			t.Errorf("%s: wrong result after a program exceeded the limits. got=%v (%v), want=100", engine.name, result, err)
+		}
+
+		if _, err := interpreter.Run(context.Background(), `let s = "a"; while (true) { s = s + s }`); !errors.Is(err, object.ErrMemoryLimitExceeded) {
+			t.Errorf("%s: wrong error for too much memory. got=%v", engine.name, err)
+		}
+
+		if _, err := interpreter.Run(cancelled, "while (true) { 1 }"); !errors.Is(err, context.Canceled) {
+			t.Errorf("%s: wrong error for a cancelled context. got=%v", engine.name, err)
+		}
+
+		if _, err := interpreter.Run(context.Background(), "let loop = fn() { loop() };"); err != nil {
+			t.Fatalf("%s: could not run the program: %s", engine.name, err)
+		}
+
+		if _, err := interpreter.CallContext(cancelled, "loop"); !errors.Is(err, context.Canceled) {
+			t.Errorf("%s: wrong error for a cancelled call. got=%v", engine.name, err)
+		}
+	}
+}
+
+func TestExit(t *testing.T) {
+	for _, engine := range engines {
+		var out bytes.Buffer
+		interpreter, err := New(WithEngine(engine.engine), WithStdout(&out))
+		if err != nil {
+			t.Fatalf("%s: could not create an interpreter: %s", engine.name, err)
+		}
+
+		result, err := interpreter.Run(context.Background(), "quit(3); puts(1)")
+		if err != nil || result != object.NULL {
+			t.Errorf("%s: wrong result of quit. got=%v (%v), want=null", engine.name, result, err)
+		}
+
+		if code, exited := interpreter.Runtime().ExitCode(); !exited || code != 3 {
+			t.Errorf("%s: wrong exit code. got=%d (%t), want=3", engine.name, code, exited)
+		}
+
+		if _, err := interpreter.Run(context.Background(), "1"); !errors.Is(err, ErrExited) {
+			t.Errorf("%s: wrong error after quit. got=%v", engine.name, err)
+		}
+
+		if out.Len() != 0 {
+			t.Errorf("%s: program continued after quit. got=%q", engine.name, out.String())
+		}
+	}
+}
+
+func TestRegisterFunc(t *testing.T) {
+	type order struct {
+		Total float64 `monkey:"total"`
+		Items int     `monkey:"items"`
+	}
+
+	errClosed := errors.New("store is closed")
+
+	for _, engine := range engines {
+		interpreter, err := New(WithEngine(engine.engine))
+		if err != nil {
+			t.Fatalf("%s: could not create an interpreter: %s", engine.name, err)
+		}
+
+		funcs := map[string]any{
+			"discount": func(o order, rate float64) float64 { return o.Total * (1 - rate) },
+			"lookup":   func(id int) (order, error) { return order{Total: 10, Items: id}, nil },
+			"close":    func() error { return errClosed },
+		}
+		for name, fn := range funcs {
+			if err := interpreter.RegisterFunc(name, fn); err != nil {
+				t.Fatalf("%s: could not register %s: %s", engine.name, name, err)
+			}
+		}
+
+		tests := []struct {
+			input    string
+			expected string
+		}{
+			{`discount({"total": 100, "items": 2}, 0.25)`, "75.0"},
+			{"lookup(3).items", "3"},
+			{"let apply = fn(f) { f(2).total }; apply(lookup)", "10.0"},
+		}
+
+		for _, tt := range tests {
+			result, err := interpreter.Run(context.Background(), tt.input)
+			if err != nil || result.Inspect() != tt.expected {
+				t.Errorf("%s: %s: wrong result. got=%v (%v), want=%s", engine.name, tt.input, result, err, tt.expected)
+			}
+		}
+
+		if _, err := interpreter.Run(context.Background(), "close()"); !errors.Is(err, errClosed) {
+			t.Errorf("%s: wrong error of a Go function. got=%v", engine.name, err)
+		}
+
+		_, err = interpreter.Run(context.Background(), `discount("a", 1)`)
+		if err == nil || err.Error() != "argument 1 to `discount` must be HASH. got=STRING" {
+			t.Errorf("%s: wrong error for a mismatched argument. got=%v", engine.name, err)
+		}
+
+		result, err := interpreter.Call("lookup", &object.Integer{Value: 5})
+		if err != nil || result.Inspect() != "{total: 10.0, items: 5}" {
+			t.Errorf("%s: wrong result of calling a Go function. got=%v (%v)", engine.name, result, err)
+		}
+	}
+
+	interpreter, err := New()
+	if err != nil {
+		t.Fatalf("could not create an interpreter: %s", err)
+	}
+
+	if err := interpreter.RegisterFunc("bad", 1); err == nil {
+		t.Errorf("registered a value that is not a function")
+	}
+}
@@ -0,0 +1,521 @@
+package object
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Builtins are the functions written in the host language (go) that are available to every Monkey program regardless
+// of the engine used to run it.
+var Builtins = map[string]*Builtin{
+	"len": {
+		// Calculate the length of array, string, or set.
+		Fn: func(rt *Runtime, args ...Object) Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments. got=%d, want=1", len(args))
+			}
+
+			switch arg := args[0].(type) {
+			case *Array:
+				return &Integer{Value: int64(len(arg.Elements))}
+			case *String:
+				return &Integer{Value: int64(len(arg.Value))}
+			case *Set:
+				return &Integer{Value: int64(arg.Len())}
+			default:
+				return newError("argument to `len` not supported. got=%s", args[0].Type())
+			}
+		},
+	},
+	"first": {
+		// Get the first element of an array.
+		Fn: func(rt *Runtime, args ...Object) Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments. got=%d, want=1", len(args))
+			}
+
+			array, ok := args[0].(*Array)
+			if !ok {
+				return newError(
+					"'first' only accepts an array as an argument. got=%s",
+					args[0].Type(),
+				)
+			}
+
+			if len(array.Elements) == 0 {
+				return NULL
+			}
+
+			return array.Elements[0]
+		},
+	},
+	"last": {
+		// Get the last element of an array.
+		Fn: func(rt *Runtime, args ...Object) Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments. got=%d, want=1", len(args))
+			}
+
+			array, ok := args[0].(*Array)
+			if !ok {
+				return newError(
+					"'last' only accepts an array as an argument. got=%s",
+					args[0].Type(),
+				)
+			}
+
+			length := len(array.Elements)
+			if length == 0 {
+				return NULL
+			}
+
+			return array.Elements[length-1]
+		},
+	},
+	"rest": {
+		// Return a copy of the array with the first element removed.
+		Fn: func(rt *Runtime, args ...Object) Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments. got=%d, want=1", len(args))
+			}
+
+			array, ok := args[0].(*Array)
+			if !ok {
+				return newError(
+					"'rest' only accepts an array as an argument. got=%s",
+					args[0].Type(),
+				)
+			}
+
+			length := len(array.Elements)
+			if length == 0 {
+				return NULL
+			}
+
+			elements := make([]Object, length-1)
+
+			if length == 1 {
+				return &Array{Elements: elements}
+			}
+
+			copy(elements, array.Elements[1:])
+			return &Array{Elements: elements}
+		},
+	},
+	"push": {
+		// Return a cloned array with a new value appended to it.
+		Fn: func(rt *Runtime, args ...Object) Object {
+			if len(args) < 2 {
+				return newError("wrong number of arguments. got=%d, want=>2", len(args))
+			}
+
+			array, ok := args[0].(*Array)
+			if !ok {
+				return newError(
+					"the first argument needs to be of type ARRAY. got=%s",
+					args[0].Type(),
+				)
+			}
+
+			length := len(array.Elements)
+
+			elements := make([]Object, length)
+			copy(elements, array.Elements)
+			elements = append(elements, args[1:]...)
+
+			return &Array{Elements: elements}
+		},
+	},
+	"keys": {
+		// Return an array of the keys of a hash in insertion order.
+		Fn: func(rt *Runtime, args ...Object) Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments. got=%d, want=1", len(args))
+			}
+
+			hash, ok := args[0].(*Hash)
+			if !ok {
+				return newError("'keys' only accepts a hash as an argument. got=%s", args[0].Type())
+			}
+
+			elements := make([]Object, 0, hash.Len())
+			for _, pair := range hash.Pairs() {
+				elements = append(elements, pair.Key)
+			}
+
+			return &Array{Elements: elements}
+		},
+	},
+	"values": {
+		// Return an array of the values of a hash or the elements of a set in insertion order.
+		Fn: func(rt *Runtime, args ...Object) Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments. got=%d, want=1", len(args))
+			}
+
+			switch arg := args[0].(type) {
+			case *Hash:
+				elements := make([]Object, 0, arg.Len())
+				for _, pair := range arg.Pairs() {
+					elements = append(elements, pair.Value)
+				}
+
+				return &Array{Elements: elements}
+			case *Set:
+				return &Array{Elements: arg.Elements()}
+			default:
+				return newError("'values' only accepts a hash or set as an argument. got=%s", args[0].Type())
+			}
+		},
+	},
+	"entries": {
+		// Return an array of [key, value] arrays for a hash in insertion order.
+		Fn: func(rt *Runtime, args ...Object) Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments. got=%d, want=1", len(args))
+			}
+
+			hash, ok := args[0].(*Hash)
+			if !ok {
+				return newError("'entries' only accepts a hash as an argument. got=%s", args[0].Type())
+			}
+
+			// An entry is an array of two elements
+			size := addSizes(sizeOfArray(int64(hash.Len())), sizeOfArray(2)*int64(hash.Len()))
+			if err := rt.reserve(size); err != nil {
+				return err
+			}
+
+			elements := make([]Object, 0, hash.Len())
+			for _, pair := range hash.Pairs() {
+				entry := &Array{Elements: []Object{pair.Key, pair.Value}}
+				if err := rt.Allocate(entry); err != nil {
+					return err
+				}
+				elements = append(elements, entry)
+			}
+
+			return &Array{Elements: elements}
+		},
+	},
+	"has": {
+		// Check if a hash contains a key or a set contains an element.
+		Fn: func(rt *Runtime, args ...Object) Object {
+			if len(args) != 2 {
+				return newError("wrong number of arguments. got=%d, want=2", len(args))
+			}
+
+			key, ok := args[1].(Hashable)
+			if !ok {
+				return newError("unhashable key: %s", args[1].Type())
+			}
+
+			switch arg := args[0].(type) {
+			case *Hash:
+				_, ok = arg.Get(key)
+				return nativeBoolToBooleanObject(ok)
+			case *Set:
+				return nativeBoolToBooleanObject(arg.Has(key))
+			default:
+				return newError("the first argument needs to be of type HASH or SET. got=%s", args[0].Type())
+			}
+		},
+	},
+	"delete": {
+		// Return a cloned hash with a key removed.
+		Fn: func(rt *Runtime, args ...Object) Object {
+			if len(args) != 2 {
+				return newError("wrong number of arguments. got=%d, want=2", len(args))
+			}
+
+			hash, ok := args[0].(*Hash)
+			if !ok {
+				return newError("the first argument needs to be of type HASH. got=%s", args[0].Type())
+			}
+
+			key, ok := args[1].(Hashable)
+			if !ok {
+				return newError("unhashable key: %s", args[1].Type())
+			}
+
+			cloned := hash.Copy()
+			cloned.Delete(key)
+
+			return cloned
+		},
+	},
+	"merge": {
+		// Return a new hash containing the pairs of every hash, with later hashes taking precedence.
+		Fn: func(rt *Runtime, args ...Object) Object {
+			if len(args) < 2 {
+				return newError("wrong number of arguments. got=%d, want=>2", len(args))
+			}
+
+			merged := &Hash{}
+			for i, arg := range args {
+				hash, ok := arg.(*Hash)
+				if !ok {
+					return newError("argument %d to `merge` must be HASH. got=%s", i+1, arg.Type())
+				}
+
+				for _, pair := range hash.Pairs() {
+					merged.Set(pair.Key.(Hashable), pair.Value)
+				}
+			}
+
+			return merged
+		},
+	},
+	"set": {
+		// Create a set from the elements of an optional array.
+		Fn: func(rt *Runtime, args ...Object) Object {
+			if len(args) > 1 {
+				return newError("wrong number of arguments. got=%d, want=0 or 1", len(args))
+			}
+
+			set := &Set{}
+			if len(args) == 0 {
+				return set
+			}
+
+			array, ok := args[0].(*Array)
+			if !ok {
+				return newError("'set' only accepts an array as an argument. got=%s", args[0].Type())
+			}
+
+			for _, element := range array.Elements {
+				hashable, ok := element.(Hashable)
+				if !ok {
+					return newError("unhashable element: %s", element.Type())
+				}
+
+				set.Add(hashable)
+			}
+
+			return set
+		},
+	},
+	"add": {
+		// Return a cloned set with new elements added to it.
+		Fn: func(rt *Runtime, args ...Object) Object {
+			if len(args) < 2 {
+				return newError("wrong number of arguments. got=%d, want=>2", len(args))
+			}
+
+			set, ok := args[0].(*Set)
+			if !ok {
+				return newError("the first argument needs to be of type SET. got=%s", args[0].Type())
+			}
+
+			cloned := set.Copy()
+			for _, element := range args[1:] {
+				hashable, ok := element.(Hashable)
+				if !ok {
+					return newError("unhashable element: %s", element.Type())
+				}
+
+				cloned.Add(hashable)
+			}
+
+			return cloned
+		},
+	},
+	"remove": {
+		// Return a cloned set with an element removed.
+		Fn: func(rt *Runtime, args ...Object) Object {
+			if len(args) != 2 {
+				return newError("wrong number of arguments. got=%d, want=2", len(args))
+			}
+
+			set, ok := args[0].(*Set)
+			if !ok {
+				return newError("the first argument needs to be of type SET. got=%s", args[0].Type())
+			}
+
+			element, ok := args[1].(Hashable)
+			if !ok {
+				return newError("unhashable element: %s", args[1].Type())
+			}
+
+			cloned := set.Copy()
+			cloned.Remove(element)
+
+			return cloned
+		},
+	},
+	"union": {
+		// Return a new set with the elements that are in either set.
+		Fn: func(rt *Runtime, args ...Object) Object {
+			return applySetOperation("union", (*Set).Union, args)
+		},
+	},
+	"intersection": {
+		// Return a new set with the elements that are in both sets.
+		Fn: func(rt *Runtime, args ...Object) Object {
+			return applySetOperation("intersection", (*Set).Intersection, args)
+		},
+	},
+	"difference": {
+		// Return a new set with the elements of the first set that are not in the second.
+		Fn: func(rt *Runtime, args ...Object) Object {
+			return applySetOperation("difference", (*Set).Difference, args)
+		},
+	},
+	"quit": {
+		// Stop the program with an optional exit code, defaulting to 0.
+		// The exit code is recorded in the runtime so the host can decide what to do with it.
+		Fn: func(rt *Runtime, args ...Object) Object {
+			if len(args) > 1 {
+				return newError("wrong number of arguments. got=%d, want=0 or 1", len(args))
+			}
+
+			var code int64
+			if len(args) == 1 {
+				integer, ok := args[0].(*Integer)
+				if !ok {
+					return newError("argument to `quit` must be INTEGER. got=%s", args[0].Type())
+				}
+				code = integer.Value
+			}
+
+			rt.Exit(int(code))
+
+			return &Exit{Code: code}
+		},
+	},
+	"puts": {
+		// Write every argument on its own line to the output of the runtime.
+		Fn: func(rt *Runtime, args ...Object) Object {
+			for _, arg := range args {
+				fmt.Fprintln(rt.Stdout, arg.Inspect())
+			}
+			return NULL
+		},
+	},
+	"gets": {
+		Fn: readLine,
+	},
+	"read_line": {
+		Fn: readLine,
+	},
+}
+
+// readLine reads the next line from the input of the runtime without the line ending.
+// Returns null once the input is exhausted.
+func readLine(rt *Runtime, args ...Object) Object {
+	if len(args) != 0 {
+		return newError("wrong number of arguments. got=%d, want=0", len(args))
+	}
+
+	line, err := rt.Stdin.ReadString('\n')
+	if err == io.EOF && line == "" {
+		return NULL
+	}
+	if err != nil && err != io.EOF {
+		return newError("could not read a line: %s", err)
+	}
+
+	line = strings.TrimSuffix(line, "\n")
+	line = strings.TrimSuffix(line, "\r")
+
+	return &String{Value: line}
+}
+
+// applySetOperation validates the arguments of a builtin that combines two sets.
+func applySetOperation(
+	name string,
+	operation func(*Set, *Set) *Set,
+	args []Object,
+) Object {
+	if len(args) != 2 {
+		return newError("wrong number of arguments. got=%d, want=2", len(args))
+	}
+
+	left, ok := args[0].(*Set)
+	if !ok {
+		return newError("argument 1 to `%s` must be SET. got=%s", name, args[0].Type())
+	}
+
+	right, ok := args[1].(*Set)
+	if !ok {
+		return newError("argument 2 to `%s` must be SET. got=%s", name, args[1].Type())
+	}
+
+	return operation(left, right)
+}
+
+var (
+	builtinNames     []string
+	builtinNamesOnce sync.Once
+)
+
+// namespaces group related builtins and constants under a single name, e.g. `math.sqrt`, so they do not crowd the
+// global scope. A namespace is a hash of names to values. Callers only get copies, so no one can change the originals.
+var namespaces = map[string]*Hash{}
+
+// LookupBuiltin gets the builtin function or namespace bound to a name.
+// Every call gets a new copy of a namespace, see Runtime.LookupBuiltin for getting the same copy every time.
+func LookupBuiltin(name string) (Object, bool) {
+	if builtin, ok := Builtins[name]; ok {
+		return builtin, true
+	}
+
+	if namespace, ok := namespaces[name]; ok {
+		return namespace.Copy(), true
+	}
+
+	return nil, false
+}
+
+// BuiltinNames returns the names of all the builtins and namespaces in a stable order.
+// The position of a name is used by the compiler and virtual machine to refer to a builtin.
+func BuiltinNames() []string {
+	builtinNamesOnce.Do(func() {
+		for name := range Builtins {
+			builtinNames = append(builtinNames, name)
+		}
+		for name := range namespaces {
+			builtinNames = append(builtinNames, name)
+		}
+		slices.Sort(builtinNames)
+	})
+
+	return builtinNames
+}
+
+// newNamespace creates a namespace from builtin functions and constants.
+// Members are sorted by name so the namespace is inspected the same way every time.
+func newNamespace(functions map[string]BuiltinFunction, constants map[string]Object) *Hash {
+	members := make(map[string]Object, len(functions)+len(constants))
+	names := make([]string, 0, len(functions)+len(constants))
+	for name, fn := range functions {
+		members[name] = &Builtin{Fn: fn}
+		names = append(names, name)
+	}
+	for name, value := range constants {
+		members[name] = value
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	namespace := &Hash{}
+	for _, name := range names {
+		namespace.Set(&String{Value: name}, members[name])
+	}
+
+	return namespace
+}
+
+func newError(format string, a ...any) *Error {
+	return &Error{Message: fmt.Sprintf(format, a...)}
+}
+
+func nativeBoolToBooleanObject(value bool) *Boolean {
+	if value {
+		return TRUE
+	}
+	return FALSE
+}
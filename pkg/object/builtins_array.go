@@ -0,0 +1,368 @@
+package object
+
+import (
+	"math"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+func init() {
+	for name, fn := range arrayBuiltins {
+		Builtins[name] = &Builtin{Fn: fn}
+	}
+}
+
+var arrayBuiltins = map[string]BuiltinFunction{
+	// Return a reversed copy of an array.
+	"reverse": func(rt *Runtime, args ...Object) Object {
+		if len(args) != 1 {
+			return newError("wrong number of arguments. got=%d, want=1", len(args))
+		}
+
+		array, ok := args[0].(*Array)
+		if !ok {
+			return newError("'reverse' only accepts an array as an argument. got=%s", args[0].Type())
+		}
+
+		elements := make([]Object, len(array.Elements))
+		copy(elements, array.Elements)
+		slices.Reverse(elements)
+
+		return &Array{Elements: elements}
+	},
+	// Check if an array contains a value or a string contains a substring.
+	"contains": func(rt *Runtime, args ...Object) Object {
+		if len(args) != 2 {
+			return newError("wrong number of arguments. got=%d, want=2", len(args))
+		}
+
+		switch arg := args[0].(type) {
+		case *Array:
+			return nativeBoolToBooleanObject(indexOf(arg, args[1]) != -1)
+		case *String:
+			substr, ok := args[1].(*String)
+			if !ok {
+				return newError("argument 2 to `contains` must be STRING. got=%s", args[1].Type())
+			}
+
+			return nativeBoolToBooleanObject(strings.Contains(arg.Value, substr.Value))
+		default:
+			return newError("the first argument needs to be of type ARRAY or STRING. got=%s", args[0].Type())
+		}
+	},
+	// Get the index of the first element of an array equal to a value or the first instance of a substring in a
+	// string, counted in characters, i.e. runes. Returns -1 if there is none.
+	"index_of": func(rt *Runtime, args ...Object) Object {
+		if len(args) != 2 {
+			return newError("wrong number of arguments. got=%d, want=2", len(args))
+		}
+
+		switch arg := args[0].(type) {
+		case *Array:
+			return &Integer{Value: int64(indexOf(arg, args[1]))}
+		case *String:
+			substr, ok := args[1].(*String)
+			if !ok {
+				return newError("argument 2 to `index_of` must be STRING. got=%s", args[1].Type())
+			}
+
+			index := strings.Index(arg.Value, substr.Value)
+			if index > 0 {
+				index = utf8.RuneCountInString(arg.Value[:index])
+			}
+
+			return &Integer{Value: int64(index)}
+		default:
+			return newError("the first argument needs to be of type ARRAY or STRING. got=%s", args[0].Type())
+		}
+	},
+	// Create an array of integers from start (inclusive) to end (exclusive).
+	// range(end), range(start, end), and range(start, end, step) are supported.
+	"range": func(rt *Runtime, args ...Object) Object {
+		if len(args) < 1 || len(args) > 3 {
+			return newError("wrong number of arguments. got=%d, want=1 to 3", len(args))
+		}
+
+		bounds := make([]int64, len(args))
+		for i, arg := range args {
+			integer, ok := arg.(*Integer)
+			if !ok {
+				return newError("argument %d to `range` must be INTEGER. got=%s", i+1, arg.Type())
+			}
+			bounds[i] = integer.Value
+		}
+
+		var start, end, step int64 = 0, 0, 1
+		switch len(bounds) {
+		case 1:
+			end = bounds[0]
+		case 2:
+			start, end = bounds[0], bounds[1]
+		case 3:
+			start, end, step = bounds[0], bounds[1], bounds[2]
+		}
+
+		if step == 0 {
+			return newError("'range' step cannot be zero")
+		}
+
+		count := rangeLength(start, end, step)
+		if err := rt.reserve(sizeOfArray(count)); err != nil {
+			return err
+		}
+
+		elements := make([]Object, count)
+		for i := range elements {
+			elements[i] = &Integer{Value: start + int64(i)*step}
+		}
+
+		return &Array{Elements: elements}
+	},
+	// Combine arrays into an array of arrays where the ith array holds the ith element of every array.
+	// The result is as long as the shortest array.
+	"zip": func(rt *Runtime, args ...Object) Object {
+		if len(args) < 2 {
+			return newError("wrong number of arguments. got=%d, want=>2", len(args))
+		}
+
+		arrays := make([]*Array, len(args))
+		length := -1
+		for i, arg := range args {
+			array, ok := arg.(*Array)
+			if !ok {
+				return newError("argument %d to `zip` must be ARRAY. got=%s", i+1, arg.Type())
+			}
+
+			arrays[i] = array
+			if length == -1 || len(array.Elements) < length {
+				length = len(array.Elements)
+			}
+		}
+
+		tupleSize := sizeOfArray(int64(len(arrays)))
+		size := sizeOfArray(int64(length))
+		if length > 0 && tupleSize > (math.MaxInt64-size)/int64(length) {
+			size = -1
+		} else {
+			size += tupleSize * int64(length)
+		}
+
+		if err := rt.reserve(size); err != nil {
+			return err
+		}
+
+		elements := make([]Object, length)
+		for i := range length {
+			tuple := make([]Object, len(arrays))
+			for j, array := range arrays {
+				tuple[j] = array.Elements[i]
+			}
+			elements[i] = &Array{Elements: tuple}
+			if err := rt.Allocate(elements[i]); err != nil {
+				return err
+			}
+		}
+
+		return &Array{Elements: elements}
+	},
+	// Return a new array with the elements of any nested arrays moved up one level.
+	"flatten": func(rt *Runtime, args ...Object) Object {
+		if len(args) != 1 {
+			return newError("wrong number of arguments. got=%d, want=1", len(args))
+		}
+
+		array, ok := args[0].(*Array)
+		if !ok {
+			return newError("'flatten' only accepts an array as an argument. got=%s", args[0].Type())
+		}
+
+		var length int64
+		for _, element := range array.Elements {
+			if nested, ok := element.(*Array); ok {
+				length += int64(len(nested.Elements))
+			} else {
+				length++
+			}
+		}
+
+		if err := rt.reserve(sizeOfArray(length)); err != nil {
+			return err
+		}
+
+		elements := make([]Object, 0, length)
+		for _, element := range array.Elements {
+			if nested, ok := element.(*Array); ok {
+				elements = append(elements, nested.Elements...)
+			} else {
+				elements = append(elements, element)
+			}
+		}
+
+		return &Array{Elements: elements}
+	},
+	// Join the elements of an array into a string with an optional separator.
+	"join": func(rt *Runtime, args ...Object) Object {
+		if len(args) != 1 && len(args) != 2 {
+			return newError("wrong number of arguments. got=%d, want=1 or 2", len(args))
+		}
+
+		array, ok := args[0].(*Array)
+		if !ok {
+			return newError("argument 1 to `join` must be ARRAY. got=%s", args[0].Type())
+		}
+
+		separator := ""
+		if len(args) == 2 {
+			str, ok := args[1].(*String)
+			if !ok {
+				return newError("argument 2 to `join` must be STRING. got=%s", args[1].Type())
+			}
+			separator = str.Value
+		}
+
+		elements := make([]string, len(array.Elements))
+		length := int64(len(separator)) * int64(max(len(elements)-1, 0))
+		for i, element := range array.Elements {
+			elements[i] = element.Inspect()
+			length += int64(len(elements[i]))
+		}
+
+		if err := rt.reserve(sizeOfString(length)); err != nil {
+			return err
+		}
+
+		return &String{Value: strings.Join(elements, separator)}
+	},
+	// Return a new array with the elements of every array.
+	"concat": func(rt *Runtime, args ...Object) Object {
+		var length int64
+		for i, arg := range args {
+			array, ok := arg.(*Array)
+			if !ok {
+				return newError("argument %d to `concat` must be ARRAY. got=%s", i+1, arg.Type())
+			}
+
+			length += int64(len(array.Elements))
+		}
+
+		if err := rt.reserve(sizeOfArray(length)); err != nil {
+			return err
+		}
+
+		elements := make([]Object, 0, length)
+		for _, arg := range args {
+			elements = append(elements, arg.(*Array).Elements...)
+		}
+
+		return &Array{Elements: elements}
+	},
+	// Return a copy of the elements of an array from start (inclusive) to an optional end (exclusive).
+	// Negative indexes count back from the end of the array.
+	"slice": func(rt *Runtime, args ...Object) Object {
+		if len(args) != 2 && len(args) != 3 {
+			return newError("wrong number of arguments. got=%d, want=2 or 3", len(args))
+		}
+
+		array, ok := args[0].(*Array)
+		if !ok {
+			return newError("argument 1 to `slice` must be ARRAY. got=%s", args[0].Type())
+		}
+
+		start, end, err := sliceBounds("slice", len(array.Elements), args[1:])
+		if err != nil {
+			return err
+		}
+
+		elements := make([]Object, end-start)
+		copy(elements, array.Elements[start:end])
+
+		return &Array{Elements: elements}
+	},
+}
+
+// rangeLength gets the number of integers from start (inclusive) to end (exclusive) in steps of step, which is not zero.
+func rangeLength(start, end, step int64) int64 {
+	// The distances are unsigned so that they cannot overflow
+	var distance, stride uint64
+	switch {
+	case step > 0 && start < end:
+		distance, stride = uint64(end)-uint64(start), uint64(step)
+	case step < 0 && start > end:
+		distance, stride = uint64(start)-uint64(end), -uint64(step)
+	default:
+		return 0
+	}
+
+	return int64(min((distance-1)/stride+1, math.MaxInt64))
+}
+
+// sliceBounds converts the optional start and end arguments of a slicing builtin into bounds for a sequence.
+// Negative indexes count back from the end and out of range indexes are clamped to the sequence.
+func sliceBounds(name string, length int, args []Object) (int, int, *Error) {
+	bounds := []int{0, length}
+	for i, arg := range args {
+		integer, ok := arg.(*Integer)
+		if !ok {
+			return 0, 0, newError("argument %d to `%s` must be INTEGER. got=%s", i+2, name, arg.Type())
+		}
+
+		bound := int(integer.Value)
+		if bound < 0 {
+			bound += length
+		}
+
+		bounds[i] = max(0, min(bound, length))
+	}
+
+	start, end := bounds[0], bounds[1]
+	if end < start {
+		end = start
+	}
+
+	return start, end, nil
+}
+
+// indexOf gets the index of the first element of an array equal to a value.
+// Returns -1 if there is no such element.
+func indexOf(array *Array, value Object) int {
+	return slices.IndexFunc(array.Elements, func(element Object) bool {
+		return objectsEqual(element, value)
+	})
+}
+
+// objectsEqual compares two objects by value.
+// Arrays and hashes are equal if their elements are equal, every other object is compared by identity.
+func objectsEqual(a, b Object) bool {
+	switch a := a.(type) {
+	case *Integer:
+		b, ok := b.(*Integer)
+		return ok && a.Value == b.Value
+	case *Float:
+		b, ok := b.(*Float)
+		return ok && a.Value == b.Value
+	case *String:
+		b, ok := b.(*String)
+		return ok && a.Value == b.Value
+	case *Array:
+		b, ok := b.(*Array)
+		return ok && slices.EqualFunc(a.Elements, b.Elements, objectsEqual)
+	case *Hash:
+		b, ok := b.(*Hash)
+		if !ok || a.Len() != b.Len() {
+			return false
+		}
+
+		for _, pair := range a.Pairs() {
+			other, ok := b.Get(pair.Key.(Hashable))
+			if !ok || !objectsEqual(pair.Value, other.Value) {
+				return false
+			}
+		}
+
+		return true
+	default:
+		// Booleans and null are singletons
+		return a == b
+	}
+}
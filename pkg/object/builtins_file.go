@@ -0,0 +1,162 @@
+package object
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"strings"
+)
+
+func init() {
+	for name, fn := range fileBuiltins {
+		Builtins[name] = &Builtin{Fn: fn}
+	}
+}
+
+// The file builtins can only access the files permitted by the file system of the runtime.
+var fileBuiltins = map[string]BuiltinFunction{
+	// Read the contents of a file.
+	"read_file": func(rt *Runtime, args ...Object) Object {
+		str, err := stringArg("read_file", args)
+		if err != nil {
+			return err
+		}
+
+		path, pathErr := rt.FileSystem.readable(str.Value)
+		if pathErr != nil {
+			return newError("could not read %q: %s", str.Value, pathErr)
+		}
+
+		contents, readErr := readFile(rt, path)
+		var limitErr *Error
+		if errors.As(readErr, &limitErr) {
+			return limitErr
+		}
+		if readErr != nil {
+			return newError("could not read %q: %s", str.Value, unwrapPathError(readErr))
+		}
+
+		return &String{Value: string(contents)}
+	},
+	// Read the lines of a file without their line endings.
+	"read_lines": func(rt *Runtime, args ...Object) Object {
+		str, err := stringArg("read_lines", args)
+		if err != nil {
+			return err
+		}
+
+		path, pathErr := rt.FileSystem.readable(str.Value)
+		if pathErr != nil {
+			return newError("could not read %q: %s", str.Value, pathErr)
+		}
+
+		contents, readErr := readFile(rt, path)
+		var limitErr *Error
+		if errors.As(readErr, &limitErr) {
+			return limitErr
+		}
+		if readErr != nil {
+			return newError("could not read %q: %s", str.Value, unwrapPathError(readErr))
+		}
+
+		text := strings.TrimSuffix(string(contents), "\n")
+		if text == "" {
+			return &Array{Elements: []Object{}}
+		}
+
+		lines := strings.Split(text, "\n")
+		for i, line := range lines {
+			lines[i] = strings.TrimSuffix(line, "\r")
+		}
+
+		return stringsToArray(rt, lines)
+	},
+	// Create or overwrite a file with a string.
+	"write_file": func(rt *Runtime, args ...Object) Object {
+		strs, err := stringArgs("write_file", 2, args)
+		if err != nil {
+			return err
+		}
+
+		path, pathErr := rt.FileSystem.writable(strs[0])
+		if pathErr != nil {
+			return newError("could not write %q: %s", strs[0], pathErr)
+		}
+
+		writeErr := os.WriteFile(path, []byte(strs[1]), 0o644)
+		if writeErr != nil {
+			return newError("could not write %q: %s", strs[0], unwrapPathError(writeErr))
+		}
+
+		return NULL
+	},
+	// Get the sorted names of the entries in a directory, defaulting to the root directory.
+	"list_dir": func(rt *Runtime, args ...Object) Object {
+		if len(args) > 1 {
+			return newError("wrong number of arguments. got=%d, want=0 or 1", len(args))
+		}
+
+		name := "."
+		if len(args) == 1 {
+			str, ok := args[0].(*String)
+			if !ok {
+				return newError("argument to `list_dir` must be STRING. got=%s", args[0].Type())
+			}
+			name = str.Value
+		}
+
+		path, pathErr := rt.FileSystem.readable(name)
+		if pathErr != nil {
+			return newError("could not list %q: %s", name, pathErr)
+		}
+
+		entries, readErr := os.ReadDir(path)
+		if readErr != nil {
+			return newError("could not list %q: %s", name, unwrapPathError(readErr))
+		}
+
+		names := make([]string, len(entries))
+		for i, entry := range entries {
+			names[i] = entry.Name()
+		}
+
+		return stringsToArray(rt, names)
+	},
+	// Check if a file or directory exists.
+	"exists": func(rt *Runtime, args ...Object) Object {
+		str, err := stringArg("exists", args)
+		if err != nil {
+			return err
+		}
+
+		path, pathErr := rt.FileSystem.readable(str.Value)
+		if pathErr != nil {
+			return newError("could not check %q: %s", str.Value, pathErr)
+		}
+
+		_, statErr := os.Stat(path)
+		if errors.Is(statErr, fs.ErrNotExist) {
+			return FALSE
+		}
+		if statErr != nil {
+			return newError("could not check %q: %s", str.Value, unwrapPathError(statErr))
+		}
+
+		return TRUE
+	},
+}
+
+// readFile reads the contents of a file, if the program has the memory for a string that holds them.
+// Returns the *Error for the memory limit of the program if it does not.
+func readFile(rt *Runtime, path string) ([]byte, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := rt.reserve(sizeOfString(info.Size())); err != nil {
+		return nil, err
+	}
+
+	return os.ReadFile(path)
+}
@@ -0,0 +1,229 @@
+package object
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+)
+
+func init() {
+	for name, fn := range higherOrderBuiltins {
+		Builtins[name] = &Builtin{Fn: fn}
+	}
+}
+
+// The higher-order builtins call back into the Monkey functions passed to them through Runtime.Call, which calls them
+// with the engine running the program.
+var higherOrderBuiltins = map[string]BuiltinFunction{
+	// Return a new array with the result of calling a function on every element.
+	"map": func(rt *Runtime, args ...Object) Object {
+		array, fn, err := arrayAndFunctionArgs("map", args)
+		if err != nil {
+			return err
+		}
+
+		elements := make([]Object, len(array.Elements))
+		for i, element := range array.Elements {
+			result := rt.Call(fn, element)
+			if isError(result) {
+				return result
+			}
+
+			elements[i] = result
+		}
+
+		return &Array{Elements: elements}
+	},
+	// Return a new array with the elements for which a function returns a truthy value.
+	"filter": func(rt *Runtime, args ...Object) Object {
+		array, fn, err := arrayAndFunctionArgs("filter", args)
+		if err != nil {
+			return err
+		}
+
+		elements := []Object{}
+		for _, element := range array.Elements {
+			result := rt.Call(fn, element)
+			if isError(result) {
+				return result
+			}
+
+			if isTruthy(result) {
+				elements = append(elements, element)
+			}
+		}
+
+		return &Array{Elements: elements}
+	},
+	// Combine the elements of an array into a single value by calling a function with the accumulated value and
+	// each element. The first element is used as the initial value if one is not given.
+	"reduce": func(rt *Runtime, args ...Object) Object {
+		if len(args) != 2 && len(args) != 3 {
+			return newError("wrong number of arguments. got=%d, want=2 or 3", len(args))
+		}
+
+		array, fn, err := arrayAndFunctionArgs("reduce", args[:2])
+		if err != nil {
+			return err
+		}
+
+		elements := array.Elements
+
+		var accumulated Object
+		if len(args) == 3 {
+			accumulated = args[2]
+		} else {
+			if len(elements) == 0 {
+				return newError("'reduce' of an empty array with no initial value")
+			}
+
+			accumulated, elements = elements[0], elements[1:]
+		}
+
+		for _, element := range elements {
+			accumulated = rt.Call(fn, accumulated, element)
+			if isError(accumulated) {
+				return accumulated
+			}
+		}
+
+		return accumulated
+	},
+	// Return a sorted copy of an array.
+	// Without a comparator the elements must all be integers or all be strings. A comparator is called with two
+	// elements and returns a negative integer if the first should come first, a positive integer if it should come
+	// second, and zero if their order does not matter.
+	"sort": func(rt *Runtime, args ...Object) Object {
+		if len(args) != 1 && len(args) != 2 {
+			return newError("wrong number of arguments. got=%d, want=1 or 2", len(args))
+		}
+
+		array, ok := args[0].(*Array)
+		if !ok {
+			return newError("argument 1 to `sort` must be ARRAY. got=%s", args[0].Type())
+		}
+
+		elements := make([]Object, len(array.Elements))
+		copy(elements, array.Elements)
+
+		var compare func(a, b Object) Object
+		if len(args) == 2 {
+			if !isCallable(args[1]) {
+				return newError("argument 2 to `sort` must be FUNCTION. got=%s", args[1].Type())
+			}
+
+			compare = func(a, b Object) Object {
+				return rt.Call(args[1], a, b)
+			}
+		} else {
+			compare = compareObjects
+		}
+
+		// The first error aborts the comparisons, leaving the copy partially sorted which is fine as it is discarded
+		var sortErr Object
+		slices.SortStableFunc(elements, func(a, b Object) int {
+			if sortErr != nil {
+				return 0
+			}
+
+			result := compare(a, b)
+			if isError(result) {
+				sortErr = result
+				return 0
+			}
+
+			integer, ok := result.(*Integer)
+			if !ok {
+				sortErr = newError("comparator for `sort` must return INTEGER. got=%s", result.Type())
+				return 0
+			}
+
+			switch {
+			case integer.Value < 0:
+				return -1
+			case integer.Value > 0:
+				return 1
+			default:
+				return 0
+			}
+		})
+
+		if sortErr != nil {
+			return sortErr
+		}
+
+		return &Array{Elements: elements}
+	},
+}
+
+// arrayAndFunctionArgs validates the arguments of a builtin that calls a function on the elements of an array.
+func arrayAndFunctionArgs(name string, args []Object) (*Array, Object, *Error) {
+	if len(args) != 2 {
+		return nil, nil, newError("wrong number of arguments. got=%d, want=2", len(args))
+	}
+
+	array, ok := args[0].(*Array)
+	if !ok {
+		return nil, nil, newError("argument 1 to `%s` must be ARRAY. got=%s", name, args[0].Type())
+	}
+
+	if !isCallable(args[1]) {
+		return nil, nil, newError("argument 2 to `%s` must be FUNCTION. got=%s", name, args[1].Type())
+	}
+
+	return array, args[1], nil
+}
+
+// isCallable checks if an object can be applied to arguments.
+func isCallable(obj Object) bool {
+	switch obj.(type) {
+	case *Function, *Closure, *Builtin:
+		return true
+	default:
+		return false
+	}
+}
+
+// compareObjects is the default comparator for `sort`.
+func compareObjects(a, b Object) Object {
+	switch a := a.(type) {
+	case *Integer:
+		if b, ok := b.(*Integer); ok {
+			return &Integer{Value: int64(cmp.Compare(a.Value, b.Value))}
+		}
+	case *String:
+		if b, ok := b.(*String); ok {
+			return &Integer{Value: int64(strings.Compare(a.Value, b.Value))}
+		}
+	}
+
+	// Integers and floats can be compared with each other
+	if a, ok := AsFloat(a); ok {
+		if b, ok := AsFloat(b); ok {
+			return &Integer{Value: int64(cmp.Compare(a, b))}
+		}
+	}
+
+	return newError("cannot compare %s and %s without a comparator", a.Type(), b.Type())
+}
+
+// isError checks if the result of a function stops the builtin calling it, which it does for errors and for `quit`.
+func isError(obj Object) bool {
+	return obj != nil && (obj.Type() == ERROR_OBJ || obj.Type() == EXIT_OBJ)
+}
+
+// isTruthy determines the truthiness of an object the same way the engines do.
+func isTruthy(obj Object) bool {
+	switch obj := obj.(type) {
+	case *Boolean:
+		return obj.Value
+	case *Null:
+		return false
+	case *Integer:
+		return obj.Value > 0
+	case *Float:
+		return obj.Value > 0
+	default:
+		return true
+	}
+}
@@ -0,0 +1,248 @@
+package object
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+func init() {
+	for name, fn := range jsonBuiltins {
+		Builtins[name] = &Builtin{Fn: fn}
+	}
+}
+
+var jsonBuiltins = map[string]BuiltinFunction{
+	// Parse JSON text into Monkey objects.
+	// Objects become hashes that keep the order of their keys, and numbers become integers when they are whole.
+	"json_parse": func(rt *Runtime, args ...Object) Object {
+		str, err := stringArg("json_parse", args)
+		if err != nil {
+			return err
+		}
+
+		decoder := json.NewDecoder(strings.NewReader(str.Value))
+		decoder.UseNumber()
+
+		value, decodeErr := decodeJSON(rt, decoder)
+		if decodeErr == nil {
+			// The text must hold a single value
+			if _, trailingErr := decoder.Token(); trailingErr != io.EOF {
+				decodeErr = errors.New("unexpected data after the JSON value")
+			}
+		}
+
+		var limitErr *Error
+		if errors.As(decodeErr, &limitErr) {
+			return limitErr
+		}
+		if decodeErr != nil {
+			return newError("could not parse JSON: %s", decodeErr)
+		}
+
+		return value
+	},
+	// Convert an object to JSON text, indented by an optional number of spaces or string.
+	"json_stringify": func(rt *Runtime, args ...Object) Object {
+		if len(args) != 1 && len(args) != 2 {
+			return newError("wrong number of arguments. got=%d, want=1 or 2", len(args))
+		}
+
+		var out bytes.Buffer
+		if err := encodeJSON(&out, args[0]); err != nil {
+			return newError("could not convert to JSON: %s", err)
+		}
+
+		if len(args) == 1 {
+			return &String{Value: out.String()}
+		}
+
+		var indent string
+		switch arg := args[1].(type) {
+		case *Integer:
+			if arg.Value < 0 {
+				return newError("'json_stringify' indent cannot be negative. got=%d", arg.Value)
+			}
+			indent = strings.Repeat(" ", int(arg.Value))
+		case *String:
+			indent = arg.Value
+		default:
+			return newError("argument 2 to `json_stringify` must be INTEGER or STRING. got=%s", args[1].Type())
+		}
+
+		var indented bytes.Buffer
+		if err := json.Indent(&indented, out.Bytes(), "", indent); err != nil {
+			return newError("could not convert to JSON: %s", err)
+		}
+
+		return &String{Value: indented.String()}
+	},
+}
+
+// decodeJSON reads the next JSON value from a decoder as a Monkey object.
+// The strings, arrays and hashes nested in the value are allocated by the program as they are read, so a value too
+// large for the memory limit of the program stops the parse with the *Error for the limit. The value itself is left for
+// the caller to allocate.
+func decodeJSON(rt *Runtime, decoder *json.Decoder) (Object, error) {
+	token, err := decoder.Token()
+	if err == io.EOF {
+		return nil, errors.New("unexpected end of JSON input")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	switch token := token.(type) {
+	case json.Delim:
+		switch token {
+		case '[':
+			elements := []Object{}
+			for decoder.More() {
+				element, err := decodeJSON(rt, decoder)
+				if err != nil {
+					return nil, err
+				}
+
+				if err := allocateJSON(rt, element); err != nil {
+					return nil, err
+				}
+				elements = append(elements, element)
+			}
+
+			// Consume the closing ']'
+			if _, err := decoder.Token(); err != nil {
+				return nil, err
+			}
+
+			return &Array{Elements: elements}, nil
+		case '{':
+			hash := &Hash{}
+			for decoder.More() {
+				// The decoder guarantees that the keys of an object are strings
+				key, err := decoder.Token()
+				if err != nil {
+					return nil, err
+				}
+
+				value, err := decodeJSON(rt, decoder)
+				if err != nil {
+					return nil, err
+				}
+
+				keyString := &String{Value: key.(string)}
+				if err := allocateJSON(rt, keyString); err != nil {
+					return nil, err
+				}
+				if err := allocateJSON(rt, value); err != nil {
+					return nil, err
+				}
+				hash.Set(keyString, value)
+			}
+
+			// Consume the closing '}'
+			if _, err := decoder.Token(); err != nil {
+				return nil, err
+			}
+
+			return hash, nil
+		default:
+			return nil, fmt.Errorf("unexpected %q", rune(token))
+		}
+	case json.Number:
+		if integer, err := token.Int64(); err == nil {
+			return &Integer{Value: integer}, nil
+		}
+
+		float, err := token.Float64()
+		if err != nil {
+			return nil, fmt.Errorf("number %s is out of range", token)
+		}
+
+		return &Float{Value: float}, nil
+	case string:
+		return &String{Value: token}, nil
+	case bool:
+		return nativeBoolToBooleanObject(token), nil
+	case nil:
+		return NULL, nil
+	default:
+		return nil, fmt.Errorf("unexpected token %v", token)
+	}
+}
+
+// allocateJSON allocates a value nested in a JSON value, unless it would exceed the memory limit of the program.
+func allocateJSON(rt *Runtime, obj Object) *Error {
+	if size, ok := sizeOf(obj); ok {
+		if err := rt.reserve(size); err != nil {
+			return err
+		}
+	}
+
+	return rt.Allocate(obj)
+}
+
+// encodeJSON writes an object as compact JSON text.
+// Hash keys that are not strings are written as the string of their value.
+func encodeJSON(out *bytes.Buffer, obj Object) error {
+	switch obj := obj.(type) {
+	case *Null:
+		out.WriteString("null")
+	case *Boolean:
+		out.WriteString(strconv.FormatBool(obj.Value))
+	case *Integer:
+		out.WriteString(strconv.FormatInt(obj.Value, 10))
+	case *Float:
+		if math.IsNaN(obj.Value) || math.IsInf(obj.Value, 0) {
+			return fmt.Errorf("unsupported float %s", obj.Inspect())
+		}
+		out.WriteString(obj.Inspect())
+	case *String:
+		encodeJSONString(out, obj.Value)
+	case *Array:
+		out.WriteByte('[')
+		for i, element := range obj.Elements {
+			if i > 0 {
+				out.WriteByte(',')
+			}
+			if err := encodeJSON(out, element); err != nil {
+				return err
+			}
+		}
+		out.WriteByte(']')
+	case *Hash:
+		out.WriteByte('{')
+		for i, pair := range obj.Pairs() {
+			if i > 0 {
+				out.WriteByte(',')
+			}
+			encodeJSONString(out, pair.Key.Inspect())
+			out.WriteByte(':')
+			if err := encodeJSON(out, pair.Value); err != nil {
+				return err
+			}
+		}
+		out.WriteByte('}')
+	default:
+		return fmt.Errorf("unsupported type %s", obj.Type())
+	}
+
+	return nil
+}
+
+// encodeJSONString writes a string as a quoted JSON string.
+// Unlike json.Marshal, the HTML characters <, >, and & are not escaped.
+func encodeJSONString(out *bytes.Buffer, str string) {
+	var quoted bytes.Buffer
+	encoder := json.NewEncoder(&quoted)
+	encoder.SetEscapeHTML(false)
+	// Encoding a string cannot fail
+	_ = encoder.Encode(str)
+
+	// Encode terminates the value with a newline
+	out.Write(bytes.TrimSuffix(quoted.Bytes(), []byte("\n")))
+}
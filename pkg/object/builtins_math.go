@@ -0,0 +1,251 @@
+package object
+
+import "math"
+
+func init() {
+	namespaces["math"] = newNamespace(mathBuiltins, map[string]Object{
+		"PI": &Float{Value: math.Pi},
+		"E":  &Float{Value: math.E},
+	})
+}
+
+var mathBuiltins = map[string]BuiltinFunction{
+	// Get the absolute value of a number.
+	"abs": func(rt *Runtime, args ...Object) Object {
+		if len(args) != 1 {
+			return newError("wrong number of arguments. got=%d, want=1", len(args))
+		}
+
+		switch arg := args[0].(type) {
+		case *Integer:
+			if arg.Value < 0 {
+				return &Integer{Value: -arg.Value}
+			}
+			return arg
+		case *Float:
+			return &Float{Value: math.Abs(arg.Value)}
+		default:
+			return newError("argument to `abs` must be INTEGER or FLOAT. got=%s", args[0].Type())
+		}
+	},
+	// Get the smallest of the numbers passed as arguments or in an array.
+	"min": func(rt *Runtime, args ...Object) Object {
+		return extremum("min", args, func(a, b float64) bool { return a < b })
+	},
+	// Get the largest of the numbers passed as arguments or in an array.
+	"max": func(rt *Runtime, args ...Object) Object {
+		return extremum("max", args, func(a, b float64) bool { return a > b })
+	},
+	// Raise a number to a power.
+	// The result is an integer when both numbers are integers and the exponent is not negative, and an error if that
+	// integer overflows.
+	"pow": func(rt *Runtime, args ...Object) Object {
+		values, err := numberArgs("pow", 2, args)
+		if err != nil {
+			return err
+		}
+
+		base, ok := args[0].(*Integer)
+		exponent, isInteger := args[1].(*Integer)
+		if !ok || !isInteger || exponent.Value < 0 {
+			return &Float{Value: math.Pow(values[0], values[1])}
+		}
+
+		result, ok := powIntegers(base.Value, exponent.Value)
+		if !ok {
+			return newError("integer overflow: pow(%d, %d)", base.Value, exponent.Value)
+		}
+
+		return &Integer{Value: result}
+	},
+	"sqrt":  floatFunction("sqrt", math.Sqrt),
+	"exp":   floatFunction("exp", math.Exp),
+	"log":   floatFunction("log", math.Log),
+	"log2":  floatFunction("log2", math.Log2),
+	"log10": floatFunction("log10", math.Log10),
+	"sin":   floatFunction("sin", math.Sin),
+	"cos":   floatFunction("cos", math.Cos),
+	"tan":   floatFunction("tan", math.Tan),
+	"asin":  floatFunction("asin", math.Asin),
+	"acos":  floatFunction("acos", math.Acos),
+	"atan":  floatFunction("atan", math.Atan),
+	// Get the angle between the positive x axis and the point (x, y), i.e. atan2(y, x).
+	"atan2": func(rt *Runtime, args ...Object) Object {
+		values, err := numberArgs("atan2", 2, args)
+		if err != nil {
+			return err
+		}
+
+		return &Float{Value: math.Atan2(values[0], values[1])}
+	},
+	"floor": roundingFunction("floor", math.Floor),
+	"ceil":  roundingFunction("ceil", math.Ceil),
+	// Round half away from zero.
+	"round": roundingFunction("round", math.Round),
+	// Get -1, 0, or 1 depending on the sign of a number.
+	"sign": func(rt *Runtime, args ...Object) Object {
+		values, err := numberArgs("sign", 1, args)
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case values[0] > 0:
+			return &Integer{Value: 1}
+		case values[0] < 0:
+			return &Integer{Value: -1}
+		default:
+			return &Integer{Value: 0}
+		}
+	},
+	// Get the greatest common divisor of two integers.
+	"gcd": func(rt *Runtime, args ...Object) Object {
+		if len(args) != 2 {
+			return newError("wrong number of arguments. got=%d, want=2", len(args))
+		}
+
+		values := make([]int64, len(args))
+		for i, arg := range args {
+			integer, ok := arg.(*Integer)
+			if !ok {
+				return newError("argument %d to `gcd` must be INTEGER. got=%s", i+1, arg.Type())
+			}
+			values[i] = integer.Value
+		}
+
+		a, b := values[0], values[1]
+		for b != 0 {
+			a, b = b, a%b
+		}
+
+		if a < 0 {
+			a = -a
+		}
+
+		return &Integer{Value: a}
+	},
+}
+
+// numberArgs validates the arguments of a builtin that accepts a fixed number of integers or floats.
+// Returns the values of the numbers as floats.
+func numberArgs(name string, want int, args []Object) ([]float64, *Error) {
+	if len(args) != want {
+		return nil, newError("wrong number of arguments. got=%d, want=%d", len(args), want)
+	}
+
+	values := make([]float64, len(args))
+	for i, arg := range args {
+		value, ok := AsFloat(arg)
+		if !ok {
+			return nil, newError("argument %d to `%s` must be INTEGER or FLOAT. got=%s", i+1, name, arg.Type())
+		}
+		values[i] = value
+	}
+
+	return values, nil
+}
+
+// floatFunction creates a builtin from a function of one float.
+func floatFunction(name string, fn func(float64) float64) BuiltinFunction {
+	return func(rt *Runtime, args ...Object) Object {
+		values, err := numberArgs(name, 1, args)
+		if err != nil {
+			return err
+		}
+
+		return &Float{Value: fn(values[0])}
+	}
+}
+
+// roundingFunction creates a builtin that rounds a number to an integer.
+func roundingFunction(name string, fn func(float64) float64) BuiltinFunction {
+	return func(rt *Runtime, args ...Object) Object {
+		values, err := numberArgs(name, 1, args)
+		if err != nil {
+			return err
+		}
+
+		if integer, ok := args[0].(*Integer); ok {
+			return integer
+		}
+
+		// The range of an int64 is [-2^63, 2^63), and NaN is outside of any range
+		rounded := fn(values[0])
+		if !(rounded >= math.MinInt64 && rounded < math.MaxInt64) {
+			return newError("cannot convert %s to an integer", args[0].Inspect())
+		}
+
+		return &Integer{Value: int64(rounded)}
+	}
+}
+
+// powIntegers raises an integer to a power that is not negative by squaring.
+// Returns false if the result overflows.
+func powIntegers(base, exponent int64) (int64, bool) {
+	result := int64(1)
+	for exponent > 0 {
+		var ok bool
+		if exponent&1 == 1 {
+			if result, ok = multiplyIntegers(result, base); !ok {
+				return 0, false
+			}
+		}
+
+		// The base is only squared if it is needed, so it cannot overflow needlessly
+		exponent >>= 1
+		if exponent > 0 {
+			if base, ok = multiplyIntegers(base, base); !ok {
+				return 0, false
+			}
+		}
+	}
+
+	return result, true
+}
+
+// multiplyIntegers multiplies two integers. Returns false if the product overflows.
+func multiplyIntegers(a, b int64) (int64, bool) {
+	if a == 0 || b == 0 {
+		return 0, true
+	}
+
+	// Dividing math.MinInt64 by -1 overflows as well, so it cannot detect the overflow
+	if (a == -1 && b == math.MinInt64) || (b == -1 && a == math.MinInt64) {
+		return 0, false
+	}
+
+	product := a * b
+	if product/b != a {
+		return 0, false
+	}
+
+	return product, true
+}
+
+// extremum gets the number that is preferred over every other number, passed either as arguments or in a single array.
+func extremum(name string, args []Object, prefer func(a, b float64) bool) Object {
+	if len(args) == 1 {
+		if array, ok := args[0].(*Array); ok {
+			args = array.Elements
+		}
+	}
+
+	if len(args) == 0 {
+		return newError("'%s' needs at least one number", name)
+	}
+
+	var result Object
+	var resultValue float64
+	for i, arg := range args {
+		value, ok := AsFloat(arg)
+		if !ok {
+			return newError("argument %d to `%s` must be INTEGER or FLOAT. got=%s", i+1, name, arg.Type())
+		}
+
+		if result == nil || prefer(value, resultValue) {
+			result, resultValue = arg, value
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,95 @@
+package object
+
+func init() {
+	for name, fn := range randomBuiltins {
+		Builtins[name] = &Builtin{Fn: fn}
+	}
+}
+
+// The random builtins use the random number generator of the runtime so that seeding it makes a program reproducible.
+var randomBuiltins = map[string]BuiltinFunction{
+	// Seed the random number generator of the interpreter.
+	"seed": func(rt *Runtime, args ...Object) Object {
+		if len(args) != 1 {
+			return newError("wrong number of arguments. got=%d, want=1", len(args))
+		}
+
+		seed, ok := args[0].(*Integer)
+		if !ok {
+			return newError("argument to `seed` must be INTEGER. got=%s", args[0].Type())
+		}
+
+		rt.Rand.Seed(seed.Value)
+
+		return NULL
+	},
+	// Get a random integer between lo and hi inclusive.
+	"random_int": func(rt *Runtime, args ...Object) Object {
+		if len(args) != 2 {
+			return newError("wrong number of arguments. got=%d, want=2", len(args))
+		}
+
+		bounds := make([]int64, len(args))
+		for i, arg := range args {
+			integer, ok := arg.(*Integer)
+			if !ok {
+				return newError("argument %d to `random_int` must be INTEGER. got=%s", i+1, arg.Type())
+			}
+			bounds[i] = integer.Value
+		}
+
+		lo, hi := bounds[0], bounds[1]
+		if hi < lo {
+			return newError("'random_int' upper bound %d is less than lower bound %d", hi, lo)
+		}
+
+		span := hi - lo + 1
+		if span <= 0 {
+			// The range is too large to be counted by an int64 so random integers are drawn until one is in range
+			for {
+				value := int64(rt.Rand.Uint64())
+				if value >= lo && value <= hi {
+					return &Integer{Value: value}
+				}
+			}
+		}
+
+		return &Integer{Value: lo + rt.Rand.Int63n(span)}
+	},
+	// Get a random element of an array. Returns null if the array is empty.
+	"random_choice": func(rt *Runtime, args ...Object) Object {
+		if len(args) != 1 {
+			return newError("wrong number of arguments. got=%d, want=1", len(args))
+		}
+
+		array, ok := args[0].(*Array)
+		if !ok {
+			return newError("'random_choice' only accepts an array as an argument. got=%s", args[0].Type())
+		}
+
+		if len(array.Elements) == 0 {
+			return NULL
+		}
+
+		return array.Elements[rt.Rand.Intn(len(array.Elements))]
+	},
+	// Return a shuffled copy of an array.
+	"shuffle": func(rt *Runtime, args ...Object) Object {
+		if len(args) != 1 {
+			return newError("wrong number of arguments. got=%d, want=1", len(args))
+		}
+
+		array, ok := args[0].(*Array)
+		if !ok {
+			return newError("'shuffle' only accepts an array as an argument. got=%s", args[0].Type())
+		}
+
+		elements := make([]Object, len(array.Elements))
+		copy(elements, array.Elements)
+		rt.Rand.Shuffle(len(elements), func(i, j int) {
+			elements[i], elements[j] = elements[j], elements[i]
+		})
+
+		return &Array{Elements: elements}
+	},
+}
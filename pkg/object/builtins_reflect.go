@@ -0,0 +1,121 @@
+package object
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/grantwforsythe/monkeylang/pkg/ast"
+)
+
+func init() {
+	for name, fn := range reflectBuiltins {
+		Builtins[name] = &Builtin{Fn: fn}
+	}
+
+	for name, types := range typePredicates {
+		Builtins[name] = &Builtin{Fn: typePredicate(types...)}
+	}
+}
+
+// typePredicates maps the name of each builtin that checks the type of an object to the types it accepts.
+var typePredicates = map[string][]ObjectType{
+	"is_int":      {INTEGER_OBJ},
+	"is_float":    {FLOAT_OBJ},
+	"is_number":   {INTEGER_OBJ, FLOAT_OBJ},
+	"is_string":   {STRING_OBJ},
+	"is_bool":     {BOOLEAN_OBJ},
+	"is_null":     {NULL_OBJ},
+	"is_array":    {ARRAY_OBJ},
+	"is_hash":     {HASH_OBJ},
+	"is_set":      {SET_OBJ},
+	"is_function": {FUNCTION_OBJ, BUILTIN_OBJ},
+}
+
+var reflectBuiltins = map[string]BuiltinFunction{
+	// Get the type of an object, e.g. "INTEGER".
+	"type": func(rt *Runtime, args ...Object) Object {
+		if len(args) != 1 {
+			return newError("wrong number of arguments. got=%d, want=1", len(args))
+		}
+
+		return &String{Value: string(args[0].Type())}
+	},
+	// Get the number of parameters of a function.
+	"fn_arity": func(rt *Runtime, args ...Object) Object {
+		params, _, err := functionArg("fn_arity", args)
+		if err != nil {
+			return err
+		}
+
+		return &Integer{Value: int64(len(params))}
+	},
+	// Get the names of the parameters of a function.
+	"fn_params": func(rt *Runtime, args ...Object) Object {
+		params, _, err := functionArg("fn_params", args)
+		if err != nil {
+			return err
+		}
+
+		return stringsToArray(rt, params)
+	},
+	// Get the source of a function, reconstructed from its AST.
+	"fn_source": func(rt *Runtime, args ...Object) Object {
+		_, source, err := functionArg("fn_source", args)
+		if err != nil {
+			return err
+		}
+
+		return &String{Value: source}
+	},
+}
+
+// FunctionSource reconstructs the source of a function from its AST, the way `fn_source` returns it.
+func FunctionSource(parameters []*ast.Identifier, body *ast.BlockStatement) string {
+	params := make([]string, len(parameters))
+	for i, param := range parameters {
+		params[i] = param.String()
+	}
+
+	return fmt.Sprintf("fn(%s) { %s }", strings.Join(params, ", "), body.String())
+}
+
+// typePredicate creates a builtin that checks if an object is one of a set of types.
+func typePredicate(types ...ObjectType) BuiltinFunction {
+	return func(rt *Runtime, args ...Object) Object {
+		if len(args) != 1 {
+			return newError("wrong number of arguments. got=%d, want=1", len(args))
+		}
+
+		for _, t := range types {
+			if args[0].Type() == t {
+				return TRUE
+			}
+		}
+
+		return FALSE
+	}
+}
+
+// functionArg validates the arguments of a builtin that inspects a function defined in Monkey, which is a closure in
+// the virtual machine. Returns the names of the parameters of the function and its source.
+func functionArg(name string, args []Object) ([]string, string, *Error) {
+	if len(args) != 1 {
+		return nil, "", newError("wrong number of arguments. got=%d, want=1", len(args))
+	}
+
+	switch fn := args[0].(type) {
+	case *Function:
+		params := make([]string, len(fn.Parameters))
+		for i, param := range fn.Parameters {
+			params[i] = param.Value
+		}
+
+		return params, FunctionSource(fn.Parameters, fn.Body), nil
+	case *Closure:
+		return fn.Fn.Parameters, fn.Fn.Source, nil
+	default:
+		return nil, "", newError(
+			"'%s' only accepts a function defined in Monkey as an argument. got=%s", name, args[0].Type(),
+		)
+	}
+}
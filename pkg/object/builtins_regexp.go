@@ -0,0 +1,165 @@
+package object
+
+import (
+	"errors"
+	"regexp"
+	"regexp/syntax"
+)
+
+func init() {
+	for name, fn := range regexpBuiltins {
+		Builtins[name] = &Builtin{Fn: fn}
+	}
+}
+
+// maxCachedRegexps is the number of compiled patterns a runtime keeps before the cache is cleared.
+const maxCachedRegexps = 256
+
+// Every regular expression builtin takes the pattern as its first argument and the string to search as its second.
+// A match is an array of the whole match followed by every capture group, where groups that did not participate in the
+// match are null.
+var regexpBuiltins = map[string]BuiltinFunction{
+	// Get the first match of a pattern in a string. Returns null if there is no match.
+	"re_match": func(rt *Runtime, args ...Object) Object {
+		re, str, err := regexpArgs(rt, "re_match", 2, args)
+		if err != nil {
+			return err
+		}
+
+		indexes := re.FindStringSubmatchIndex(str)
+		if indexes == nil {
+			return NULL
+		}
+
+		return newMatch(str, indexes)
+	},
+	// Get the named capture groups of the first match of a pattern in a string as a hash.
+	// Returns null if there is no match.
+	"re_groups": func(rt *Runtime, args ...Object) Object {
+		re, str, err := regexpArgs(rt, "re_groups", 2, args)
+		if err != nil {
+			return err
+		}
+
+		indexes := re.FindStringSubmatchIndex(str)
+		if indexes == nil {
+			return NULL
+		}
+
+		groups := &Hash{}
+		for i, name := range re.SubexpNames() {
+			if name != "" {
+				groups.Set(&String{Value: name}, submatch(str, indexes, i))
+			}
+		}
+
+		return groups
+	},
+	// Get every match of a pattern in a string.
+	"re_find_all": func(rt *Runtime, args ...Object) Object {
+		re, str, err := regexpArgs(rt, "re_find_all", 2, args)
+		if err != nil {
+			return err
+		}
+
+		matches := []Object{}
+		for _, indexes := range re.FindAllStringSubmatchIndex(str, -1) {
+			matches = append(matches, newMatch(str, indexes))
+		}
+
+		return &Array{Elements: matches}
+	},
+	// Replace every match of a pattern in a string.
+	// The replacement can refer to capture groups with $1 or ${name}, which is written "$${name}" in a Monkey string.
+	"re_replace": func(rt *Runtime, args ...Object) Object {
+		re, str, err := regexpArgs(rt, "re_replace", 3, args)
+		if err != nil {
+			return err
+		}
+
+		replacement, ok := args[2].(*String)
+		if !ok {
+			return newError("argument 3 to `re_replace` must be STRING. got=%s", args[2].Type())
+		}
+
+		return &String{Value: re.ReplaceAllString(str, replacement.Value)}
+	},
+	// Split a string around the matches of a pattern.
+	"re_split": func(rt *Runtime, args ...Object) Object {
+		re, str, err := regexpArgs(rt, "re_split", 2, args)
+		if err != nil {
+			return err
+		}
+
+		return stringsToArray(rt, re.Split(str, -1))
+	},
+}
+
+// regexpArgs validates the arguments of a regular expression builtin.
+// Returns the compiled pattern and the string to search.
+func regexpArgs(rt *Runtime, name string, want int, args []Object) (*regexp.Regexp, string, *Error) {
+	if len(args) != want {
+		return nil, "", newError("wrong number of arguments. got=%d, want=%d", len(args), want)
+	}
+
+	pattern, ok := args[0].(*String)
+	if !ok {
+		return nil, "", newError("argument 1 to `%s` must be STRING. got=%s", name, args[0].Type())
+	}
+
+	str, ok := args[1].(*String)
+	if !ok {
+		return nil, "", newError("argument 2 to `%s` must be STRING. got=%s", name, args[1].Type())
+	}
+
+	re, err := rt.compileRegexp(pattern.Value)
+	if err != nil {
+		var syntaxErr *syntax.Error
+		if errors.As(err, &syntaxErr) {
+			return nil, "", newError("invalid regular expression %q: %s", pattern.Value, syntaxErr.Code)
+		}
+		return nil, "", newError("invalid regular expression %q: %s", pattern.Value, err)
+	}
+
+	return re, str.Value, nil
+}
+
+// compileRegexp compiles a pattern, reusing the compiled pattern if the runtime has seen it before.
+func (rt *Runtime) compileRegexp(pattern string) (*regexp.Regexp, error) {
+	if re, ok := rt.regexps[pattern]; ok {
+		return re, nil
+	}
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	if rt.regexps == nil || len(rt.regexps) >= maxCachedRegexps {
+		rt.regexps = make(map[string]*regexp.Regexp)
+	}
+	rt.regexps[pattern] = re
+
+	return re, nil
+}
+
+// newMatch creates an array of the whole match and every capture group from the indexes of a submatch.
+func newMatch(str string, indexes []int) *Array {
+	elements := make([]Object, len(indexes)/2)
+	for i := range elements {
+		elements[i] = submatch(str, indexes, i)
+	}
+
+	return &Array{Elements: elements}
+}
+
+// submatch gets the ith group from the indexes of a submatch.
+// Returns null if the group did not participate in the match.
+func submatch(str string, indexes []int, i int) Object {
+	start, end := indexes[2*i], indexes[2*i+1]
+	if start < 0 {
+		return NULL
+	}
+
+	return &String{Value: str[start:end]}
+}
@@ -0,0 +1,53 @@
+package object
+
+import (
+	"strings"
+
+	"github.com/grantwforsythe/monkeylang/pkg/ast"
+	"github.com/grantwforsythe/monkeylang/pkg/lexer"
+	"github.com/grantwforsythe/monkeylang/pkg/parser"
+)
+
+func init() {
+	Builtins["parse"] = &Builtin{
+		// Parse source code into a quoted program without evaluating it.
+		Fn: func(rt *Runtime, args ...Object) Object {
+			str, err := stringArg("parse", args)
+			if err != nil {
+				return err
+			}
+
+			program, err := ParseSource(str.Value)
+			if err != nil {
+				return err
+			}
+
+			return &Quote{Node: program}
+		},
+	}
+	Builtins["eval"] = &Builtin{
+		// Report that source code cannot be evaluated. The evaluator defines its own `eval`, which evaluates source in
+		// the environment of its caller, so this is only called by the virtual machine, which has no such environment.
+		Fn: func(rt *Runtime, args ...Object) Object {
+			return newError("`eval` is not supported by the virtual machine")
+		},
+	}
+}
+
+// ParseSource parses source code into a program.
+// Returns an error object holding every parse error if the source is not a valid program.
+func ParseSource(source string) (*ast.Program, *Error) {
+	p := parser.New(lexer.New(source))
+	program := p.ParseProgram()
+
+	if len(p.Errors()) != 0 {
+		messages := make([]string, len(p.Errors()))
+		for i, err := range p.Errors() {
+			messages[i] = err.Error()
+		}
+
+		return nil, newError("could not parse source: %s", strings.Join(messages, "; "))
+	}
+
+	return program, nil
+}
@@ -0,0 +1,379 @@
+package object
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+func init() {
+	for name, fn := range stringBuiltins {
+		Builtins[name] = &Builtin{Fn: fn}
+	}
+}
+
+var stringBuiltins = map[string]BuiltinFunction{
+	// Split a string into an array of substrings around a separator.
+	// Without a separator the string is split around runs of whitespace.
+	"split": func(rt *Runtime, args ...Object) Object {
+		if len(args) != 1 && len(args) != 2 {
+			return newError("wrong number of arguments. got=%d, want=1 or 2", len(args))
+		}
+
+		str, ok := args[0].(*String)
+		if !ok {
+			return newError("argument 1 to `split` must be STRING. got=%s", args[0].Type())
+		}
+
+		var parts []string
+		if len(args) == 1 {
+			parts = strings.Fields(str.Value)
+		} else {
+			separator, ok := args[1].(*String)
+			if !ok {
+				return newError("argument 2 to `split` must be STRING. got=%s", args[1].Type())
+			}
+
+			parts = strings.Split(str.Value, separator.Value)
+		}
+
+		return stringsToArray(rt, parts)
+	},
+	// Remove leading and trailing whitespace, or the characters in an optional cutset, from a string.
+	"trim": func(rt *Runtime, args ...Object) Object {
+		if len(args) != 1 && len(args) != 2 {
+			return newError("wrong number of arguments. got=%d, want=1 or 2", len(args))
+		}
+
+		str, ok := args[0].(*String)
+		if !ok {
+			return newError("argument 1 to `trim` must be STRING. got=%s", args[0].Type())
+		}
+
+		if len(args) == 1 {
+			return &String{Value: strings.TrimSpace(str.Value)}
+		}
+
+		cutset, ok := args[1].(*String)
+		if !ok {
+			return newError("argument 2 to `trim` must be STRING. got=%s", args[1].Type())
+		}
+
+		return &String{Value: strings.Trim(str.Value, cutset.Value)}
+	},
+	// Convert a string to upper case.
+	"upper": func(rt *Runtime, args ...Object) Object {
+		str, err := stringArg("upper", args)
+		if err != nil {
+			return err
+		}
+
+		return &String{Value: strings.ToUpper(str.Value)}
+	},
+	// Convert a string to lower case.
+	"lower": func(rt *Runtime, args ...Object) Object {
+		str, err := stringArg("lower", args)
+		if err != nil {
+			return err
+		}
+
+		return &String{Value: strings.ToLower(str.Value)}
+	},
+	// Replace every instance of a substring in a string.
+	"replace": func(rt *Runtime, args ...Object) Object {
+		strs, err := stringArgs("replace", 3, args)
+		if err != nil {
+			return err
+		}
+
+		return &String{Value: strings.ReplaceAll(strs[0], strs[1], strs[2])}
+	},
+	// Check if a string begins with a prefix.
+	"starts_with": func(rt *Runtime, args ...Object) Object {
+		strs, err := stringArgs("starts_with", 2, args)
+		if err != nil {
+			return err
+		}
+
+		return nativeBoolToBooleanObject(strings.HasPrefix(strs[0], strs[1]))
+	},
+	// Check if a string ends with a suffix.
+	"ends_with": func(rt *Runtime, args ...Object) Object {
+		strs, err := stringArgs("ends_with", 2, args)
+		if err != nil {
+			return err
+		}
+
+		return nativeBoolToBooleanObject(strings.HasSuffix(strs[0], strs[1]))
+	},
+	// Repeat a string a number of times.
+	"repeat": func(rt *Runtime, args ...Object) Object {
+		if len(args) != 2 {
+			return newError("wrong number of arguments. got=%d, want=2", len(args))
+		}
+
+		str, ok := args[0].(*String)
+		if !ok {
+			return newError("argument 1 to `repeat` must be STRING. got=%s", args[0].Type())
+		}
+
+		count, ok := args[1].(*Integer)
+		if !ok {
+			return newError("argument 2 to `repeat` must be INTEGER. got=%s", args[1].Type())
+		}
+
+		if count.Value < 0 {
+			return newError("'repeat' count cannot be negative. got=%d", count.Value)
+		}
+
+		size := int64(-1)
+		if len(str.Value) == 0 || count.Value <= math.MaxInt64/int64(len(str.Value)) {
+			size = sizeOfString(int64(len(str.Value)) * count.Value)
+		}
+
+		if err := rt.reserve(size); err != nil {
+			return err
+		}
+
+		return &String{Value: strings.Repeat(str.Value, int(count.Value))}
+	},
+	// Return the part of a string from start (inclusive) to an optional end (exclusive).
+	// The indexes count characters, i.e. runes, and negative indexes count back from the end of the string.
+	"substr": func(rt *Runtime, args ...Object) Object {
+		if len(args) != 2 && len(args) != 3 {
+			return newError("wrong number of arguments. got=%d, want=2 or 3", len(args))
+		}
+
+		str, ok := args[0].(*String)
+		if !ok {
+			return newError("argument 1 to `substr` must be STRING. got=%s", args[0].Type())
+		}
+
+		runes := []rune(str.Value)
+		start, end, err := sliceBounds("substr", len(runes), args[1:])
+		if err != nil {
+			return err
+		}
+
+		return &String{Value: string(runes[start:end])}
+	},
+	// Split a string into an array of its characters, i.e. runes.
+	"chars": func(rt *Runtime, args ...Object) Object {
+		str, err := stringArg("chars", args)
+		if err != nil {
+			return err
+		}
+
+		return stringsToArray(rt, strings.Split(str.Value, ""))
+	},
+	// Convert a string or a float to an integer.
+	"to_int": func(rt *Runtime, args ...Object) Object {
+		if len(args) != 1 {
+			return newError("wrong number of arguments. got=%d, want=1", len(args))
+		}
+
+		switch arg := args[0].(type) {
+		case *Integer:
+			return arg
+		case *Float:
+			// Floats are truncated towards zero
+			if math.IsNaN(arg.Value) || math.IsInf(arg.Value, 0) {
+				return newError("could not convert %s to an integer", arg.Inspect())
+			}
+
+			return &Integer{Value: int64(arg.Value)}
+		case *String:
+			value, err := strconv.ParseInt(strings.TrimSpace(arg.Value), 10, 64)
+			if err != nil {
+				return newError("could not convert %q to an integer", arg.Value)
+			}
+
+			return &Integer{Value: value}
+		default:
+			return newError("argument to `to_int` not supported. got=%s", args[0].Type())
+		}
+	},
+	// Convert an integer or a string to a float.
+	"to_float": func(rt *Runtime, args ...Object) Object {
+		if len(args) != 1 {
+			return newError("wrong number of arguments. got=%d, want=1", len(args))
+		}
+
+		switch arg := args[0].(type) {
+		case *Float:
+			return arg
+		case *Integer:
+			return &Float{Value: float64(arg.Value)}
+		case *String:
+			value, err := strconv.ParseFloat(strings.TrimSpace(arg.Value), 64)
+			if err != nil {
+				return newError("could not convert %q to a float", arg.Value)
+			}
+
+			return &Float{Value: value}
+		default:
+			return newError("argument to `to_float` not supported. got=%s", args[0].Type())
+		}
+	},
+	// Convert any object to its string representation.
+	"to_string": func(rt *Runtime, args ...Object) Object {
+		if len(args) != 1 {
+			return newError("wrong number of arguments. got=%d, want=1", len(args))
+		}
+
+		if str, ok := args[0].(*String); ok {
+			return str
+		}
+
+		return &String{Value: args[0].Inspect()}
+	},
+	// Format a string with printf-style verbs: %d for integers, %f for numbers and %s or %v for any object.
+	// Verbs can have the flags "-", "+", " " and "0", a width and a precision, e.g. "%-8s", "%05d" or "%.2f".
+	"format": func(rt *Runtime, args ...Object) Object {
+		if len(args) == 0 {
+			return newError("wrong number of arguments. got=0, want=1 or more")
+		}
+
+		format, ok := args[0].(*String)
+		if !ok {
+			return newError("argument 1 to `format` must be STRING. got=%s", args[0].Type())
+		}
+
+		str, err := formatString(format.Value, args[1:])
+		if err != nil {
+			return err
+		}
+
+		return &String{Value: str}
+	},
+}
+
+// formatString replaces the verbs in a format with the formatted values.
+func formatString(format string, values []Object) (string, *Error) {
+	var out strings.Builder
+
+	// used is the number of verbs, which must match the number of values
+	used := 0
+	for i := 0; i < len(format); i++ {
+		if format[i] != '%' {
+			out.WriteByte(format[i])
+			continue
+		}
+
+		start := i
+		i++
+		for i < len(format) && strings.IndexByte("-+ 0", format[i]) >= 0 {
+			i++
+		}
+		for i < len(format) && '0' <= format[i] && format[i] <= '9' {
+			i++
+		}
+		if i < len(format) && format[i] == '.' {
+			i++
+			for i < len(format) && '0' <= format[i] && format[i] <= '9' {
+				i++
+			}
+		}
+
+		if i >= len(format) {
+			return "", newError("format %q ends with an incomplete verb", format)
+		}
+
+		verb := format[i]
+		if verb == '%' && i == start+1 {
+			out.WriteByte('%')
+			continue
+		}
+
+		if strings.IndexByte("dfsv", verb) < 0 {
+			return "", newError("unknown verb %%%c in format %q", verb, format)
+		}
+
+		used++
+		if used > len(values) {
+			continue
+		}
+
+		value := values[used-1]
+		spec := format[start:i]
+		switch verb {
+		case 'd':
+			integer, ok := value.(*Integer)
+			if !ok {
+				return "", newError("argument %d to `format` must be INTEGER for %%d. got=%s", used+1, value.Type())
+			}
+			out.WriteString(fmt.Sprintf(spec+"d", integer.Value))
+		case 'f':
+			number, ok := AsFloat(value)
+			if !ok {
+				return "", newError("argument %d to `format` must be INTEGER or FLOAT for %%f. got=%s", used+1, value.Type())
+			}
+			out.WriteString(fmt.Sprintf(spec+"f", number))
+		default:
+			out.WriteString(fmt.Sprintf(spec+"s", value.Inspect()))
+		}
+	}
+
+	if used != len(values) {
+		return "", newError("wrong number of arguments for format %q. got=%d, want=%d", format, len(values), used)
+	}
+
+	return out.String(), nil
+}
+
+// stringArg validates the arguments of a builtin that accepts a single string.
+func stringArg(name string, args []Object) (*String, *Error) {
+	if len(args) != 1 {
+		return nil, newError("wrong number of arguments. got=%d, want=1", len(args))
+	}
+
+	str, ok := args[0].(*String)
+	if !ok {
+		return nil, newError("'%s' only accepts a string as an argument. got=%s", name, args[0].Type())
+	}
+
+	return str, nil
+}
+
+// stringArgs validates the arguments of a builtin that accepts a fixed number of strings.
+// Returns the values of the strings.
+func stringArgs(name string, want int, args []Object) ([]string, *Error) {
+	if len(args) != want {
+		return nil, newError("wrong number of arguments. got=%d, want=%d", len(args), want)
+	}
+
+	strs := make([]string, len(args))
+	for i, arg := range args {
+		str, ok := arg.(*String)
+		if !ok {
+			return nil, newError("argument %d to `%s` must be STRING. got=%s", i+1, name, arg.Type())
+		}
+		strs[i] = str.Value
+	}
+
+	return strs, nil
+}
+
+// stringsToArray converts a slice of strings into an array of string objects.
+// The strings are allocated by the program, which must have room for them and the array before they are created.
+func stringsToArray(rt *Runtime, strs []string) Object {
+	size := sizeOfArray(int64(len(strs)))
+	for _, str := range strs {
+		size = addSizes(size, sizeOfString(int64(len(str))))
+	}
+
+	if err := rt.reserve(size); err != nil {
+		return err
+	}
+
+	elements := make([]Object, len(strs))
+	for i, str := range strs {
+		elements[i] = &String{Value: str}
+		if err := rt.Allocate(elements[i]); err != nil {
+			return err
+		}
+	}
+
+	return &Array{Elements: elements}
+}
@@ -0,0 +1,148 @@
+package object
+
+import "time"
+
+func init() {
+	for name, fn := range timeBuiltins {
+		Builtins[name] = &Builtin{Fn: fn}
+	}
+}
+
+// namedLayouts are the layouts that can be referred to by name instead of by a Go reference layout.
+var namedLayouts = map[string]string{
+	"RFC3339":  time.RFC3339,
+	"RFC1123":  time.RFC1123,
+	"DateTime": time.DateTime,
+	"DateOnly": time.DateOnly,
+	"TimeOnly": time.TimeOnly,
+	"Kitchen":  time.Kitchen,
+}
+
+// Timestamps are integers holding the number of milliseconds since the Unix epoch and durations are integers holding a
+// number of milliseconds, so both can be added and subtracted with the arithmetic operators.
+var timeBuiltins = map[string]BuiltinFunction{
+	// Get the current time of the clock of the runtime as a timestamp.
+	"now": func(rt *Runtime, args ...Object) Object {
+		if len(args) != 0 {
+			return newError("wrong number of arguments. got=%d, want=0", len(args))
+		}
+
+		return &Integer{Value: rt.Clock.Now().UnixMilli()}
+	},
+	// Pause the program for a number of milliseconds.
+	"sleep": func(rt *Runtime, args ...Object) Object {
+		if len(args) != 1 {
+			return newError("wrong number of arguments. got=%d, want=1", len(args))
+		}
+
+		ms, ok := args[0].(*Integer)
+		if !ok {
+			return newError("argument to `sleep` must be INTEGER. got=%s", args[0].Type())
+		}
+
+		if ms.Value < 0 {
+			return newError("'sleep' duration cannot be negative. got=%d", ms.Value)
+		}
+
+		rt.Clock.Sleep(time.Duration(ms.Value) * time.Millisecond)
+
+		return NULL
+	},
+	// Format a timestamp with a Go reference layout or a named layout, e.g. "RFC3339", in UTC or an optional time zone.
+	"format_time": func(rt *Runtime, args ...Object) Object {
+		if len(args) != 2 && len(args) != 3 {
+			return newError("wrong number of arguments. got=%d, want=2 or 3", len(args))
+		}
+
+		timestamp, ok := args[0].(*Integer)
+		if !ok {
+			return newError("argument 1 to `format_time` must be INTEGER. got=%s", args[0].Type())
+		}
+
+		layout, err := layoutArg("format_time", args[1])
+		if err != nil {
+			return err
+		}
+
+		location := time.UTC
+		if len(args) == 3 {
+			zone, ok := args[2].(*String)
+			if !ok {
+				return newError("argument 3 to `format_time` must be STRING. got=%s", args[2].Type())
+			}
+
+			var loadErr error
+			location, loadErr = time.LoadLocation(zone.Value)
+			if loadErr != nil {
+				return newError("unknown time zone %q", zone.Value)
+			}
+		}
+
+		return &String{Value: time.UnixMilli(timestamp.Value).In(location).Format(layout)}
+	},
+	// Parse a string into a timestamp with a Go reference layout or a named layout.
+	// Times without a time zone are in UTC.
+	"parse_time": func(rt *Runtime, args ...Object) Object {
+		if len(args) != 2 {
+			return newError("wrong number of arguments. got=%d, want=2", len(args))
+		}
+
+		str, ok := args[0].(*String)
+		if !ok {
+			return newError("argument 1 to `parse_time` must be STRING. got=%s", args[0].Type())
+		}
+
+		layout, err := layoutArg("parse_time", args[1])
+		if err != nil {
+			return err
+		}
+
+		parsed, parseErr := time.Parse(layout, str.Value)
+		if parseErr != nil {
+			return newError("could not parse %q as a time with layout %q", str.Value, layout)
+		}
+
+		return &Integer{Value: parsed.UnixMilli()}
+	},
+	// Parse a duration such as "1h30m" or "250ms" into a number of milliseconds.
+	"duration": func(rt *Runtime, args ...Object) Object {
+		str, err := stringArg("duration", args)
+		if err != nil {
+			return err
+		}
+
+		duration, parseErr := time.ParseDuration(str.Value)
+		if parseErr != nil {
+			return newError("could not parse %q as a duration", str.Value)
+		}
+
+		return &Integer{Value: duration.Milliseconds()}
+	},
+	// Format a number of milliseconds as a duration such as "1h30m0s".
+	"format_duration": func(rt *Runtime, args ...Object) Object {
+		if len(args) != 1 {
+			return newError("wrong number of arguments. got=%d, want=1", len(args))
+		}
+
+		ms, ok := args[0].(*Integer)
+		if !ok {
+			return newError("argument to `format_duration` must be INTEGER. got=%s", args[0].Type())
+		}
+
+		return &String{Value: (time.Duration(ms.Value) * time.Millisecond).String()}
+	},
+}
+
+// layoutArg gets the layout of a time builtin, expanding named layouts.
+func layoutArg(name string, arg Object) (string, *Error) {
+	layout, ok := arg.(*String)
+	if !ok {
+		return "", newError("argument 2 to `%s` must be STRING. got=%s", name, arg.Type())
+	}
+
+	if named, ok := namedLayouts[layout.Value]; ok {
+		return named, nil
+	}
+
+	return layout.Value, nil
+}
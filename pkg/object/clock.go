@@ -0,0 +1,52 @@
+package object
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock is the source of time for the time builtins.
+// Hosts can replace the clock of a runtime, e.g. with a FakeClock, to make programs that use time deterministic.
+type Clock interface {
+	// Now gets the current time.
+	Now() time.Time
+	// Sleep pauses the program for a duration.
+	Sleep(d time.Duration)
+}
+
+// SystemClock is a clock that uses the time of the host.
+type SystemClock struct{}
+
+func (SystemClock) Now() time.Time        { return time.Now() }
+func (SystemClock) Sleep(d time.Duration) { time.Sleep(d) }
+
+// FakeClock is a clock that only moves when it is told to.
+// Sleeping advances the clock immediately instead of waiting.
+type FakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+// NewFakeClock creates a fake clock set to a time.
+func NewFakeClock(now time.Time) *FakeClock {
+	return &FakeClock{now: now}
+}
+
+func (c *FakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.now
+}
+
+func (c *FakeClock) Sleep(d time.Duration) {
+	c.Advance(d)
+}
+
+// Advance moves the clock forward by a duration.
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.now = c.now.Add(d)
+}
@@ -0,0 +1,446 @@
+package object
+
+import (
+	"cmp"
+	"fmt"
+	"math"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// maxConversionDepth is the maximum nesting of the values converted between Go and Monkey, which stops the conversion
+// of a value that refers to itself.
+const maxConversionDepth = 1000
+
+// objectType is the type of the Object interface.
+var objectType = reflect.TypeOf((*Object)(nil)).Elem()
+
+// FromGo converts a Go value into a Monkey object:
+//   - nil and nil pointers become null.
+//   - Booleans, integers, floats and strings become their Monkey counterparts.
+//   - Slices and arrays become arrays, including nil slices.
+//   - Maps become hashes ordered by key, including nil maps. Their keys must be booleans, integers, floats or strings.
+//   - Structs become hashes of their exported fields in the order they are declared.
+//   - Objects are returned as they are.
+//
+// The name of a struct field in the hash can be set with a `monkey:"name"` tag. A field tagged `monkey:"-"` is left out,
+// as is a field tagged with the omitempty option, e.g. `monkey:"name,omitempty"`, if it has its zero value.
+// Returns an error for a value that cannot be converted, such as a function or a channel.
+func FromGo(value any) (Object, error) {
+	return fromGo(reflect.ValueOf(value), 0)
+}
+
+func fromGo(v reflect.Value, depth int) (Object, error) {
+	if !v.IsValid() {
+		return NULL, nil
+	}
+
+	if depth > maxConversionDepth {
+		return nil, fmt.Errorf("Go %s is nested too deeply", v.Type())
+	}
+
+	if v.Type().Implements(objectType) {
+		if (v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface) && v.IsNil() {
+			return NULL, nil
+		}
+
+		return v.Interface().(Object), nil
+	}
+
+	switch v.Kind() {
+	case reflect.Bool:
+		return nativeBoolToBooleanObject(v.Bool()), nil
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return &Integer{Value: v.Int()}, nil
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if v.Uint() > math.MaxInt64 {
+			return nil, fmt.Errorf("Go %s %d is out of range", v.Type(), v.Uint())
+		}
+
+		return &Integer{Value: int64(v.Uint())}, nil
+
+	case reflect.Float32, reflect.Float64:
+		return &Float{Value: v.Float()}, nil
+
+	case reflect.String:
+		return &String{Value: v.String()}, nil
+
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			return NULL, nil
+		}
+
+		return fromGo(v.Elem(), depth+1)
+
+	case reflect.Slice, reflect.Array:
+		elements := make([]Object, v.Len())
+		for i := range elements {
+			element, err := fromGo(v.Index(i), depth+1)
+			if err != nil {
+				return nil, err
+			}
+
+			elements[i] = element
+		}
+
+		return &Array{Elements: elements}, nil
+
+	case reflect.Map:
+		// Maps are unordered, so the keys are sorted for the hash to have the same order every time
+		keys := v.MapKeys()
+		slices.SortFunc(keys, compareMapKeys)
+
+		hash := &Hash{}
+		for _, key := range keys {
+			k, err := fromGo(key, depth+1)
+			if err != nil {
+				return nil, err
+			}
+
+			hashKey, ok := k.(Hashable)
+			if !ok {
+				return nil, fmt.Errorf("Go map key %s is not hashable", key.Type())
+			}
+
+			value, err := fromGo(v.MapIndex(key), depth+1)
+			if err != nil {
+				return nil, err
+			}
+
+			hash.Set(hashKey, value)
+		}
+
+		return hash, nil
+
+	case reflect.Struct:
+		hash := &Hash{}
+		for _, field := range structFields(v.Type()) {
+			value := v.Field(field.index)
+			if field.omitEmpty && value.IsZero() {
+				continue
+			}
+
+			obj, err := fromGo(value, depth+1)
+			if err != nil {
+				return nil, err
+			}
+
+			hash.Set(&String{Value: field.name}, obj)
+		}
+
+		return hash, nil
+
+	default:
+		return nil, fmt.Errorf("cannot convert Go %s to a Monkey object", v.Type())
+	}
+}
+
+// compareMapKeys orders the keys of a Go map of a type FromGo supports.
+func compareMapKeys(a, b reflect.Value) int {
+	if a.Kind() == reflect.Interface {
+		a, b = a.Elem(), b.Elem()
+		if a.Kind() != b.Kind() {
+			return cmp.Compare(a.Kind(), b.Kind())
+		}
+	}
+
+	switch a.Kind() {
+	case reflect.Bool:
+		return cmp.Compare(boolRank(a.Bool()), boolRank(b.Bool()))
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return cmp.Compare(a.Int(), b.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return cmp.Compare(a.Uint(), b.Uint())
+	case reflect.Float32, reflect.Float64:
+		return cmp.Compare(a.Float(), b.Float())
+	case reflect.String:
+		return cmp.Compare(a.String(), b.String())
+	default:
+		// Keys that cannot be hashed fail the conversion, so their order does not matter
+		return 0
+	}
+}
+
+func boolRank(b bool) int {
+	if b {
+		return 1
+	}
+
+	return 0
+}
+
+// structField is an exported field of a struct converted to and from a hash.
+type structField struct {
+	index     int
+	name      string // name is the key of the field in the hash.
+	omitEmpty bool
+}
+
+// structFields gets the exported fields of a struct type that are not left out by their tag.
+func structFields(t reflect.Type) []structField {
+	fields := []structField{}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		tag := field.Tag.Get("monkey")
+		if tag == "-" {
+			continue
+		}
+
+		name, options, _ := strings.Cut(tag, ",")
+		if name == "" {
+			name = field.Name
+		}
+
+		fields = append(fields, structField{index: i, name: name, omitEmpty: options == "omitempty"})
+	}
+
+	return fields
+}
+
+// ToGo converts a Monkey object into a Go value:
+//   - Null becomes nil.
+//   - Integers become int64, floats float64, strings string and booleans bool.
+//   - Arrays and sets become []any.
+//   - Hashes become map[string]any. Keys that are not strings become the string of their value, as they do in JSON.
+//
+// Returns an error for an object that cannot be converted, such as a function.
+func ToGo(obj Object) (any, error) {
+	var value any
+	if err := toGo(obj, reflect.ValueOf(&value).Elem(), 0); err != nil {
+		return nil, err
+	}
+
+	return value, nil
+}
+
+// ToGoInto converts a Monkey object into the Go value target points to, the reverse of FromGo. For example, a hash can
+// be converted into a struct with the same `monkey` tags, and an array into a slice of any type its elements convert to.
+// Targets of type any get the same values as ToGo, and targets of an Object type get the object itself.
+// Returns an error if target is not a non-nil pointer or the object cannot be converted into its type.
+func ToGoInto(obj Object, target any) error {
+	v := reflect.ValueOf(target)
+	if v.Kind() != reflect.Pointer || v.IsNil() {
+		return fmt.Errorf("target must be a non-nil pointer. got=%T", target)
+	}
+
+	return toGo(obj, v.Elem(), 0)
+}
+
+func toGo(obj Object, v reflect.Value, depth int) error {
+	if obj == nil {
+		obj = NULL
+	}
+
+	if depth > maxConversionDepth {
+		return fmt.Errorf("%s is nested too deeply", obj.Type())
+	}
+
+	if v.Kind() == reflect.Interface && v.NumMethod() == 0 {
+		value, err := toGoValue(obj, depth)
+		if err != nil {
+			return err
+		}
+
+		if value == nil {
+			v.Set(reflect.Zero(v.Type()))
+		} else {
+			v.Set(reflect.ValueOf(value))
+		}
+
+		return nil
+	}
+
+	if reflect.TypeOf(obj).AssignableTo(v.Type()) {
+		v.Set(reflect.ValueOf(obj))
+		return nil
+	}
+
+	if obj.Type() == NULL_OBJ {
+		v.Set(reflect.Zero(v.Type()))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.Pointer:
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+
+		return toGo(obj, v.Elem(), depth+1)
+
+	case reflect.Bool:
+		if obj, ok := obj.(*Boolean); ok {
+			v.SetBool(obj.Value)
+			return nil
+		}
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if obj, ok := obj.(*Integer); ok {
+			if v.OverflowInt(obj.Value) {
+				return fmt.Errorf("%d is out of range for Go %s", obj.Value, v.Type())
+			}
+
+			v.SetInt(obj.Value)
+			return nil
+		}
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if obj, ok := obj.(*Integer); ok {
+			if obj.Value < 0 || v.OverflowUint(uint64(obj.Value)) {
+				return fmt.Errorf("%d is out of range for Go %s", obj.Value, v.Type())
+			}
+
+			v.SetUint(uint64(obj.Value))
+			return nil
+		}
+
+	case reflect.Float32, reflect.Float64:
+		switch obj := obj.(type) {
+		case *Float:
+			v.SetFloat(obj.Value)
+			return nil
+		case *Integer:
+			v.SetFloat(float64(obj.Value))
+			return nil
+		}
+
+	case reflect.String:
+		if obj, ok := obj.(*String); ok {
+			v.SetString(obj.Value)
+			return nil
+		}
+
+	case reflect.Slice:
+		if elements, ok := goElements(obj); ok {
+			slice := reflect.MakeSlice(v.Type(), len(elements), len(elements))
+			for i, element := range elements {
+				if err := toGo(element, slice.Index(i), depth+1); err != nil {
+					return err
+				}
+			}
+
+			v.Set(slice)
+			return nil
+		}
+
+	case reflect.Array:
+		if elements, ok := goElements(obj); ok {
+			if len(elements) != v.Len() {
+				return fmt.Errorf("cannot convert %s of length %d to Go %s", obj.Type(), len(elements), v.Type())
+			}
+
+			for i, element := range elements {
+				if err := toGo(element, v.Index(i), depth+1); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		}
+
+	case reflect.Map:
+		if hash, ok := obj.(*Hash); ok {
+			m := reflect.MakeMapWithSize(v.Type(), hash.Len())
+			for _, pair := range hash.Pairs() {
+				key := reflect.New(v.Type().Key()).Elem()
+				if err := toGo(pair.Key, key, depth+1); err != nil {
+					return err
+				}
+
+				value := reflect.New(v.Type().Elem()).Elem()
+				if err := toGo(pair.Value, value, depth+1); err != nil {
+					return err
+				}
+
+				m.SetMapIndex(key, value)
+			}
+
+			v.Set(m)
+			return nil
+		}
+
+	case reflect.Struct:
+		if hash, ok := obj.(*Hash); ok {
+			// Fields without a pair in the hash keep their value
+			for _, field := range structFields(v.Type()) {
+				pair, ok := hash.Get(&String{Value: field.name})
+				if !ok {
+					continue
+				}
+
+				if err := toGo(pair.Value, v.Field(field.index), depth+1); err != nil {
+					return fmt.Errorf("field %s: %w", field.name, err)
+				}
+			}
+
+			return nil
+		}
+	}
+
+	return fmt.Errorf("cannot convert %s to Go %s", obj.Type(), v.Type())
+}
+
+// toGoValue converts a Monkey object into the Go value ToGo returns for it.
+func toGoValue(obj Object, depth int) (any, error) {
+	switch obj := obj.(type) {
+	case *Null:
+		return nil, nil
+	case *Boolean:
+		return obj.Value, nil
+	case *Integer:
+		return obj.Value, nil
+	case *Float:
+		return obj.Value, nil
+	case *String:
+		return obj.Value, nil
+	case *Array, *Set:
+		elements, _ := goElements(obj)
+
+		values := make([]any, len(elements))
+		for i, element := range elements {
+			if err := toGo(element, reflect.ValueOf(&values[i]).Elem(), depth+1); err != nil {
+				return nil, err
+			}
+		}
+
+		return values, nil
+	case *Hash:
+		values := make(map[string]any, obj.Len())
+		for _, pair := range obj.Pairs() {
+			key := pair.Key.Inspect()
+			if str, ok := pair.Key.(*String); ok {
+				key = str.Value
+			}
+
+			var value any
+			if err := toGo(pair.Value, reflect.ValueOf(&value).Elem(), depth+1); err != nil {
+				return nil, err
+			}
+
+			values[key] = value
+		}
+
+		return values, nil
+	default:
+		return nil, fmt.Errorf("cannot convert %s to a Go value", obj.Type())
+	}
+}
+
+// goElements gets the elements of an array or set converted into a Go slice or array.
+func goElements(obj Object) ([]Object, bool) {
+	switch obj := obj.(type) {
+	case *Array:
+		return obj.Elements, true
+	case *Set:
+		return obj.Elements(), true
+	default:
+		return nil, false
+	}
+}
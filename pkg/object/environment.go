@@ -2,20 +2,32 @@ package object
 
 // Environment represents the scope of a program.
 type Environment struct {
-	store map[string]Object
-	outer *Environment
+	store   map[string]Object
+	outer   *Environment
+	runtime *Runtime
 }
 
-// NewEnvironment creates a new global environment.
+// NewEnvironment creates a new global environment with its own runtime.
 func NewEnvironment() *Environment {
+	return NewEnvironmentWithRuntime(NewRuntime())
+}
+
+// NewEnvironmentWithRuntime creates a new global environment that shares a runtime.
+func NewEnvironmentWithRuntime(runtime *Runtime) *Environment {
 	store := make(map[string]Object)
-	return &Environment{store: store, outer: nil}
+	return &Environment{store: store, outer: nil, runtime: runtime}
 }
 
 // NewEnclosedEnvironment creates a new enclosed environment.
+// The enclosed environment shares the runtime of the outer environment.
 func NewEnclosedEnvironment(outer *Environment) *Environment {
 	store := make(map[string]Object)
-	return &Environment{store: store, outer: outer}
+	return &Environment{store: store, outer: outer, runtime: outer.runtime}
+}
+
+// Runtime gets the runtime of the interpreter the environment belongs to.
+func (e *Environment) Runtime() *Runtime {
+	return e.runtime
 }
 
 // Get gets the value associated with the identifier.
@@ -28,6 +40,19 @@ func (e *Environment) Get(identifier string) (Object, bool) {
 	return obj, ok
 }
 
+// Assign changes the value of an identifier in the environment it is defined in, which may enclose this one.
+// Returns false if the identifier is not defined.
+func (e *Environment) Assign(identifier string, value Object) bool {
+	for env := e; env != nil; env = env.outer {
+		if _, ok := env.store[identifier]; ok {
+			env.store[identifier] = value
+			return true
+		}
+	}
+
+	return false
+}
+
 // Set stores a value in the environment for the given identifier.
 func (e *Environment) Set(identifier string, value Object) Object {
 	e.store[identifier] = value
@@ -0,0 +1,165 @@
+package object
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// FileSystem confines the file builtins to a directory on the host. Symbolic links are only followed to paths inside
+// of the directory. The zero value does not permit any file access.
+type FileSystem struct {
+	Root  string // Root is the directory that paths are relative to. Paths outside of it are rejected.
+	Read  bool   // Read permits reading files and directories.
+	Write bool   // Write permits creating and overwriting files.
+}
+
+// maxLinks is the maximum number of symbolic links followed when resolving a path.
+const maxLinks = 40
+
+// errOutsideRoot is returned when resolving a path that leads outside of the root directory.
+var errOutsideRoot = errors.New("outside the root directory")
+
+// resolve converts a path used by a Monkey program into a path on the host.
+// Returns the path with every symbolic link in it followed, or an error if the path or any link it goes through leads
+// outside of the root directory, or if it cannot be checked.
+func (f FileSystem) resolve(path string) (string, error) {
+	if f.Root == "" {
+		return "", errors.New("file access is not permitted")
+	}
+
+	root, err := filepath.EvalSymlinks(f.Root)
+	if err == nil {
+		root, err = filepath.Abs(root)
+	}
+	if err != nil {
+		return "", fmt.Errorf("root directory is not accessible: %w", unwrapPathError(err))
+	}
+
+	full := filepath.Join(root, path)
+	if filepath.IsAbs(path) {
+		full = filepath.Clean(path)
+	}
+
+	if !within(root, full) {
+		return "", fmt.Errorf("path %q is outside the root directory", path)
+	}
+
+	rel, _ := filepath.Rel(root, full)
+	resolved, err := resolveLinks(root, rel)
+	if errors.Is(err, errOutsideRoot) {
+		return "", fmt.Errorf("path %q is outside the root directory", path)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	return resolved, nil
+}
+
+// resolveLinks follows the symbolic links of a path relative to the root one part at a time, so that every link is
+// checked before it is followed, including a link to a file that does not exist yet, e.g. one that is about to be
+// written. The parts that do not exist are kept as they are.
+func resolveLinks(root, rel string) (string, error) {
+	resolved := root
+	parts := splitPath(rel)
+	links := 0
+
+	for len(parts) > 0 {
+		part := parts[0]
+		parts = parts[1:]
+
+		if part == ".." {
+			resolved = filepath.Dir(resolved)
+			if !within(root, resolved) {
+				return "", errOutsideRoot
+			}
+
+			continue
+		}
+
+		next := filepath.Join(resolved, part)
+		info, err := os.Lstat(next)
+		if errors.Is(err, fs.ErrNotExist) || (err == nil && info.Mode()&fs.ModeSymlink == 0) {
+			resolved = next
+			continue
+		}
+		if err != nil {
+			return "", unwrapPathError(err)
+		}
+
+		links++
+		if links > maxLinks {
+			return "", errors.New("too many levels of symbolic links")
+		}
+
+		target, err := os.Readlink(next)
+		if err != nil {
+			return "", unwrapPathError(err)
+		}
+
+		// The target of a link replaces the link, and is relative to the directory of the link unless it is absolute
+		if filepath.IsAbs(target) {
+			target = filepath.Clean(target)
+			if !within(root, target) {
+				return "", errOutsideRoot
+			}
+
+			resolved = root
+			target, _ = filepath.Rel(root, target)
+		}
+
+		parts = append(splitPath(target), parts...)
+	}
+
+	return resolved, nil
+}
+
+// splitPath splits a relative path into its parts, leaving out the empty and "." parts.
+func splitPath(path string) []string {
+	parts := []string{}
+	for _, part := range strings.Split(path, string(filepath.Separator)) {
+		if part != "" && part != "." {
+			parts = append(parts, part)
+		}
+	}
+
+	return parts
+}
+
+// readable resolves a path that is going to be read.
+func (f FileSystem) readable(path string) (string, error) {
+	if f.Root != "" && !f.Read {
+		return "", errors.New("reading files is not permitted")
+	}
+
+	return f.resolve(path)
+}
+
+// writable resolves a path that is going to be written.
+func (f FileSystem) writable(path string) (string, error) {
+	if f.Root != "" && !f.Write {
+		return "", errors.New("writing files is not permitted")
+	}
+
+	return f.resolve(path)
+}
+
+// within checks if a path is the root directory or inside of it.
+func within(root, path string) bool {
+	rel, err := filepath.Rel(root, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// unwrapPathError removes the host path from an error so it is not exposed to Monkey programs.
+func unwrapPathError(err error) error {
+	var pathErr *os.PathError
+	if errors.As(err, &pathErr) {
+		return pathErr.Err
+	}
+
+	return err
+}
@@ -0,0 +1,161 @@
+package object
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+var (
+	errorType   = reflect.TypeOf((*error)(nil)).Elem()
+	runtimeType = reflect.TypeOf((*Runtime)(nil))
+)
+
+// WrapFunc creates a builtin that calls a Go function, converting its arguments with ToGoInto and its result with
+// FromGo. The name identifies the builtin in errors.
+//
+// The function can take a *Runtime as its first parameter, which is passed the runtime of the caller instead of an
+// argument, and can be variadic. It can return nothing, a value, an error, or a value and an error. A non-nil error
+// becomes a Monkey error whose Err is the error.
+//
+// Calling the builtin with the wrong number of arguments, or with an argument that does not convert to the type of its
+// parameter, returns the same errors as the other builtins.
+// Returns an error if fn is not a function or returns something else.
+func WrapFunc(name string, fn any) (*Builtin, error) {
+	v := reflect.ValueOf(fn)
+	if v.Kind() != reflect.Func || v.IsNil() {
+		return nil, fmt.Errorf("cannot wrap %T as builtin `%s`: not a function", fn, name)
+	}
+
+	t := v.Type()
+	switch {
+	case t.NumOut() > 2,
+		t.NumOut() == 2 && t.Out(1) != errorType,
+		t.NumOut() == 2 && t.Out(0) == errorType:
+		return nil, fmt.Errorf("cannot wrap %s as builtin `%s`: it must return at most a value and an error", t, name)
+	}
+
+	// The parameters the arguments are converted into, which do not include the runtime
+	params := make([]reflect.Type, t.NumIn())
+	for i := range params {
+		params[i] = t.In(i)
+	}
+
+	withRuntime := len(params) > 0 && params[0] == runtimeType
+	if withRuntime {
+		params = params[1:]
+	}
+
+	return &Builtin{Fn: func(rt *Runtime, args ...Object) Object {
+		if err := checkArity(len(args), len(params), t.IsVariadic()); err != nil {
+			return err
+		}
+
+		in := make([]reflect.Value, 0, len(args)+1)
+		if withRuntime {
+			in = append(in, reflect.ValueOf(rt))
+		}
+
+		for i, arg := range args {
+			param := params[min(i, len(params)-1)]
+			if t.IsVariadic() && i >= len(params)-1 {
+				param = param.Elem()
+			}
+
+			// Null is only passed to parameters that can be nil, instead of becoming the zero value of the type
+			if want := objectTypesOf(param); want != nil && !slices.Contains(want, arg.Type()) {
+				return newError("argument %d to `%s` must be %s. got=%s", i+1, name, joinObjectTypes(want), arg.Type())
+			}
+
+			value := reflect.New(param).Elem()
+			if err := toGo(arg, value, 0); err != nil {
+				return newError("argument %d to `%s`: %s", i+1, name, err)
+			}
+
+			in = append(in, value)
+		}
+
+		return funcResult(name, v.Call(in))
+	}}, nil
+}
+
+// checkArity checks the number of arguments passed to a function with a number of parameters.
+func checkArity(args, params int, variadic bool) *Error {
+	switch {
+	case variadic && args < params-1:
+		return newError("wrong number of arguments. got=%d, want=%d or more", args, params-1)
+	case !variadic && args != params:
+		return newError("wrong number of arguments. got=%d, want=%d", args, params)
+	default:
+		return nil
+	}
+}
+
+// funcResult converts the results of a Go function wrapped by WrapFunc into a Monkey object.
+func funcResult(name string, out []reflect.Value) Object {
+	if len(out) > 0 && out[len(out)-1].Type() == errorType {
+		if err, ok := out[len(out)-1].Interface().(error); ok && err != nil {
+			return &Error{Message: err.Error(), Err: err}
+		}
+
+		out = out[:len(out)-1]
+	}
+
+	if len(out) == 0 {
+		return NULL
+	}
+
+	result, err := fromGo(out[0], 0)
+	if err != nil {
+		return newError("could not convert the result of `%s`: %s", name, err)
+	}
+
+	return result
+}
+
+// objectTypesOf gets the types of the objects that convert into a Go type, or nil if any object might.
+// Null converts into the types that can be nil.
+func objectTypesOf(t reflect.Type) []ObjectType {
+	if t.Implements(objectType) && t.Kind() == reflect.Pointer {
+		return []ObjectType{reflect.New(t.Elem()).Interface().(Object).Type()}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return []ObjectType{BOOLEAN_OBJ}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return []ObjectType{INTEGER_OBJ}
+	case reflect.Float32, reflect.Float64:
+		return []ObjectType{INTEGER_OBJ, FLOAT_OBJ}
+	case reflect.String:
+		return []ObjectType{STRING_OBJ}
+	case reflect.Slice:
+		return []ObjectType{ARRAY_OBJ, SET_OBJ, NULL_OBJ}
+	case reflect.Array:
+		return []ObjectType{ARRAY_OBJ, SET_OBJ}
+	case reflect.Map:
+		return []ObjectType{HASH_OBJ, NULL_OBJ}
+	case reflect.Struct:
+		return []ObjectType{HASH_OBJ}
+	case reflect.Pointer:
+		if types := objectTypesOf(t.Elem()); types != nil {
+			return append(types, NULL_OBJ)
+		}
+
+		return nil
+	default:
+		return nil
+	}
+}
+
+// joinObjectTypes lists object types the way the errors of the builtins do, e.g. "INTEGER or FLOAT".
+func joinObjectTypes(types []ObjectType) string {
+	names := make([]string, len(types))
+	for i, t := range types {
+		names[i] = string(t)
+	}
+
+	return strings.Join(names, " or ")
+}
@@ -0,0 +1,170 @@
+package object
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"strings"
+)
+
+type HashPair struct {
+	Key   Object
+	Value Object
+}
+
+// Hash is a collection of key-value pairs that remembers the order in which its keys were first inserted.
+// The zero value is an empty hash ready to use.
+//
+// Pairs are indexed by their hash key. As different keys can generate the same hash key, e.g. two strings with a FNV
+// collision, every pair in a bucket is compared against the actual key before it is considered a match.
+type Hash struct {
+	index map[HashKey][]int // index maps a hash key to the positions in pairs of every key that generated it
+	pairs []HashPair
+}
+
+func (h *Hash) Type() ObjectType { return HASH_OBJ }
+func (h *Hash) Inspect() string {
+	var out bytes.Buffer
+
+	pairs := []string{}
+	for _, pair := range h.pairs {
+		pairs = append(pairs, fmt.Sprintf("%s: %s", pair.Key.Inspect(), pair.Value.Inspect()))
+	}
+
+	out.WriteString("{")
+	out.WriteString(strings.Join(pairs, ", "))
+	out.WriteString("}")
+
+	return out.String()
+}
+
+// Len returns the number of pairs in the hash.
+func (h *Hash) Len() int { return len(h.pairs) }
+
+// Pairs returns the pairs of the hash in insertion order.
+// The returned slice must not be modified.
+func (h *Hash) Pairs() []HashPair { return h.pairs }
+
+// Get gets the pair stored for a key.
+func (h *Hash) Get(key Hashable) (HashPair, bool) {
+	i := h.find(key.HashKey(), key)
+	if i == -1 {
+		return HashPair{}, false
+	}
+
+	return h.pairs[i], true
+}
+
+// Set stores a value for a key.
+// Overwriting an existing key keeps its original position.
+func (h *Hash) Set(key Hashable, value Object) {
+	if h.index == nil {
+		h.index = make(map[HashKey][]int)
+	}
+
+	hashKey := key.HashKey()
+	if i := h.find(hashKey, key); i != -1 {
+		h.pairs[i] = HashPair{Key: key, Value: value}
+		return
+	}
+
+	h.index[hashKey] = append(h.index[hashKey], len(h.pairs))
+	h.pairs = append(h.pairs, HashPair{Key: key, Value: value})
+}
+
+// Delete removes the pair stored for a key.
+// Returns true if the key was present.
+func (h *Hash) Delete(key Hashable) bool {
+	hashKey := key.HashKey()
+
+	i := h.find(hashKey, key)
+	if i == -1 {
+		return false
+	}
+
+	bucket := h.index[hashKey]
+	for j, position := range bucket {
+		if position == i {
+			bucket = append(bucket[:j], bucket[j+1:]...)
+			break
+		}
+	}
+
+	if len(bucket) == 0 {
+		delete(h.index, hashKey)
+	} else {
+		h.index[hashKey] = bucket
+	}
+
+	h.pairs = append(h.pairs[:i], h.pairs[i+1:]...)
+
+	// Every pair after the deleted one has shifted down by one
+	for _, bucket := range h.index {
+		for j, position := range bucket {
+			if position > i {
+				bucket[j] = position - 1
+			}
+		}
+	}
+
+	return true
+}
+
+// Copy creates a shallow copy of the hash.
+func (h *Hash) Copy() *Hash {
+	copied := &Hash{
+		index: make(map[HashKey][]int, len(h.index)),
+		pairs: make([]HashPair, len(h.pairs)),
+	}
+
+	copy(copied.pairs, h.pairs)
+	for hashKey, bucket := range h.index {
+		copied.index[hashKey] = append([]int(nil), bucket...)
+	}
+
+	return copied
+}
+
+// find gets the position of a key in pairs.
+// Returns -1 if the key is not in the hash.
+func (h *Hash) find(hashKey HashKey, key Hashable) int {
+	for _, i := range h.index[hashKey] {
+		if equalKeys(h.pairs[i].Key, key) {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// equalKeys compares two keys that generated the same hash key.
+// Integers and floats are compared by value, so a whole float matches the integer it is equal to, and NaN matches NaN.
+func equalKeys(a, b Object) bool {
+	switch a := a.(type) {
+	case *String:
+		b, ok := b.(*String)
+		return ok && a.Value == b.Value
+	case *Boolean:
+		b, ok := b.(*Boolean)
+		return ok && a.Value == b.Value
+	case *Integer:
+		switch b := b.(type) {
+		case *Integer:
+			return a.Value == b.Value
+		case *Float:
+			integer, ok := floatToInteger(b.Value)
+			return ok && integer == a.Value
+		default:
+			return false
+		}
+	case *Float:
+		if _, ok := b.(*Integer); ok {
+			return equalKeys(b, a)
+		}
+
+		b, ok := b.(*Float)
+		return ok && (a.Value == b.Value || math.IsNaN(a.Value) && math.IsNaN(b.Value))
+	default:
+		return false
+	}
+}
@@ -0,0 +1,150 @@
+package object
+
+import (
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+
+	"github.com/grantwforsythe/monkeylang/pkg/ast"
+)
+
+// ModuleLoader finds the source of the modules a program imports.
+type ModuleLoader interface {
+	// Load gets the source of the module at a path.
+	Load(path string) (string, error)
+}
+
+// FSLoader loads modules from a file system, such as a directory opened with os.DirFS or an embed.FS.
+// Module paths are relative to the root of the file system.
+type FSLoader struct {
+	FS fs.FS
+}
+
+// Load reads the module at a path from the file system.
+func (l FSLoader) Load(name string) (string, error) {
+	source, err := fs.ReadFile(l.FS, name)
+	if err != nil {
+		return "", unwrapPathError(err)
+	}
+
+	return string(source), nil
+}
+
+// MapLoader loads modules from a map of paths to sources.
+type MapLoader map[string]string
+
+// Load gets the source of the module at a path from the map.
+func (l MapLoader) Load(name string) (string, error) {
+	source, ok := l[name]
+	if !ok {
+		return "", fs.ErrNotExist
+	}
+
+	return source, nil
+}
+
+// Module is an imported program.
+// Only the bindings the program exports can be accessed, e.g. `util.max` or `util["max"]`.
+type Module struct {
+	Path    string
+	Exports *Hash
+}
+
+func (m *Module) Type() ObjectType { return MODULE_OBJ }
+func (m *Module) Inspect() string  { return fmt.Sprintf("module(%s)", m.Path) }
+
+// Export gets the value of an exported binding.
+// Returns an error if the module does not export the name.
+func (m *Module) Export(name Object) Object {
+	str, ok := name.(*String)
+	if !ok {
+		return newError("module member must be STRING. got=%s", name.Type())
+	}
+
+	pair, ok := m.Exports.Get(str)
+	if !ok {
+		return newError("module %q has no export %q", m.Path, str.Value)
+	}
+
+	return pair.Value
+}
+
+// ModulePath cleans the path of an import so that every way of writing the same path refers to the same module.
+func ModulePath(name string) string {
+	return path.Clean(name)
+}
+
+// LoadModule loads and parses the source of a module.
+func LoadModule(loader ModuleLoader, name string) (*ast.Program, *Error) {
+	if loader == nil {
+		return nil, newError("could not import %q: modules are not enabled", name)
+	}
+
+	source, err := loader.Load(name)
+	if err != nil {
+		return nil, newError("could not import %q: %s", name, err)
+	}
+
+	program, parseErr := ParseSource(source)
+	if parseErr != nil {
+		return nil, newError("could not import %q: %s", name, parseErr.Message)
+	}
+
+	return program, nil
+}
+
+// ImportCycleError creates the error for a module that imports itself through the chain of modules being imported.
+func ImportCycleError(importing []string, name string) *Error {
+	return newError("import cycle: %s -> %s", strings.Join(importing, " -> "), name)
+}
+
+// Exports gets the names of the bindings a module exports, in the order they are declared.
+func Exports(program *ast.Program) []string {
+	names := []string{}
+	for _, stmt := range program.Statements {
+		if export, ok := stmt.(*ast.ExportStatement); ok {
+			names = append(names, export.Statement.Name.Value)
+		}
+	}
+
+	return names
+}
+
+// ImportModule gets the module at a path.
+// The first time a module is imported it is loaded with the loader of the runtime and evaluated, and every later import
+// gets the same module. Returns an error if the module cannot be loaded or evaluated or if it imports itself.
+func (rt *Runtime) ImportModule(name string, evaluate func(program *ast.Program) Object) Object {
+	name = ModulePath(name)
+	if module, ok := rt.modules[name]; ok {
+		return module
+	}
+
+	for i, importing := range rt.importing {
+		if importing == name {
+			return ImportCycleError(rt.importing[i:], name)
+		}
+	}
+
+	program, err := LoadModule(rt.Loader, name)
+	if err != nil {
+		return err
+	}
+
+	rt.importing = append(rt.importing, name)
+	result := evaluate(program)
+	rt.importing = rt.importing[:len(rt.importing)-1]
+
+	module, ok := result.(*Module)
+	if !ok {
+		return result
+	}
+	module.Path = name
+
+	if rt.modules == nil {
+		rt.modules = make(map[string]*Module)
+	}
+	rt.modules[name] = module
+
+	return module
+}
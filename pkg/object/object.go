@@ -5,10 +5,13 @@ import (
 	"bytes"
 	"fmt"
 	"hash/fnv"
+	"math"
 	"strconv"
 	"strings"
+	"sync/atomic"
 
 	"github.com/grantwforsythe/monkeylang/pkg/ast"
+	"github.com/grantwforsythe/monkeylang/pkg/code"
 	"github.com/grantwforsythe/monkeylang/pkg/token"
 )
 
@@ -16,17 +19,28 @@ type ObjectType string
 
 const (
 	INTEGER_OBJ      = "INTEGER"
+	FLOAT_OBJ        = "FLOAT"
 	BOOLEAN_OBJ      = "BOOLEAN"
 	NULL_OBJ         = "NULL"
 	RETURN_VALUE_OBJ = "RETURN"
 	ERROR_OBJ        = "ERROR"
+	EXIT_OBJ         = "EXIT"
 	FUNCTION_OBJ     = "FUNCTION"
 	STRING_OBJ       = "STRING"
 	BUILTIN_OBJ      = "BUILTIN"
 	ARRAY_OBJ        = "ARRAY"
 	HASH_OBJ         = "HASH"
+	SET_OBJ          = "SET"
 	QUOTE_OBJ        = "QUOTE"
 	MACRO_OBJ        = "MACRO"
+	MODULE_OBJ       = "MODULE"
+)
+
+// The boolean and null objects are singletons so they can be compared by identity
+var (
+	TRUE  = &Boolean{Value: true}
+	FALSE = &Boolean{Value: false}
+	NULL  = &Null{}
 )
 
 type Object interface {
@@ -49,9 +63,11 @@ type HashKey struct {
 }
 
 // Represents an object that can be hashed
+// Two hashable objects that are equal must generate the same hash key, but two objects with the same hash key are not
+// necessarily equal. See Hash for how collisions are handled.
 type Hashable interface {
+	Object
 	// Generate a hash key for an object
-	// PERF: Cache return values
 	HashKey() HashKey
 }
 
@@ -71,6 +87,82 @@ func (i *Integer) ToNode() ast.Node {
 	}
 }
 
+type Float struct {
+	Value float64
+}
+
+func (f *Float) Type() ObjectType { return FLOAT_OBJ }
+func (f *Float) Inspect() string {
+	value := f.Value
+	if value != 0 && (math.Abs(value) < 1e-6 || math.Abs(value) >= 1e21) {
+		return strconv.FormatFloat(value, 'g', -1, 64)
+	}
+
+	str := strconv.FormatFloat(value, 'f', -1, 64)
+	// Whole floats keep their decimal point so they are not mistaken for integers
+	if !strings.ContainsAny(str, ".IN") {
+		str += ".0"
+	}
+
+	return str
+}
+
+// HashKey generates the same hash key for floats that are equal, so 0.0 and -0.0 are the same key, and every NaN is
+// the same key even though NaN is not equal to itself. A whole float is the same key as the integer it is equal to.
+func (f *Float) HashKey() HashKey {
+	if integer, ok := floatToInteger(f.Value); ok {
+		return HashKey{Type: INTEGER_OBJ, Value: uint64(integer)}
+	}
+
+	if math.IsNaN(f.Value) {
+		return HashKey{Type: f.Type(), Value: math.Float64bits(math.NaN())}
+	}
+
+	return HashKey{Type: f.Type(), Value: math.Float64bits(f.Value)}
+}
+func (f *Float) ToNode() ast.Node {
+	return &ast.FloatLiteral{
+		Token: token.Token{Type: token.FLOAT, Literal: f.Inspect()},
+		Value: f.Value,
+	}
+}
+
+// floatToInteger gets the integer a float is equal to.
+// Returns false if the float is not a whole number or is out of the range of integers.
+func floatToInteger(value float64) (int64, bool) {
+	if value != math.Trunc(value) || value < math.MinInt64 || value >= math.MaxInt64 {
+		return 0, false
+	}
+
+	return int64(value), true
+}
+
+// AsFloat gets the value of an integer or float as a float.
+// Returns false if the object is not a number.
+func AsFloat(obj Object) (float64, bool) {
+	switch obj := obj.(type) {
+	case *Integer:
+		return float64(obj.Value), true
+	case *Float:
+		return obj.Value, true
+	default:
+		return 0, false
+	}
+}
+
+// DivideIntegers divides two integers the same way on every engine.
+// Returns an error for a division by zero and for the one quotient that overflows, math.MinInt64 / -1.
+func DivideIntegers(left, right int64) (int64, *Error) {
+	switch {
+	case right == 0:
+		return 0, newError("division by zero")
+	case left == math.MinInt64 && right == -1:
+		return 0, newError("integer overflow: %d / %d", left, right)
+	default:
+		return left / right, nil
+	}
+}
+
 type Boolean struct {
 	Value bool
 }
@@ -117,15 +209,31 @@ func (rv *ReturnValue) Inspect() string  { return rv.Value.Inspect() }
 // TODO: Add line and column number
 type Error struct {
 	Message string
+
+	// Err is the reason the host stopped the program, such as ErrStepLimitExceeded or the error of a cancelled
+	// context, so that hosts can tell these errors apart from the errors of the program with errors.Is.
+	Err error
 }
 
 func (e *Error) Type() ObjectType { return ERROR_OBJ }
 func (e *Error) Inspect() string  { return fmt.Sprintf("Error: %s", e.Message) }
+func (e *Error) Error() string    { return e.Message }
+func (e *Error) Unwrap() error    { return e.Err }
+
+// Exit stops a program the same way an error does, but without failing.
+// It is returned by `quit`.
+type Exit struct {
+	Code int64
+}
+
+func (e *Exit) Type() ObjectType { return EXIT_OBJ }
+func (e *Exit) Inspect() string  { return fmt.Sprintf("exit(%d)", e.Code) }
 
 type Function struct {
 	Parameters []*ast.Identifier
 	Body       *ast.BlockStatement
 	Env        *Environment
+	Name       string // Name is the identifier the function was bound to when it was defined, if any.
 }
 
 func (f *Function) Type() ObjectType { return FUNCTION_OBJ }
@@ -147,21 +255,102 @@ func (f *Function) Inspect() string {
 	return out.String()
 }
 
+// CompiledFunction is a function compiled into bytecode for the virtual machine.
+type CompiledFunction struct {
+	Instructions  code.Instructions
+	NumLocals     int // NumLocals is the number of local bindings, including the parameters.
+	NumParameters int
+	Free          []FreeVariable // Free describes the variables of enclosing functions a closure of the function captures.
+	Name          string         // Name is the identifier the function was bound to when it was defined, if any.
+	Parameters    []string       // Parameters are the names of the parameters, for the builtins that inspect functions.
+	Source        string         // Source is the source of the function, for the builtins that inspect functions.
+}
+
+// Compiled functions and closures have the same type as the functions of the evaluator, so programs see the same types
+// on both engines.
+func (cf *CompiledFunction) Type() ObjectType { return FUNCTION_OBJ }
+func (cf *CompiledFunction) Inspect() string  { return fmt.Sprintf("CompiledFunction[%p]", cf) }
+
+// FreeVariable describes where a variable of an enclosing function is captured from when a closure is created.
+type FreeVariable struct {
+	Local bool // Local is true for a local binding of the function creating the closure and false for one of its free variables.
+	Index int
+}
+
+// Closure is a compiled function together with the free variables it captured when it was created.
+type Closure struct {
+	Fn   *CompiledFunction
+	Free []*Upvalue
+}
+
+func (c *Closure) Type() ObjectType { return FUNCTION_OBJ }
+func (c *Closure) Inspect() string  { return fmt.Sprintf("Closure[%p]", c) }
+
+// Upvalue is a variable of an enclosing function captured by a closure.
+// It refers to the variable in the frame of the enclosing function while the function is running and holds the variable
+// itself once the function returns, so every closure that captures the variable sees every assignment to it.
+type Upvalue struct {
+	stack  *[]Object // stack is the stack the variable is on until the upvalue is closed. It may grow while the upvalue is open.
+	index  int
+	closed Object
+}
+
+// NewUpvalue creates an upvalue that refers to the variable at an index of the stack of a running function.
+func NewUpvalue(stack *[]Object, index int) *Upvalue {
+	return &Upvalue{stack: stack, index: index}
+}
+
+// Get gets the value of the variable.
+func (u *Upvalue) Get() Object {
+	if u.stack == nil {
+		return u.closed
+	}
+
+	return (*u.stack)[u.index]
+}
+
+// Set changes the value of the variable.
+func (u *Upvalue) Set(value Object) {
+	if u.stack == nil {
+		u.closed = value
+		return
+	}
+
+	(*u.stack)[u.index] = value
+}
+
+// Close moves the variable into the upvalue, for when the frame it refers to is about to be discarded.
+func (u *Upvalue) Close() {
+	u.closed = (*u.stack)[u.index]
+	u.stack = nil
+}
+
 type String struct {
 	Value string
+
+	// hashKey caches the hash key, as hashing is linear in the length of the string. It is atomic because constants
+	// and namespaces are shared by the interpreters running in different goroutines.
+	hashKey atomic.Pointer[HashKey]
 }
 
 func (s *String) Type() ObjectType { return STRING_OBJ }
 func (s *String) Inspect() string  { return s.Value }
 func (s *String) HashKey() HashKey {
+	if hashKey := s.hashKey.Load(); hashKey != nil {
+		return *hashKey
+	}
+
 	h := fnv.New64a()
 	h.Write([]byte(s.Value))
 
-	return HashKey{Type: s.Type(), Value: h.Sum64()}
+	hashKey := &HashKey{Type: s.Type(), Value: h.Sum64()}
+	s.hashKey.Store(hashKey)
+	return *hashKey
 }
 
-// A builtin funcion written in the host language (go) and exposed in the interpreter
-type BuiltinFunction func(a ...Object) Object
+// A builtin funcion written in the host language (go) and exposed in the interpreter.
+// The runtime is the state of the interpreter calling the builtin.
+type BuiltinFunction func(rt *Runtime, args ...Object) Object
 
 type Builtin struct {
 	Fn BuiltinFunction
@@ -170,6 +359,23 @@ type Builtin struct {
 func (f *Builtin) Type() ObjectType { return BUILTIN_OBJ }
 func (f *Builtin) Inspect() string  { return "builtin function" }
 
+// Call calls the builtin and records the string, array or hash it returns as an allocation of the program, unless it
+// is one of the arguments.
+func (f *Builtin) Call(rt *Runtime, args ...Object) Object {
+	result := f.Fn(rt, args...)
+	for _, arg := range args {
+		if result == arg {
+			return result
+		}
+	}
+
+	if err := rt.Allocate(result); err != nil {
+		return err
+	}
+
+	return result
+}
+
 type Array struct {
 	Elements []Object
 }
@@ -190,31 +396,6 @@ func (a *Array) Inspect() string {
 	return out.String()
 }
 
-type HashPair struct {
-	Key   Object
-	Value Object
-}
-
-type Hash struct {
-	Pairs map[HashKey]HashPair // HashPair is the value because we want to keep track of the object used to create the hash
-}
-
-func (h *Hash) Type() ObjectType { return HASH_OBJ }
-func (h *Hash) Inspect() string {
-	var out bytes.Buffer
-
-	pairs := []string{}
-	for _, pair := range h.Pairs {
-		pairs = append(pairs, fmt.Sprintf("%s: %s", pair.Key.Inspect(), pair.Value.Inspect()))
-	}
-
-	out.WriteString("{")
-	out.WriteString(strings.Join(pairs, ", "))
-	out.WriteString("}")
-
-	return out.String()
-}
-
 // An unevaluated AST node
 type Quote struct {
 	Node ast.Node
@@ -1,6 +1,20 @@
 package object
 
-import "testing"
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/grantwforsythe/monkeylang/pkg/ast"
+)
 
 func TestStringHashKey(t *testing.T) {
 	hello1 := &String{Value: "Hello"}
@@ -58,3 +72,769 @@ func TestIntegerHashKey(t *testing.T) {
 		t.Errorf("strings with same content have different hash keys")
 	}
 }
+
+func TestFloatInspect(t *testing.T) {
+	tests := []struct {
+		value    float64
+		expected string
+	}{
+		{1.5, "1.5"},
+		{2, "2.0"},
+		{-0.25, "-0.25"},
+		{0, "0.0"},
+		{1e21, "1e+21"},
+		{1e-7, "1e-07"},
+		{math.Inf(1), "+Inf"},
+		{math.NaN(), "NaN"},
+	}
+
+	for _, tt := range tests {
+		float := &Float{Value: tt.value}
+		if float.Inspect() != tt.expected {
+			t.Errorf("wrong inspect for %v. expected=%q, got=%q", tt.value, tt.expected, float.Inspect())
+		}
+	}
+
+	if (&Float{Value: 1.5}).HashKey() != (&Float{Value: 1.5}).HashKey() {
+		t.Errorf("floats with same value have different hash keys")
+	}
+}
+
+func TestHashNumberKeys(t *testing.T) {
+	tests := []struct {
+		first, second Hashable
+		same          bool
+	}{
+		{&Float{Value: 0}, &Float{Value: math.Copysign(0, -1)}, true},
+		{&Float{Value: math.NaN()}, &Float{Value: math.NaN()}, true},
+		{&Float{Value: 1}, &Integer{Value: 1}, true},
+		{&Integer{Value: -3}, &Float{Value: -3}, true},
+		{&Float{Value: 1.5}, &Float{Value: 1.5}, true},
+		{&Float{Value: 1.5}, &Integer{Value: 1}, false},
+		{&Float{Value: 1 << 53}, &Integer{Value: 1<<53 + 1}, false},
+		{&Float{Value: 1e300}, &Float{Value: math.Inf(1)}, false},
+		{&Integer{Value: 1}, &Boolean{Value: true}, false},
+	}
+
+	for _, tt := range tests {
+		hash := &Hash{}
+		hash.Set(tt.first, &Integer{Value: 1})
+		hash.Set(tt.second, &Integer{Value: 2})
+
+		if same := hash.Len() == 1; same != tt.same {
+			t.Errorf("wrong key equality of %s and %s. expected=%t, got=%t",
+				tt.first.Inspect(), tt.second.Inspect(), tt.same, same)
+		}
+	}
+}
+
+func TestHashKeepsInsertionOrder(t *testing.T) {
+	hash := &Hash{}
+	hash.Set(&String{Value: "c"}, &Integer{Value: 1})
+	hash.Set(&String{Value: "a"}, &Integer{Value: 2})
+	hash.Set(&String{Value: "b"}, &Integer{Value: 3})
+	// Overwriting a key keeps its original position
+	hash.Set(&String{Value: "c"}, &Integer{Value: 4})
+
+	if hash.Inspect() != "{c: 4, a: 2, b: 3}" {
+		t.Errorf("hash has wrong order. got=%s", hash.Inspect())
+	}
+
+	if !hash.Delete(&String{Value: "c"}) {
+		t.Errorf("hash.Delete returned false for an existing key")
+	}
+
+	if hash.Delete(&String{Value: "c"}) {
+		t.Errorf("hash.Delete returned true for a missing key")
+	}
+
+	if hash.Inspect() != "{a: 2, b: 3}" {
+		t.Errorf("hash has wrong order after delete. got=%s", hash.Inspect())
+	}
+
+	pair, ok := hash.Get(&String{Value: "b"})
+	if !ok || pair.Value.Inspect() != "3" {
+		t.Errorf("hash.Get returned wrong pair after delete. got=%+v", pair)
+	}
+
+	copied := hash.Copy()
+	copied.Set(&String{Value: "d"}, &Integer{Value: 5})
+
+	if hash.Len() != 2 || copied.Len() != 3 {
+		t.Errorf("copy is not independent. got=%d and %d pairs", hash.Len(), copied.Len())
+	}
+}
+
+func TestStringHashKeyIsCached(t *testing.T) {
+	str := &String{Value: "Hello"}
+	hashKey := str.HashKey()
+
+	if cached := str.hashKey.Load(); cached == nil || *cached != hashKey {
+		t.Errorf("hash key was not cached. got=%v", cached)
+	}
+}
+
+func TestHashStringKeyCollisions(t *testing.T) {
+	// Force both keys to generate the same hash key
+	collision := HashKey{Type: STRING_OBJ, Value: 42}
+	first := &String{Value: "first"}
+	first.hashKey.Store(&collision)
+	second := &String{Value: "second"}
+	second.hashKey.Store(&collision)
+
+	hash := &Hash{}
+	hash.Set(first, &Integer{Value: 1})
+	hash.Set(second, &Integer{Value: 2})
+
+	if hash.Len() != 2 {
+		t.Fatalf("colliding keys overwrote each other. got=%s", hash.Inspect())
+	}
+
+	for key, expected := range map[*String]string{first: "1", second: "2"} {
+		pair, ok := hash.Get(key)
+		if !ok || pair.Value.Inspect() != expected {
+			t.Errorf("wrong pair for key %q. got=%+v", key.Value, pair)
+		}
+	}
+
+	hash.Delete(first)
+
+	if _, ok := hash.Get(first); ok {
+		t.Errorf("deleted key is still present")
+	}
+
+	pair, ok := hash.Get(second)
+	if !ok || pair.Value.Inspect() != "2" {
+		t.Errorf("deleting a colliding key removed the wrong pair. got=%s", hash.Inspect())
+	}
+}
+
+func TestSetOperations(t *testing.T) {
+	newSet := func(values ...int64) *Set {
+		set := &Set{}
+		for _, value := range values {
+			set.Add(&Integer{Value: value})
+		}
+		return set
+	}
+
+	tests := []struct {
+		actual   *Set
+		expected string
+	}{
+		{newSet(1, 2, 2, 1), "set([1, 2])"},
+		{newSet(1, 2).Union(newSet(2, 3)), "set([1, 2, 3])"},
+		{newSet(1, 2, 3).Intersection(newSet(3, 1)), "set([1, 3])"},
+		{newSet(1, 2, 3).Difference(newSet(3, 1)), "set([2])"},
+	}
+
+	for _, tt := range tests {
+		if tt.actual.Inspect() != tt.expected {
+			t.Errorf("wrong set. got=%s, expected=%s", tt.actual.Inspect(), tt.expected)
+		}
+	}
+
+	set := newSet(1)
+	copied := set.Copy()
+	copied.Add(&Integer{Value: 2})
+	copied.Remove(&Integer{Value: 1})
+
+	if !set.Has(&Integer{Value: 1}) || set.Len() != 1 {
+		t.Errorf("copy is not independent. got=%s", set.Inspect())
+	}
+}
+
+func TestJSONParse(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`{"b": 1, "a": [true, null, 1.5, "x"]}`, "{b: 1, a: [true, null, 1.5, x]}"},
+		{`[]`, "[]"},
+		{` 42 `, "42"},
+		{`-2.0`, "-2.0"},
+		{`1e3`, "1000.0"},
+		{`99999999999999999999`, "100000000000000000000.0"},
+		{`"é\n"`, "é\n"},
+		{`{"a": 1, "a": 2}`, "{a: 2}"},
+		{`{"a": }`, "Error: could not parse JSON: missing value after object key"},
+		{`[1, 2`, "Error: could not parse JSON: unexpected end of JSON input"},
+		{`1 2`, "Error: could not parse JSON: unexpected data after the JSON value"},
+		{``, "Error: could not parse JSON: unexpected end of JSON input"},
+	}
+
+	for _, tt := range tests {
+		actual := Builtins["json_parse"].Fn(&Runtime{}, &String{Value: tt.input})
+		if actual.Inspect() != tt.expected {
+			t.Errorf("json_parse(%q). expected=%q, got=%q", tt.input, tt.expected, actual.Inspect())
+		}
+	}
+}
+
+func TestJSONStringify(t *testing.T) {
+	hash := &Hash{}
+	hash.Set(&String{Value: "b"}, &Array{Elements: []Object{&Integer{Value: 1}, &Float{Value: 2}, NULL}})
+	hash.Set(&Integer{Value: 1}, &String{Value: "<\"a\">"})
+	hash.Set(TRUE, &Hash{})
+
+	tests := []struct {
+		args     []Object
+		expected string
+	}{
+		{[]Object{hash}, `{"b":[1,2.0,null],"1":"<\"a\">","true":{}}`},
+		{[]Object{&Array{Elements: []Object{&Integer{Value: 1}}}, &Integer{Value: 2}}, "[\n  1\n]"},
+		{[]Object{hash, &String{Value: "\t"}}, "{\n\t\"b\": [\n\t\t1,\n\t\t2.0,\n\t\tnull\n\t],\n\t\"1\": \"<\\\"a\\\">\",\n\t\"true\": {}\n}"},
+		{[]Object{&Array{Elements: []Object{&Builtin{}}}}, "Error: could not convert to JSON: unsupported type BUILTIN"},
+		{[]Object{&Float{Value: math.Inf(1)}}, "Error: could not convert to JSON: unsupported float +Inf"},
+		{[]Object{NULL, TRUE}, "Error: argument 2 to `json_stringify` must be INTEGER or STRING. got=BOOLEAN"},
+	}
+
+	for _, tt := range tests {
+		actual := Builtins["json_stringify"].Fn(nil, tt.args...)
+		if actual.Inspect() != tt.expected {
+			t.Errorf("wrong json. expected=%q, got=%q", tt.expected, actual.Inspect())
+		}
+	}
+}
+
+func TestFileBuiltins(t *testing.T) {
+	root := t.TempDir()
+	outside := t.TempDir()
+
+	if err := os.WriteFile(filepath.Join(root, "lines.txt"), []byte("a\r\nb\n\nc\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(filepath.Join(root, "dir"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(outside, "secret.txt"), []byte("secret"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	links := map[string]string{
+		"escape":   outside,
+		"victim":   filepath.Join(outside, "victim.txt"),
+		"up":       "dir/../..",
+		"inside":   "dir",
+		"absolute": filepath.Join(root, "dir"),
+		"pending":  "dir/pending.txt",
+		"loop":     "loop",
+	}
+	for name, target := range links {
+		if err := os.Symlink(target, filepath.Join(root, name)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	call := func(fs FileSystem, name string, args ...string) string {
+		objects := make([]Object, len(args))
+		for i, arg := range args {
+			objects[i] = &String{Value: arg}
+		}
+
+		return Builtins[name].Fn(&Runtime{FileSystem: fs}, objects...).Inspect()
+	}
+
+	readWrite := FileSystem{Root: root, Read: true, Write: true}
+	readOnly := FileSystem{Root: root, Read: true}
+
+	tests := []struct {
+		fs       FileSystem
+		name     string
+		args     []string
+		expected string
+	}{
+		{readWrite, "write_file", []string{"out.txt", "hello"}, "null"},
+		{readWrite, "read_file", []string{"out.txt"}, "hello"},
+		{readWrite, "read_file", []string{"dir/../out.txt"}, "hello"},
+		{readWrite, "read_lines", []string{"lines.txt"}, "[a, b, , c]"},
+		{readWrite, "list_dir", nil, "[absolute, dir, escape, inside, lines.txt, loop, out.txt, pending, up, victim]"},
+		{readWrite, "list_dir", []string{"dir"}, "[]"},
+		{readWrite, "exists", []string{"out.txt"}, "true"},
+		{readWrite, "exists", []string{"missing.txt"}, "false"},
+		{readWrite, "read_file", []string{"missing.txt"}, `Error: could not read "missing.txt": no such file or directory`},
+		{readWrite, "read_file", []string{"../secret.txt"}, `Error: could not read "../secret.txt": path "../secret.txt" is outside the root directory`},
+		{readWrite, "read_file", []string{filepath.Join(outside, "secret.txt")}, fmt.Sprintf(`Error: could not read %q: path %q is outside the root directory`, filepath.Join(outside, "secret.txt"), filepath.Join(outside, "secret.txt"))},
+		{readWrite, "read_file", []string{"escape/secret.txt"}, `Error: could not read "escape/secret.txt": path "escape/secret.txt" is outside the root directory`},
+		{readWrite, "write_file", []string{"escape/new.txt", "x"}, `Error: could not write "escape/new.txt": path "escape/new.txt" is outside the root directory`},
+		{readWrite, "write_file", []string{"victim", "x"}, `Error: could not write "victim": path "victim" is outside the root directory`},
+		{readWrite, "exists", []string{"victim"}, `Error: could not check "victim": path "victim" is outside the root directory`},
+		{readWrite, "read_file", []string{"up/secret.txt"}, `Error: could not read "up/secret.txt": path "up/secret.txt" is outside the root directory`},
+		{readWrite, "read_file", []string{"dir/../escape/secret.txt"}, `Error: could not read "dir/../escape/secret.txt": path "dir/../escape/secret.txt" is outside the root directory`},
+		{readWrite, "write_file", []string{"inside/in.txt", "in"}, "null"},
+		{readWrite, "read_file", []string{"dir/in.txt"}, "in"},
+		{readWrite, "read_file", []string{"absolute/in.txt"}, "in"},
+		{readWrite, "write_file", []string{"pending", "pending"}, "null"},
+		{readWrite, "read_file", []string{"dir/pending.txt"}, "pending"},
+		{readWrite, "read_file", []string{"loop"}, `Error: could not read "loop": too many levels of symbolic links`},
+		{readWrite, "read_file", []string{"out.txt/x"}, `Error: could not read "out.txt/x": not a directory`},
+		{readOnly, "read_file", []string{"out.txt"}, "hello"},
+		{readOnly, "write_file", []string{"out.txt", "x"}, `Error: could not write "out.txt": writing files is not permitted`},
+		{FileSystem{Root: root, Write: true}, "exists", []string{"out.txt"}, `Error: could not check "out.txt": reading files is not permitted`},
+		{FileSystem{}, "read_file", []string{"out.txt"}, `Error: could not read "out.txt": file access is not permitted`},
+	}
+
+	for _, tt := range tests {
+		actual := call(tt.fs, tt.name, tt.args...)
+		if actual != tt.expected {
+			t.Errorf("%s(%q). expected=%q, got=%q", tt.name, tt.args, tt.expected, actual)
+		}
+	}
+
+	for _, name := range []string{"new.txt", "victim.txt"} {
+		if _, err := os.Stat(filepath.Join(outside, name)); err == nil {
+			t.Errorf("%s was written outside of the root directory", name)
+		}
+	}
+
+	// A file too large for the memory limit is not read
+	rt := &Runtime{FileSystem: readWrite, MaxMemory: 36}
+	result := Builtins["read_file"].Call(rt, &String{Value: "lines.txt"})
+	if err, ok := result.(*Error); !ok || !errors.Is(err, ErrMemoryLimitExceeded) {
+		t.Errorf("read_file(%q). expected=%v, got=%s", "lines.txt", ErrMemoryLimitExceeded, result.Inspect())
+	}
+	if rt.Memory() != 0 {
+		t.Errorf("read_file(%q) allocated past the limit. got=%d", "lines.txt", rt.Memory())
+	}
+}
+
+func TestNamespacesAreCopiedPerRuntime(t *testing.T) {
+	rt := &Runtime{}
+
+	first, _ := rt.LookupBuiltin("math")
+	second, _ := rt.LookupBuiltin("math")
+	if first != second {
+		t.Errorf("runtime got a different copy of the namespace")
+	}
+
+	// Changing the namespace of one runtime does not affect any other
+	first.(*Hash).Set(&String{Value: "PI"}, &Integer{Value: 3})
+
+	other, _ := (&Runtime{}).LookupBuiltin("math")
+	if pi, _ := other.(*Hash).Get(&String{Value: "PI"}); pi.Value.Inspect() != "3.141592653589793" {
+		t.Errorf("runtimes share namespaces. got=%s", pi.Value.Inspect())
+	}
+}
+
+func TestRegexpsAreCachedPerRuntime(t *testing.T) {
+	rt := &Runtime{}
+
+	first, err := rt.compileRegexp("a+")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	second, _ := rt.compileRegexp("a+")
+	if first != second {
+		t.Errorf("pattern was compiled twice")
+	}
+
+	other, _ := (&Runtime{}).compileRegexp("a+")
+	if other == first {
+		t.Errorf("runtimes share compiled patterns")
+	}
+
+	for i := range maxCachedRegexps {
+		_, _ = rt.compileRegexp(fmt.Sprintf("b%d", i))
+	}
+
+	if len(rt.regexps) > maxCachedRegexps {
+		t.Errorf("cache grew past its limit. got=%d", len(rt.regexps))
+	}
+}
+
+func TestModuleLoaders(t *testing.T) {
+	loaders := map[string]ModuleLoader{
+		"fs":  FSLoader{FS: fstest.MapFS{"lib/util.mk": {Data: []byte("export let x = 1;")}}},
+		"map": MapLoader{"lib/util.mk": "export let x = 1;"},
+	}
+
+	for name, loader := range loaders {
+		program, err := LoadModule(loader, ModulePath("./lib/../lib/util.mk"))
+		if err != nil {
+			t.Fatalf("%s: could not load module: %s", name, err.Message)
+		}
+
+		exports := Exports(program)
+		if len(exports) != 1 || exports[0] != "x" {
+			t.Errorf("%s: wrong exports. got=%v", name, exports)
+		}
+
+		_, err = LoadModule(loader, "missing.mk")
+		if err == nil || err.Message != `could not import "missing.mk": file does not exist` {
+			t.Errorf("%s: wrong error for a missing module. got=%v", name, err)
+		}
+	}
+}
+
+func TestModulesAreCachedPerRuntime(t *testing.T) {
+	rt := &Runtime{Loader: MapLoader{"m.mk": "1"}}
+
+	evaluations := 0
+	evaluate := func(program *ast.Program) Object {
+		evaluations++
+		return &Module{Exports: &Hash{}}
+	}
+
+	first := rt.ImportModule("m.mk", evaluate)
+	second := rt.ImportModule("./m.mk", evaluate)
+	if first != second || evaluations != 1 {
+		t.Errorf("module was evaluated %d times", evaluations)
+	}
+
+	(&Runtime{Loader: rt.Loader}).ImportModule("m.mk", evaluate)
+	if evaluations != 2 {
+		t.Errorf("runtimes share imported modules")
+	}
+
+	// Modules that fail to evaluate are not cached
+	failing := &Runtime{Loader: rt.Loader}
+	failing.ImportModule("m.mk", func(program *ast.Program) Object { return newError("failed") })
+	if _, ok := failing.modules["m.mk"]; ok {
+		t.Errorf("failed module was cached")
+	}
+}
+
+func TestRuntimeSteps(t *testing.T) {
+	tests := []struct {
+		limit int64
+		steps int
+		err   error
+	}{
+		{0, 5000, nil},
+		{3000, 3000, nil},
+		{3000, 3001, ErrStepLimitExceeded},
+		{1, 2, ErrStepLimitExceeded},
+	}
+
+	for _, tt := range tests {
+		rt := NewRuntime()
+		rt.MaxSteps = tt.limit
+
+		var err *Error
+		for i := 0; i < tt.steps && err == nil; i++ {
+			err = rt.Step()
+		}
+
+		if tt.err == nil && err != nil {
+			t.Errorf("limit=%d, steps=%d: unexpected error. got=%q", tt.limit, tt.steps, err.Message)
+		}
+		if tt.err != nil && !errors.Is(err, tt.err) {
+			t.Errorf("limit=%d, steps=%d: wrong error. got=%v, want=%v", tt.limit, tt.steps, err, tt.err)
+		}
+		if got := rt.Steps(); got != int64(tt.steps) {
+			t.Errorf("limit=%d: wrong number of steps. got=%d, want=%d", tt.limit, got, tt.steps)
+		}
+	}
+
+	// A context is checked at the first step after it is set, however many steps are left in the batch
+	rt := NewRuntime()
+	rt.Step()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	rt.SetContext(ctx)
+
+	if err := rt.Step(); !errors.Is(err, context.Canceled) || err.Message != "program interrupted: context canceled" {
+		t.Errorf("wrong error for a cancelled context. got=%v", err)
+	}
+
+	rt.SetContext(context.Background())
+	rt.ResetUsage()
+	if err := rt.Step(); err != nil || rt.Steps() != 1 {
+		t.Errorf("runtime was not reset. got=%v, steps=%d", err, rt.Steps())
+	}
+}
+
+type convertedAddress struct {
+	City string `monkey:"city"`
+}
+
+type convertedUser struct {
+	Name     string            `monkey:"name"`
+	Age      int               `monkey:"age"`
+	Admin    bool              `monkey:"admin"`
+	Scores   []float64         `monkey:"scores"`
+	Address  *convertedAddress `monkey:"address"`
+	Nickname string            `monkey:"nickname,omitempty"`
+	Password string            `monkey:"-"`
+	Tags     map[string]int
+	internal int
+}
+
+func TestFromGo(t *testing.T) {
+	var nilUser *convertedUser
+
+	tests := []struct {
+		input    any
+		expected string
+	}{
+		{nil, "null"},
+		{nilUser, "null"},
+		{true, "true"},
+		{42, "42"},
+		{int8(-3), "-3"},
+		{uint16(7), "7"},
+		{1.5, "1.5"},
+		{float32(0.25), "0.25"},
+		{"monkey", "monkey"},
+		{[]int{1, 2, 3}, "[1, 2, 3]"},
+		{[2]string{"a", "b"}, "[a, b]"},
+		{[]any{1, "a", nil, []bool{true}}, "[1, a, null, [true]]"},
+		{map[string]int{"b": 2, "a": 1}, "{a: 1, b: 2}"},
+		{map[int]string{10: "x", 9: "y"}, "{9: y, 10: x}"},
+		{&Integer{Value: 5}, "5"},
+		{[]Object{&String{Value: "a"}, nil}, "[a, null]"},
+		{
+			convertedUser{Name: "Ada", Age: 36, Scores: []float64{1.5}, Address: &convertedAddress{City: "London"}, Password: "secret", internal: 1},
+			"{name: Ada, age: 36, admin: false, scores: [1.5], address: {city: London}, Tags: {}}",
+		},
+		{&convertedUser{Nickname: "ad"}, "{name: , age: 0, admin: false, scores: [], address: null, nickname: ad, Tags: {}}"},
+	}
+
+	for _, tt := range tests {
+		obj, err := FromGo(tt.input)
+		if err != nil {
+			t.Errorf("%#v: unexpected error. got=%s", tt.input, err)
+			continue
+		}
+
+		if obj.Inspect() != tt.expected {
+			t.Errorf("%#v: wrong object. got=%s, want=%s", tt.input, obj.Inspect(), tt.expected)
+		}
+	}
+}
+
+func TestFromGoErrors(t *testing.T) {
+	type cyclic struct{ Next *cyclic }
+	loop := &cyclic{}
+	loop.Next = loop
+
+	tests := []struct {
+		input    any
+		expected string
+	}{
+		{func() {}, "cannot convert Go func() to a Monkey object"},
+		{make(chan int), "cannot convert Go chan int to a Monkey object"},
+		{[]any{complex(1, 2)}, "cannot convert Go complex128 to a Monkey object"},
+		{uint64(math.MaxUint64), "Go uint64 18446744073709551615 is out of range"},
+		{map[[1]int]int{{1}: 1}, "Go map key [1]int is not hashable"},
+		{loop, "Go object.cyclic is nested too deeply"},
+	}
+
+	for _, tt := range tests {
+		_, err := FromGo(tt.input)
+		if err == nil || err.Error() != tt.expected {
+			t.Errorf("%T: wrong error. got=%v, want=%q", tt.input, err, tt.expected)
+		}
+	}
+}
+
+func TestToGo(t *testing.T) {
+	hash := &Hash{}
+	hash.Set(&String{Value: "a"}, &Integer{Value: 1})
+	hash.Set(&Integer{Value: 2}, &Array{Elements: []Object{TRUE, NULL}})
+
+	set := &Set{}
+	set.Add(&String{Value: "x"})
+
+	tests := []struct {
+		input    Object
+		expected any
+	}{
+		{NULL, nil},
+		{TRUE, true},
+		{&Integer{Value: 5}, int64(5)},
+		{&Float{Value: 0.5}, 0.5},
+		{&String{Value: "monkey"}, "monkey"},
+		{&Array{Elements: []Object{&Integer{Value: 1}, &String{Value: "a"}}}, []any{int64(1), "a"}},
+		{hash, map[string]any{"a": int64(1), "2": []any{true, nil}}},
+		{set, []any{"x"}},
+	}
+
+	for _, tt := range tests {
+		value, err := ToGo(tt.input)
+		if err != nil {
+			t.Errorf("%s: unexpected error. got=%s", tt.input.Inspect(), err)
+			continue
+		}
+
+		if !reflect.DeepEqual(value, tt.expected) {
+			t.Errorf("%s: wrong value. got=%#v, want=%#v", tt.input.Inspect(), value, tt.expected)
+		}
+	}
+
+	if _, err := ToGo(&Builtin{}); err == nil || err.Error() != "cannot convert BUILTIN to a Go value" {
+		t.Errorf("wrong error for a builtin. got=%v", err)
+	}
+}
+
+func TestToGoInto(t *testing.T) {
+	user := convertedUser{
+		Name:    "Ada",
+		Age:     36,
+		Admin:   true,
+		Scores:  []float64{1.5, 2},
+		Address: &convertedAddress{City: "London"},
+		Tags:    map[string]int{"x": 1},
+	}
+
+	obj, err := FromGo(user)
+	if err != nil {
+		t.Fatalf("unexpected error. got=%s", err)
+	}
+
+	var converted convertedUser
+	if err := ToGoInto(obj, &converted); err != nil {
+		t.Fatalf("unexpected error. got=%s", err)
+	}
+
+	if !reflect.DeepEqual(converted, user) {
+		t.Errorf("wrong struct. got=%+v, want=%+v", converted, user)
+	}
+
+	var numbers [2]uint8
+	if err := ToGoInto(&Array{Elements: []Object{&Integer{Value: 1}, &Integer{Value: 2}}}, &numbers); err != nil || numbers != [2]uint8{1, 2} {
+		t.Errorf("wrong array. got=%v (%v)", numbers, err)
+	}
+
+	var keep Object
+	if err := ToGoInto(&Integer{Value: 1}, &keep); err != nil || keep.Inspect() != "1" {
+		t.Errorf("wrong object. got=%v (%v)", keep, err)
+	}
+
+	tests := []struct {
+		input    Object
+		target   any
+		expected string
+	}{
+		{&Integer{Value: 1}, converted, "target must be a non-nil pointer. got=object.convertedUser"},
+		{&String{Value: "a"}, new(int), "cannot convert STRING to Go int"},
+		{&Integer{Value: 300}, new(uint8), "300 is out of range for Go uint8"},
+		{&Integer{Value: -1}, new(uint), "-1 is out of range for Go uint"},
+		{&Array{Elements: []Object{TRUE}}, new([2]bool), "cannot convert ARRAY of length 1 to Go [2]bool"},
+		{&Array{Elements: []Object{TRUE}}, new([]string), "cannot convert BOOLEAN to Go string"},
+		{obj, new(map[string]string), "cannot convert INTEGER to Go string"},
+		{&Hash{}, new(int), "cannot convert HASH to Go int"},
+	}
+
+	for _, tt := range tests {
+		err := ToGoInto(tt.input, tt.target)
+		if err == nil || err.Error() != tt.expected {
+			t.Errorf("%s: wrong error. got=%v, want=%q", tt.input.Inspect(), err, tt.expected)
+		}
+	}
+
+	hash := &Hash{}
+	hash.Set(&String{Value: "age"}, &String{Value: "old"})
+	if err := ToGoInto(hash, &converted); err == nil || err.Error() != "field age: cannot convert STRING to Go int" {
+		t.Errorf("wrong error for a field. got=%v", err)
+	}
+}
+
+func TestWrapFunc(t *testing.T) {
+	errNegative := errors.New("n cannot be negative")
+
+	funcs := map[string]any{
+		"add":   func(a, b int) int { return a + b },
+		"scale": func(x float64, factor float32) float64 { return x * float64(factor) },
+		"sqrt": func(n int) (int, error) {
+			if n < 0 {
+				return 0, errNegative
+			}
+			return int(math.Sqrt(float64(n))), nil
+		},
+		"join": func(sep string, parts ...string) string { return strings.Join(parts, sep) },
+		"user": func(u convertedUser) string { return u.Name + " from " + u.Address.City },
+		"keys": func(m map[string]int) []string {
+			keys := []string{}
+			for key := range m {
+				keys = append(keys, key)
+			}
+			slices.Sort(keys)
+			return keys
+		},
+		"maybe":   func(n *int) bool { return n == nil },
+		"noop":    func() {},
+		"check":   func(ok bool) error { return map[bool]error{false: errNegative}[ok] },
+		"inspect": func(obj Object) string { return obj.Inspect() },
+		"seeded":  func(rt *Runtime, n int) int { return n + int(rt.MaxCallDepth) },
+		"channel": func() chan int { return nil },
+	}
+
+	tests := []struct {
+		name     string
+		args     []Object
+		expected string
+	}{
+		{"add", []Object{&Integer{Value: 1}, &Integer{Value: 2}}, "3"},
+		{"scale", []Object{&Integer{Value: 2}, &Float{Value: 1.5}}, "3.0"},
+		{"sqrt", []Object{&Integer{Value: 16}}, "4"},
+		{"join", []Object{&String{Value: "-"}}, ""},
+		{"join", []Object{&String{Value: "-"}, &String{Value: "a"}, &String{Value: "b"}}, "a-b"},
+		{"user", []Object{mustFromGo(t, convertedUser{Name: "Ada", Address: &convertedAddress{City: "London"}})}, "Ada from London"},
+		{"keys", []Object{mustFromGo(t, map[string]int{"b": 1, "a": 2})}, "[a, b]"},
+		{"maybe", []Object{NULL}, "true"},
+		{"maybe", []Object{&Integer{Value: 1}}, "false"},
+		{"noop", []Object{}, "null"},
+		{"check", []Object{TRUE}, "null"},
+		{"inspect", []Object{&Array{}}, "[]"},
+		{"seeded", []Object{&Integer{Value: 1}}, "10001"},
+
+		{"add", []Object{&Integer{Value: 1}}, "Error: wrong number of arguments. got=1, want=2"},
+		{"join", []Object{}, "Error: wrong number of arguments. got=0, want=1 or more"},
+		{"add", []Object{&Integer{Value: 1}, &String{Value: "2"}}, "Error: argument 2 to `add` must be INTEGER. got=STRING"},
+		{"add", []Object{NULL, &Integer{Value: 2}}, "Error: argument 1 to `add` must be INTEGER. got=NULL"},
+		{"scale", []Object{TRUE, &Integer{Value: 1}}, "Error: argument 1 to `scale` must be INTEGER or FLOAT. got=BOOLEAN"},
+		{"join", []Object{&String{Value: "-"}, &String{Value: "a"}, &Integer{Value: 1}}, "Error: argument 3 to `join` must be STRING. got=INTEGER"},
+		{"keys", []Object{&Array{}}, "Error: argument 1 to `keys` must be HASH or NULL. got=ARRAY"},
+		{"keys", []Object{mustFromGo(t, map[string]string{"a": "b"})}, "Error: argument 1 to `keys`: cannot convert STRING to Go int"},
+		{"sqrt", []Object{&Integer{Value: -1}}, "Error: n cannot be negative"},
+		{"check", []Object{FALSE}, "Error: n cannot be negative"},
+		{"channel", []Object{}, "Error: could not convert the result of `channel`: cannot convert Go chan int to a Monkey object"},
+	}
+
+	rt := NewRuntime()
+	for _, tt := range tests {
+		builtin, err := WrapFunc(tt.name, funcs[tt.name])
+		if err != nil {
+			t.Fatalf("%s: could not wrap the function: %s", tt.name, err)
+		}
+
+		result := builtin.Fn(rt, tt.args...)
+		if result.Inspect() != tt.expected {
+			t.Errorf("%s: wrong result. got=%s, want=%s", tt.name, result.Inspect(), tt.expected)
+		}
+	}
+
+	builtin, _ := WrapFunc("sqrt", funcs["sqrt"])
+	if err, ok := builtin.Fn(rt, &Integer{Value: -1}).(*Error); !ok || !errors.Is(err, errNegative) {
+		t.Errorf("Go error is not the reason of the Monkey error. got=%v", err)
+	}
+
+	invalid := []struct {
+		fn       any
+		expected string
+	}{
+		{1, "cannot wrap int as builtin `invalid`: not a function"},
+		{(func())(nil), "cannot wrap func() as builtin `invalid`: not a function"},
+		{func() (int, int) { return 0, 0 }, "cannot wrap func() (int, int) as builtin `invalid`: it must return at most a value and an error"},
+		{func() (error, int) { return nil, 0 }, "cannot wrap func() (error, int) as builtin `invalid`: it must return at most a value and an error"},
+	}
+
+	for _, tt := range invalid {
+		if _, err := WrapFunc("invalid", tt.fn); err == nil || err.Error() != tt.expected {
+			t.Errorf("%T: wrong error. got=%v, want=%q", tt.fn, err, tt.expected)
+		}
+	}
+}
+
+func mustFromGo(t *testing.T, value any) Object {
+	t.Helper()
+
+	obj, err := FromGo(value)
+	if err != nil {
+		t.Fatalf("could not convert %#v: %s", value, err)
+	}
+
+	return obj
+}
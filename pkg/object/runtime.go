@@ -0,0 +1,363 @@
+package object
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// DefaultMaxCallDepth is the maximum number of function calls that can be in progress at once in a new runtime.
+const DefaultMaxCallDepth = 10000
+
+// checkInterval is the maximum number of steps a program takes between checks of its limits and context, which keeps
+// the checks out of the hot loop of the engines.
+const checkInterval = 1024
+
+var (
+	// ErrStepLimitExceeded is the reason a program stopped after taking more steps than its runtime allows.
+	ErrStepLimitExceeded = errors.New("step limit exceeded")
+	// ErrAllocationLimitExceeded is the reason a program stopped after allocating more than its runtime allows.
+	ErrAllocationLimitExceeded = errors.New("allocation limit exceeded")
+	// ErrMemoryLimitExceeded is the reason a program stopped after allocating more memory than its runtime allows.
+	ErrMemoryLimitExceeded = errors.New("memory limit exceeded")
+)
+
+// The approximate sizes in bytes of strings, arrays and hashes, used to account for the memory a program allocates.
+const (
+	stringSize   = 32 // stringSize is the size of a string, excluding its value.
+	arraySize    = 24 // arraySize is the size of an array, excluding its elements.
+	elementSize  = 16 // elementSize is the size of an element of an array, excluding the object it refers to.
+	hashSize     = 48 // hashSize is the size of a hash, excluding its pairs.
+	hashPairSize = 96 // hashPairSize is the size of a pair of a hash and its index entry, excluding the key and value.
+)
+
+// callTraceSize is the number of the most recent calls listed in the error for exceeding the maximum call depth.
+const callTraceSize = 5
+
+// Runtime holds the state that builtins share within a single interpreter.
+// Every interpreter has its own runtime so that, for example, seeding the random number generator in one program does
+// not affect another.
+type Runtime struct {
+	Rand       *rand.Rand    // Rand is the source of random numbers for the random builtins.
+	FileSystem FileSystem    // FileSystem is the part of the host file system the file builtins can access.
+	Stdout     io.Writer     // Stdout is where builtins such as `puts` write their output.
+	Stdin      *bufio.Reader // Stdin is where builtins such as `gets` read their input from.
+	Clock      Clock         // Clock is the source of time for the time builtins.
+	Loader     ModuleLoader  // Loader finds the source of imported modules. Programs cannot import modules without one.
+
+	// MaxCallDepth is the maximum number of function calls that can be in progress at once. A program that exceeds it
+	// stops with an error, instead of exhausting the stack of the host.
+	MaxCallDepth int
+
+	// MaxSteps is the maximum number of steps a program can take: the nodes the evaluator evaluates or the
+	// instructions the virtual machine executes. Zero means no limit.
+	MaxSteps int64
+
+	// MaxAllocations is the maximum number of strings, arrays and hashes a program can create. Zero means no limit.
+	MaxAllocations int64
+
+	// MaxMemory is the maximum number of bytes the strings, arrays and hashes a program creates can take up, which is
+	// approximated from their length. Memory is counted as it is allocated and not given back once the objects are
+	// garbage collected. Builtins that create large results check them against the limit before creating them.
+	// Zero means no limit.
+	MaxMemory int64
+
+	ctx         context.Context // ctx stops the program once it is done.
+	steps       int64           // steps is the number of steps taken up to the start of the current batch.
+	batch       int             // batch is the number of steps that can be taken before the next check.
+	countdown   int             // countdown is the number of steps left in the current batch.
+	allocations int64
+	memory      int64
+
+	exited     bool
+	exitCode   int
+	regexps    map[string]*regexp.Regexp // regexps caches the compiled patterns of the regular expression builtins.
+	namespaces map[string]*Hash          // namespaces holds the copies of the namespaces used by the programs, by name.
+	caller     Caller                    // caller calls the Monkey functions passed to builtins.
+	modules    map[string]*Module        // modules caches the imported modules by path.
+	importing  []string                  // importing is the chain of modules being imported, used to detect cycles.
+	calls      []string                  // calls are the names of the functions being called by the evaluator, innermost last.
+}
+
+// NewRuntime creates a runtime with a random number generator seeded from the current time that reads from and writes
+// to the standard input and output of the process and uses the clock of the host.
+// The runtime does not permit any file access until a file system is configured.
+func NewRuntime() *Runtime {
+	return &Runtime{
+		Rand:   rand.New(rand.NewSource(time.Now().UnixNano())),
+		Stdout: os.Stdout,
+		Stdin:  bufio.NewReader(os.Stdin),
+		Clock:  SystemClock{},
+
+		MaxCallDepth: DefaultMaxCallDepth,
+	}
+}
+
+// Caller calls a function defined in Monkey with arguments for a builtin, e.g. `map`, with the engine running the
+// program. Returns the result of the function, or an error object if it fails.
+type Caller func(fn Object, args []Object, rt *Runtime) Object
+
+// SetCaller sets how builtins call the functions passed to them. Each engine sets itself as the caller before it calls
+// a builtin.
+func (rt *Runtime) SetCaller(caller Caller) {
+	rt.caller = caller
+}
+
+// Call calls a function passed to a builtin with arguments.
+// Returns the result of the function, or an error object if it fails or cannot be called.
+func (rt *Runtime) Call(fn Object, args ...Object) Object {
+	if builtin, ok := fn.(*Builtin); ok {
+		return builtin.Call(rt, args...)
+	}
+
+	if rt.caller == nil {
+		return newError("not a function: %s", fn.Type())
+	}
+
+	return rt.caller(fn, args, rt)
+}
+
+// LookupBuiltin gets the builtin function or namespace bound to a name, the same way as the LookupBuiltin function.
+// The runtime keeps its copy of a namespace, so every program run with it gets the same one.
+func (rt *Runtime) LookupBuiltin(name string) (Object, bool) {
+	if namespace, ok := rt.namespaces[name]; ok {
+		return namespace, true
+	}
+
+	builtin, ok := LookupBuiltin(name)
+	if namespace, isNamespace := builtin.(*Hash); isNamespace {
+		if rt.namespaces == nil {
+			rt.namespaces = make(map[string]*Hash)
+		}
+		rt.namespaces[name] = namespace
+	}
+
+	return builtin, ok
+}
+
+// Exit records that the program asked to stop with an exit code.
+func (rt *Runtime) Exit(code int) {
+	rt.exited = true
+	rt.exitCode = code
+}
+
+// ExitCode gets the exit code passed to `quit`.
+// Returns false if the program has not called `quit`.
+func (rt *Runtime) ExitCode() (int, bool) {
+	return rt.exitCode, rt.exited
+}
+
+// EnterCall records that the evaluator started calling a function.
+// Returns an error if the call exceeds the maximum call depth, in which case it is not recorded.
+func (rt *Runtime) EnterCall(name string) *Error {
+	if len(rt.calls) >= rt.MaxCallDepth {
+		return CallDepthError(rt.MaxCallDepth, append(rt.calls, name))
+	}
+
+	rt.calls = append(rt.calls, name)
+	return nil
+}
+
+// LeaveCall records that the evaluator finished the most recent function call.
+func (rt *Runtime) LeaveCall() {
+	rt.calls = rt.calls[:len(rt.calls)-1]
+}
+
+// Unwind forgets the function calls and imports in progress, for a program that stopped without finishing them, such
+// as one stopped by a panic.
+func (rt *Runtime) Unwind() {
+	rt.calls = rt.calls[:0]
+	rt.importing = rt.importing[:0]
+}
+
+// CallDepthError creates the error for a call that exceeds the maximum call depth.
+// The calls are the names of the functions being called, innermost last, and the most recent of them are listed.
+func CallDepthError(limit int, calls []string) *Error {
+	recent := []string{}
+	for i := len(calls) - 1; i >= 0 && len(recent) < callTraceSize; i-- {
+		name := calls[i]
+		if name == "" {
+			name = "anonymous"
+		}
+
+		recent = append(recent, name)
+	}
+
+	return newError("maximum call depth exceeded. limit=%d, most recent calls: %s", limit, strings.Join(recent, ", "))
+}
+
+// SetContext sets the context that stops the program once it is done.
+// The context is checked at the next step of the program.
+func (rt *Runtime) SetContext(ctx context.Context) {
+	rt.ctx = ctx
+	rt.endBatch()
+}
+
+// Context gets the context that stops the program once it is done.
+func (rt *Runtime) Context() context.Context {
+	if rt.ctx == nil {
+		return context.Background()
+	}
+
+	return rt.ctx
+}
+
+// Step records that the program took a step.
+// Returns an error if the program exceeded its step limit or its context is done, which are only checked once every
+// batch of steps.
+func (rt *Runtime) Step() *Error {
+	rt.countdown--
+	if rt.countdown > 0 {
+		return nil
+	}
+
+	return rt.check()
+}
+
+// check checks the step limit and context of the program, and starts the next batch of steps if it can continue.
+// A batch never ends past the step limit, so a program stops at exactly the step it exceeds it.
+func (rt *Runtime) check() *Error {
+	rt.endBatch()
+
+	if rt.MaxSteps > 0 && rt.steps > rt.MaxSteps {
+		return &Error{Message: fmt.Sprintf("%s. limit=%d", ErrStepLimitExceeded, rt.MaxSteps), Err: ErrStepLimitExceeded}
+	}
+
+	if rt.ctx != nil {
+		if err := rt.ctx.Err(); err != nil {
+			return &Error{Message: fmt.Sprintf("program interrupted: %s", err), Err: err}
+		}
+	}
+
+	rt.batch = checkInterval
+	if rt.MaxSteps > 0 {
+		rt.batch = int(min(checkInterval, rt.MaxSteps-rt.steps+1))
+	}
+	rt.countdown = rt.batch
+
+	return nil
+}
+
+// endBatch counts the steps taken in the current batch, so the next step checks the limits and context.
+func (rt *Runtime) endBatch() {
+	rt.steps += int64(rt.batch - rt.countdown)
+	rt.batch, rt.countdown = 0, 0
+}
+
+// Steps gets the number of steps the program has taken.
+func (rt *Runtime) Steps() int64 {
+	return rt.steps + int64(rt.batch-rt.countdown)
+}
+
+// Allocate records that the program created an object. Only strings, arrays and hashes count as allocations.
+// Returns an error if the program exceeded its allocation or memory limit.
+func (rt *Runtime) Allocate(obj Object) *Error {
+	size, ok := sizeOf(obj)
+	if !ok {
+		return nil
+	}
+
+	rt.allocations++
+	if rt.MaxAllocations > 0 && rt.allocations > rt.MaxAllocations {
+		return &Error{
+			Message: fmt.Sprintf("%s. limit=%d", ErrAllocationLimitExceeded, rt.MaxAllocations),
+			Err:     ErrAllocationLimitExceeded,
+		}
+	}
+
+	rt.memory += size
+	if rt.MaxMemory > 0 && rt.memory > rt.MaxMemory {
+		return rt.memoryLimitError()
+	}
+
+	return nil
+}
+
+// reserve checks that the program can allocate size more bytes without exceeding its memory limit, so builtins can
+// refuse to create a large result before allocating it. The result is only recorded once it is allocated.
+// A negative size is a size too large to represent.
+func (rt *Runtime) reserve(size int64) *Error {
+	if rt.MaxMemory > 0 && (size < 0 || size > rt.MaxMemory-rt.memory) {
+		return rt.memoryLimitError()
+	}
+
+	return nil
+}
+
+// memoryLimitError is the error for exceeding the memory limit of the program.
+func (rt *Runtime) memoryLimitError() *Error {
+	return &Error{
+		Message: fmt.Sprintf("%s. limit=%d", ErrMemoryLimitExceeded, rt.MaxMemory),
+		Err:     ErrMemoryLimitExceeded,
+	}
+}
+
+// sizeOf gets the size of a string, array or hash. Returns false for any other object, which is not an allocation.
+func sizeOf(obj Object) (int64, bool) {
+	switch obj := obj.(type) {
+	case *String:
+		return sizeOfString(int64(len(obj.Value))), true
+	case *Array:
+		return sizeOfArray(int64(len(obj.Elements))), true
+	case *Hash:
+		return hashSize + hashPairSize*int64(obj.Len()), true
+	default:
+		return 0, false
+	}
+}
+
+// sizeOfString gets the size of a string of n bytes, or -1 if it is too large to represent.
+func sizeOfString(n int64) int64 {
+	if n < 0 || n > math.MaxInt64-stringSize {
+		return -1
+	}
+
+	return stringSize + n
+}
+
+// sizeOfArray gets the size of an array of n elements, or -1 if it is too large to represent.
+func sizeOfArray(n int64) int64 {
+	if n < 0 || n > (math.MaxInt64-arraySize)/elementSize {
+		return -1
+	}
+
+	return arraySize + elementSize*n
+}
+
+// addSizes adds sizes, or returns -1 if any of them or their sum is too large to represent.
+func addSizes(sizes ...int64) int64 {
+	var total int64
+	for _, size := range sizes {
+		if size < 0 || size > math.MaxInt64-total {
+			return -1
+		}
+		total += size
+	}
+
+	return total
+}
+
+// Allocations gets the number of strings, arrays and hashes the program has created.
+func (rt *Runtime) Allocations() int64 {
+	return rt.allocations
+}
+
+// Memory gets the approximate number of bytes the strings, arrays and hashes the program has created take up.
+func (rt *Runtime) Memory() int64 {
+	return rt.memory
+}
+
+// ResetUsage forgets the steps taken and objects allocated so far, so the next program run with the runtime has its
+// full limits.
+func (rt *Runtime) ResetUsage() {
+	rt.steps, rt.batch, rt.countdown = 0, 0, 0
+	rt.allocations, rt.memory = 0, 0
+}
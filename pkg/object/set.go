@@ -0,0 +1,100 @@
+package object
+
+import (
+	"bytes"
+	"strings"
+)
+
+// Set is a collection of unique hashable elements that keeps the order they were first added in.
+// Adding an element that is already in the set does not change its position.
+// The zero value is an empty set ready to use.
+type Set struct {
+	elements Hash // elements stores every element as both the key and value of a pair
+}
+
+func (s *Set) Type() ObjectType { return SET_OBJ }
+func (s *Set) Inspect() string {
+	var out bytes.Buffer
+
+	elements := []string{}
+	for _, pair := range s.elements.Pairs() {
+		elements = append(elements, pair.Key.Inspect())
+	}
+
+	out.WriteString("set([")
+	out.WriteString(strings.Join(elements, ", "))
+	out.WriteString("])")
+
+	return out.String()
+}
+
+// Len returns the number of elements in the set.
+func (s *Set) Len() int { return s.elements.Len() }
+
+// Elements returns the elements of the set in insertion order.
+func (s *Set) Elements() []Object {
+	elements := make([]Object, 0, s.elements.Len())
+	for _, pair := range s.elements.Pairs() {
+		elements = append(elements, pair.Key)
+	}
+
+	return elements
+}
+
+// Has checks if an element is in the set.
+func (s *Set) Has(element Hashable) bool {
+	_, ok := s.elements.Get(element)
+	return ok
+}
+
+// Add adds an element to the set.
+func (s *Set) Add(element Hashable) {
+	if !s.Has(element) {
+		s.elements.Set(element, element)
+	}
+}
+
+// Remove removes an element from the set.
+// Returns true if the element was present.
+func (s *Set) Remove(element Hashable) bool {
+	return s.elements.Delete(element)
+}
+
+// Copy creates a shallow copy of the set.
+func (s *Set) Copy() *Set {
+	return &Set{elements: *s.elements.Copy()}
+}
+
+// Union creates a new set with the elements that are in either set.
+func (s *Set) Union(other *Set) *Set {
+	union := s.Copy()
+	for _, pair := range other.elements.Pairs() {
+		union.Add(pair.Key.(Hashable))
+	}
+
+	return union
+}
+
+// Intersection creates a new set with the elements that are in both sets.
+func (s *Set) Intersection(other *Set) *Set {
+	intersection := &Set{}
+	for _, pair := range s.elements.Pairs() {
+		if element := pair.Key.(Hashable); other.Has(element) {
+			intersection.Add(element)
+		}
+	}
+
+	return intersection
+}
+
+// Difference creates a new set with the elements that are in this set but not the other.
+func (s *Set) Difference(other *Set) *Set {
+	difference := &Set{}
+	for _, pair := range s.elements.Pairs() {
+		if element := pair.Key.(Hashable); !other.Has(element) {
+			difference.Add(element)
+		}
+	}
+
+	return difference
+}
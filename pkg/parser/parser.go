@@ -4,6 +4,7 @@ package parser
 import (
 	"fmt"
 	"strconv"
+	"strings"
 
 	"github.com/grantwforsythe/monkeylang/pkg/ast"
 	"github.com/grantwforsythe/monkeylang/pkg/lexer"
@@ -16,17 +17,19 @@ import (
 const (
 	_ int = iota
 	LOWEST
+	ASSIGN      // x = y
 	EQUALS      // ==
 	LESSGREATER // > or <
 	SUM         // +
 	PRODUCT     // *
 	PREFIX      // -X or !X
 	CALL        // foobar(baz)
-	INDEX       // array[index]
+	INDEX       // array[index] or namespace.member
 )
 
 // Map of tokens to their respective precedence, i.e. BEDMAS
 var precedences = map[token.TokenType]int{
+	token.ASSIGN:   ASSIGN,
 	token.EQ:       EQUALS,
 	token.NOT_EQ:   EQUALS,
 	token.LT:       LESSGREATER,
@@ -37,6 +40,7 @@ var precedences = map[token.TokenType]int{
 	token.SLASH:    PRODUCT,
 	token.LPAREN:   CALL,
 	token.LBRACKET: INDEX,
+	token.DOT:      INDEX,
 }
 
 // TODO: Replace errorString with general errors.New(). A custom error type would only be needed when we want to wrap errors.
@@ -75,13 +79,16 @@ func New(l *lexer.Lexer) *Parser {
 	p.prefixParseFns = make(map[token.TokenType]prefixParseFn)
 	p.registerPrefix(token.IDENT, p.parseIdentifier)
 	p.registerPrefix(token.STRING, p.parseStringLiteral)
+	p.registerPrefix(token.TEMPLATE, p.parseTemplateLiteral)
 	p.registerPrefix(token.INT, p.parseIntegerLiteral)
+	p.registerPrefix(token.FLOAT, p.parseFloatLiteral)
 	p.registerPrefix(token.BANG, p.parsePrefixExpression)
 	p.registerPrefix(token.MINUS, p.parsePrefixExpression)
 	p.registerPrefix(token.TRUE, p.parseBooleanExpression)
 	p.registerPrefix(token.FALSE, p.parseBooleanExpression)
 	p.registerPrefix(token.LPAREN, p.parseGroupExpression)
 	p.registerPrefix(token.IF, p.parseIfExpression)
+	p.registerPrefix(token.WHILE, p.parseWhileExpression)
 	p.registerPrefix(token.FUNCTION, p.parseFunctionLiteral)
 	p.registerPrefix(token.STRING, p.parseStringLiteral)
 	p.registerPrefix(token.LBRACKET, p.parseArrayLiteral)
@@ -89,6 +96,7 @@ func New(l *lexer.Lexer) *Parser {
 	p.registerPrefix(token.MACRO, p.parseMacroLiteral)
 
 	p.infixParseFns = make(map[token.TokenType]infixParseFn)
+	p.registerInfix(token.ASSIGN, p.parseAssignExpression)
 	p.registerInfix(token.PLUS, p.parseInfixExpression)
 	p.registerInfix(token.MINUS, p.parseInfixExpression)
 	p.registerInfix(token.ASTERISK, p.parseInfixExpression)
@@ -99,6 +107,7 @@ func New(l *lexer.Lexer) *Parser {
 	p.registerInfix(token.GT, p.parseInfixExpression)
 	p.registerInfix(token.LPAREN, p.parseCallExpression)
 	p.registerInfix(token.LBRACKET, p.parseIndexExpression)
+	p.registerInfix(token.DOT, p.parseMemberExpression)
 
 	return p
 }
@@ -210,6 +219,29 @@ func (p *Parser) parseIfExpression() ast.Expression {
 	return expression
 }
 
+func (p *Parser) parseWhileExpression() ast.Expression {
+	expression := &ast.WhileExpression{Token: p.currToken}
+
+	if !p.expectPeek(token.LPAREN) {
+		return nil
+	}
+
+	p.nextToken()
+	expression.Condition = p.parseExpression(LOWEST)
+
+	if !p.expectPeek(token.RPAREN) {
+		return nil
+	}
+
+	if !p.expectPeek(token.LBRACE) {
+		return nil
+	}
+
+	expression.Body = p.parseBlockStatement()
+
+	return expression
+}
+
 func (p *Parser) parseBlockStatement() *ast.BlockStatement {
 	block := &ast.BlockStatement{Token: p.currToken}
 	block.Statements = []ast.Statement{}
@@ -223,6 +255,14 @@ func (p *Parser) parseBlockStatement() *ast.BlockStatement {
 		if stmt != nil {
 			block.Statements = append(block.Statements, stmt)
 		}
+
+		// Modules are imported and exported once, so imports and exports cannot be nested in a block
+		switch stmt.(type) {
+		case *ast.ImportStatement, *ast.ExportStatement:
+			msg := errorString{s: fmt.Sprintf("%s is only allowed at the top level of a program", stmt.TokenLiteral())}
+			p.errors = append(p.errors, msg)
+		}
+
 		p.nextToken()
 	}
 
@@ -249,6 +289,120 @@ func (p *Parser) parseStringLiteral() ast.Expression {
 	return &ast.StringLiteral{Token: p.currToken, Value: p.currToken.Literal}
 }
 
+// parseTemplateLiteral parses a string literal that interpolates expressions into the text between the expressions and
+// the expressions themselves.
+func (p *Parser) parseTemplateLiteral() ast.Expression {
+	template := &ast.TemplateLiteral{Token: p.currToken}
+
+	// text is the text since the last interpolated expression
+	var text strings.Builder
+	literal := p.currToken.Literal
+	for {
+		start := strings.Index(literal, "${")
+		if start < 0 {
+			text.WriteString(literal)
+			break
+		}
+
+		// `$${` is an escaped `${`
+		if start > 0 && literal[start-1] == '$' {
+			text.WriteString(literal[:start-1])
+			text.WriteString("${")
+			literal = literal[start+2:]
+			continue
+		}
+
+		text.WriteString(literal[:start])
+		if text.Len() > 0 {
+			template.Parts = append(template.Parts, newStringLiteral(text.String()))
+			text.Reset()
+		}
+
+		end := closingBrace(literal, start+2)
+		if end < 0 {
+			msg := errorString{s: fmt.Sprintf("unterminated interpolation in %q", p.currToken.Literal)}
+			p.errors = append(p.errors, msg)
+			return nil
+		}
+
+		expression := p.parseInterpolation(literal[start+2 : end])
+		if expression == nil {
+			return nil
+		}
+		template.Parts = append(template.Parts, expression)
+
+		literal = literal[end+1:]
+	}
+
+	if text.Len() > 0 {
+		template.Parts = append(template.Parts, newStringLiteral(text.String()))
+	}
+
+	return template
+}
+
+// parseInterpolation parses the source of an expression interpolated into a string literal.
+// The source must contain exactly one expression.
+func (p *Parser) parseInterpolation(source string) ast.Expression {
+	if strings.TrimSpace(source) == "" {
+		msg := errorString{s: fmt.Sprintf("empty interpolation in %q", p.currToken.Literal)}
+		p.errors = append(p.errors, msg)
+		return nil
+	}
+
+	sub := New(lexer.New(source))
+	program := sub.ParseProgram()
+	if len(sub.errors) != 0 {
+		p.errors = append(p.errors, sub.errors...)
+		return nil
+	}
+
+	if len(program.Statements) != 1 {
+		msg := errorString{s: fmt.Sprintf("interpolation must be a single expression. got=%q", source)}
+		p.errors = append(p.errors, msg)
+		return nil
+	}
+
+	stmt, ok := program.Statements[0].(*ast.ExpressionStatement)
+	if !ok {
+		msg := errorString{s: fmt.Sprintf("interpolation must be a single expression. got=%q", source)}
+		p.errors = append(p.errors, msg)
+		return nil
+	}
+
+	return stmt.Expression
+}
+
+// newStringLiteral creates a string literal for the text of a template literal.
+func newStringLiteral(text string) *ast.StringLiteral {
+	return &ast.StringLiteral{Token: token.Token{Type: token.STRING, Literal: text}, Value: text}
+}
+
+// closingBrace finds the brace that closes an interpolation, skipping over nested braces and string literals.
+// Returns -1 if the interpolation is not closed.
+func closingBrace(literal string, start int) int {
+	depth := 1
+	for i := start; i < len(literal); i++ {
+		switch literal[i] {
+		case '"':
+			end := strings.IndexByte(literal[i+1:], '"')
+			if end < 0 {
+				return -1
+			}
+			i += end + 1
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+
+	return -1
+}
+
 func (p *Parser) parseArrayLiteral() ast.Expression {
 	array := &ast.ArrayLiteral{Token: p.currToken}
 	array.Elements = p.parseExpressionList(token.RBRACKET)
@@ -279,6 +433,24 @@ func (p *Parser) parseInfixExpression(left ast.Expression) ast.Expression {
 	return exp
 }
 
+// parseAssignExpression parses an assignment to an identifier.
+// Assignment is right associative so `a = b = 1` assigns 1 to both.
+func (p *Parser) parseAssignExpression(left ast.Expression) ast.Expression {
+	name, ok := left.(*ast.Identifier)
+	if !ok {
+		msg := errorString{s: fmt.Sprintf("cannot assign to %s", left.String())}
+		p.errors = append(p.errors, msg)
+		return nil
+	}
+
+	exp := &ast.AssignExpression{Token: p.currToken, Name: name}
+
+	p.nextToken()
+	exp.Value = p.parseExpression(ASSIGN - 1)
+
+	return exp
+}
+
 func (p *Parser) parseIndexExpression(left ast.Expression) ast.Expression {
 	exp := &ast.IndexEpression{Token: p.currToken, Left: left}
 
@@ -292,6 +464,18 @@ func (p *Parser) parseIndexExpression(left ast.Expression) ast.Expression {
 	return exp
 }
 
+func (p *Parser) parseMemberExpression(left ast.Expression) ast.Expression {
+	exp := &ast.MemberExpression{Token: p.currToken, Left: left}
+
+	if !p.expectPeek(token.IDENT) {
+		return nil
+	}
+
+	exp.Property = &ast.Identifier{Token: p.currToken, Value: p.currToken.Literal}
+
+	return exp
+}
+
 func (p *Parser) registerPrefix(tokenType token.TokenType, fn prefixParseFn) {
 	p.prefixParseFns[tokenType] = fn
 }
@@ -344,6 +528,41 @@ func (p *Parser) parseLetStatement() *ast.LetStatement {
 
 	stmt.Value = p.parseExpression(LOWEST)
 
+	if fn, ok := stmt.Value.(*ast.FunctionLiteral); ok {
+		fn.Name = stmt.Name.Value
+	}
+
+	// Skip optional semicolon
+	if p.peekToken.Type == token.SEMICOLON {
+		p.nextToken()
+	}
+
+	return stmt
+}
+
+func (p *Parser) parseImportStatement() ast.Statement {
+	stmt := &ast.ImportStatement{Token: p.currToken}
+
+	if !p.expectPeek(token.STRING) {
+		return nil
+	}
+
+	stmt.Path = &ast.StringLiteral{Token: p.currToken, Value: p.currToken.Literal}
+
+	// `as` is not a keyword so that it can still be used as an identifier
+	if p.peekToken.Type != token.IDENT || p.peekToken.Literal != "as" {
+		msg := errorString{s: fmt.Sprintf("expected next token to be as. got=%s", p.peekToken.Literal)}
+		p.errors = append(p.errors, msg)
+		return nil
+	}
+	p.nextToken()
+
+	if !p.expectPeek(token.IDENT) {
+		return nil
+	}
+
+	stmt.Name = &ast.Identifier{Token: p.currToken, Value: p.currToken.Literal}
+
 	// Skip optional semicolon
 	if p.peekToken.Type == token.SEMICOLON {
 		p.nextToken()
@@ -352,6 +571,22 @@ func (p *Parser) parseLetStatement() *ast.LetStatement {
 	return stmt
 }
 
+func (p *Parser) parseExportStatement() ast.Statement {
+	stmt := &ast.ExportStatement{Token: p.currToken}
+
+	if !p.expectPeek(token.LET) {
+		return nil
+	}
+
+	let := p.parseLetStatement()
+	if let == nil {
+		return nil
+	}
+	stmt.Statement = let
+
+	return stmt
+}
+
 func (p *Parser) parseReturnStatement() *ast.ReturnStatement {
 	stmt := &ast.ReturnStatement{Token: p.currToken}
 
@@ -382,6 +617,21 @@ func (p *Parser) parseIntegerLiteral() ast.Expression {
 	return stmt
 }
 
+func (p *Parser) parseFloatLiteral() ast.Expression {
+	stmt := &ast.FloatLiteral{Token: p.currToken}
+
+	value, err := strconv.ParseFloat(p.currToken.Literal, 64)
+	if err != nil {
+		msg := errorString{s: fmt.Sprintf("could not parse %s as float", p.currToken.Literal)}
+		p.errors = append(p.errors, msg)
+		return nil
+	}
+
+	stmt.Value = value
+
+	return stmt
+}
+
 func (p *Parser) parseExpression(precedence int) ast.Expression {
 	prefix := p.prefixParseFns[p.currToken.Type]
 	if prefix == nil {
@@ -422,6 +672,10 @@ func (p *Parser) parseStatement() ast.Statement {
 		return p.parseLetStatement()
 	case token.RETURN:
 		return p.parseReturnStatement()
+	case token.IMPORT:
+		return p.parseImportStatement()
+	case token.EXPORT:
+		return p.parseExportStatement()
 	default:
 		return p.parseExpressionStatement()
 	}
@@ -473,7 +727,7 @@ func (p *Parser) parseExpressionList(end token.TokenType) []ast.Expression {
 
 func (p *Parser) parseHashLiteral() ast.Expression {
 	hash := &ast.HashLiteral{Token: p.currToken}
-	hash.Pairs = make(map[ast.Expression]ast.Expression)
+	hash.Pairs = []ast.HashPair{}
 
 	for p.peekToken.Type != token.RBRACE {
 		// Skip over '{' initially and ',' after each iteration
@@ -488,7 +742,7 @@ func (p *Parser) parseHashLiteral() ast.Expression {
 		p.nextToken()
 		value := p.parseExpression(LOWEST)
 
-		hash.Pairs[key] = value
+		hash.Pairs = append(hash.Pairs, ast.HashPair{Key: key, Value: value})
 
 		// Ensure that we are not at the end of the hash literal before ensuring that the next token is a comma
 		if p.peekToken.Type != token.RBRACE && !p.expectPeek(token.COMMA) {
@@ -121,6 +121,33 @@ func TestParsingIntegerLiteralExpression(t *testing.T) {
 	testIntegerLiteral(t, stmt.Expression, 5)
 }
 
+func TestParsingFloatLiteralExpression(t *testing.T) {
+	input := "3.25;"
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	if len(program.Statements) != 1 {
+		t.Fatalf("program does not have enough statements. got=%d", len(program.Statements))
+	}
+
+	stmt := program.Statements[0].(*ast.ExpressionStatement)
+	literal, ok := stmt.Expression.(*ast.FloatLiteral)
+	if !ok {
+		t.Fatalf("exp is not of type *ast.FloatLiteral. got=%T", stmt.Expression)
+	}
+
+	if literal.Value != 3.25 {
+		t.Errorf("literal.Value is not %f. got=%f", 3.25, literal.Value)
+	}
+
+	if literal.TokenLiteral() != "3.25" {
+		t.Errorf("literal.TokenLiteral is not %q. got=%q", "3.25", literal.TokenLiteral())
+	}
+}
+
 func TestParsingPrefixExpression(t *testing.T) {
 	prefixTest := []struct {
 		input    string
@@ -361,6 +388,23 @@ func TestParsingOperatorPrecedence(t *testing.T) {
 			"add(a * b[2], b[1], 2 * [1, 2][1])",
 			"add((a * (b[2])), (b[1]), (2 * ([1, 2][1])))",
 		},
+		// Member access binds as tightly as an index expression.
+		{
+			"-math.abs(x) * 2",
+			"((-(math.abs)(x)) * 2)",
+		},
+		{
+			"a.b.c[0]",
+			"(((a.b).c)[0])",
+		},
+		{
+			"a = b = c + 1",
+			"(a = (b = (c + 1)))",
+		},
+		{
+			"a = b == c",
+			"(a = (b == c))",
+		},
 	}
 
 	for _, tt := range tests {
@@ -491,6 +535,107 @@ func TestParsingIfElseExpression(t *testing.T) {
 	}
 }
 
+func TestParsingWhileExpression(t *testing.T) {
+	input := "while (x < y) { x = x + 1; }"
+
+	p := New(lexer.New(input))
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	if len(program.Statements) != 1 {
+		t.Fatalf("program does not have enough statements. got=%d", len(program.Statements))
+	}
+
+	stmt, ok := program.Statements[0].(*ast.ExpressionStatement)
+	if !ok {
+		t.Fatalf("program.Statement[0] is not of type ast.ExpressionStatement. got=%T", program.Statements[0])
+	}
+
+	exp, ok := stmt.Expression.(*ast.WhileExpression)
+	if !ok {
+		t.Fatalf("stmt.Expression is not of type ast.WhileExpression. got=%T", stmt.Expression)
+	}
+
+	if !testInfixExpression(t, exp.Condition, "<", "x", "y") {
+		return
+	}
+
+	if len(exp.Body.Statements) != 1 {
+		t.Fatalf("exp.Body.Statements does not have enough statements. got=%d", len(exp.Body.Statements))
+	}
+
+	body, ok := exp.Body.Statements[0].(*ast.ExpressionStatement)
+	if !ok {
+		t.Fatalf("exp.Body.Statements[0] is not of type ast.ExpressionStatement. got=%T", exp.Body.Statements[0])
+	}
+
+	assign, ok := body.Expression.(*ast.AssignExpression)
+	if !ok {
+		t.Fatalf("body.Expression is not of type ast.AssignExpression. got=%T", body.Expression)
+	}
+
+	if !testIdentifier(t, assign.Name, "x") {
+		return
+	}
+
+	testInfixExpression(t, assign.Value, "+", "x", 1)
+}
+
+func TestParsingAssignmentErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"1 = 2", "cannot assign to 1"},
+		{"a[0] = 2", "cannot assign to (a[0])"},
+		{"while x { x }", "expected next token to be (. got=IDENT"},
+	}
+
+	for _, tt := range tests {
+		p := New(lexer.New(tt.input))
+		p.ParseProgram()
+
+		errors := p.Errors()
+		if len(errors) == 0 {
+			t.Errorf("expected parser errors for %s", tt.input)
+			continue
+		}
+
+		if errors[0].Error() != tt.expected {
+			t.Errorf("wrong error for %s. want=%q, got=%q", tt.input, tt.expected, errors[0].Error())
+		}
+	}
+}
+
+func TestParsingFunctionLiteralName(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let add = fn(a, b) { a + b };", "add"},
+		{"export let add = fn(a, b) { a + b };", "add"},
+		{"let add = 1 + fn() { 1 }();", ""},
+	}
+
+	for _, tt := range tests {
+		p := New(lexer.New(tt.input))
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		var name string
+		ast.Modify(program, func(node ast.Node) ast.Node {
+			if fn, ok := node.(*ast.FunctionLiteral); ok {
+				name = fn.Name
+			}
+			return node
+		})
+
+		if name != tt.expected {
+			t.Errorf("%s: wrong function name. want=%q, got=%q", tt.input, tt.expected, name)
+		}
+	}
+}
+
 func TestParsingFunctionParameter(t *testing.T) {
 	tests := []struct {
 		input          string
@@ -684,6 +829,133 @@ func TestParsingStringLiteral(t *testing.T) {
 	}
 }
 
+func TestParsingTemplateLiteral(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{`"Hello ${name}!"`, []string{"Hello ", "name", "!"}},
+		{`"${a + b}"`, []string{"(a + b)"}},
+		{`"${a}${b}"`, []string{"a", "b"}},
+		{`"${ {"x": 1}["x"] } and ${"}"}"`, []string{"({x:1}[x])", " and ", "}"}},
+		{`"${"${nested}"}"`, []string{"${nested}"}},
+		{`"$${a} ${b}"`, []string{"${a} ", "b"}},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		stmt := program.Statements[0].(*ast.ExpressionStatement)
+		template, ok := stmt.Expression.(*ast.TemplateLiteral)
+		if !ok {
+			t.Fatalf("exp not *ast.TemplateLiteral. got=%T", stmt.Expression)
+		}
+
+		if len(template.Parts) != len(tt.expected) {
+			t.Fatalf("template.Parts has wrong length. want=%d, got=%d", len(tt.expected), len(template.Parts))
+		}
+
+		for i, part := range template.Parts {
+			if part.String() != tt.expected[i] {
+				t.Errorf("template.Parts[%d] wrong. want=%q, got=%q", i, tt.expected[i], part.String())
+			}
+		}
+	}
+}
+
+func TestParsingTemplateLiteralErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`"Hello ${}"`, `empty interpolation in "Hello ${}"`},
+		{`"${let x = 1}"`, `interpolation must be a single expression. got="let x = 1"`},
+		{`"${a; b}"`, `interpolation must be a single expression. got="a; b"`},
+		{`"${(}"`, "no prefix parse function for EOF"},
+	}
+
+	for _, tt := range tests {
+		p := New(lexer.New(tt.input))
+		p.ParseProgram()
+
+		errors := p.Errors()
+		if len(errors) == 0 {
+			t.Errorf("expected parser errors for %s", tt.input)
+			continue
+		}
+
+		if errors[0].Error() != tt.expected {
+			t.Errorf("wrong error for %s. want=%q, got=%q", tt.input, tt.expected, errors[0].Error())
+		}
+	}
+}
+
+func TestParsingImportAndExportStatements(t *testing.T) {
+	input := `import "lib/util.mk" as util; export let x = util.max(1, 2);`
+
+	p := New(lexer.New(input))
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	if len(program.Statements) != 2 {
+		t.Fatalf("program.Statements does not contain 2 statements. got=%d", len(program.Statements))
+	}
+
+	imp, ok := program.Statements[0].(*ast.ImportStatement)
+	if !ok {
+		t.Fatalf("stmt is not *ast.ImportStatement. got=%T", program.Statements[0])
+	}
+
+	if imp.Path.Value != "lib/util.mk" || imp.Name.Value != "util" {
+		t.Errorf("import has wrong path or name. got=%q, %q", imp.Path.Value, imp.Name.Value)
+	}
+
+	export, ok := program.Statements[1].(*ast.ExportStatement)
+	if !ok {
+		t.Fatalf("stmt is not *ast.ExportStatement. got=%T", program.Statements[1])
+	}
+
+	if !testLetStatement(t, export.Statement, "x") {
+		return
+	}
+
+	if program.String() != `import "lib/util.mk" as util;export let x = (util.max)(1, 2);` {
+		t.Errorf("program.String() wrong. got=%q", program.String())
+	}
+}
+
+func TestParsingImportAndExportErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`import util;`, "expected next token to be STRING. got=IDENT"},
+		{`import "util.mk" util;`, "expected next token to be as. got=util"},
+		{`import "util.mk" as;`, "expected next token to be IDENT. got=;"},
+		{`export x;`, "expected next token to be LET. got=IDENT"},
+		{`fn() { import "util.mk" as util; }`, "import is only allowed at the top level of a program"},
+		{`if (true) { export let x = 1; }`, "export is only allowed at the top level of a program"},
+	}
+
+	for _, tt := range tests {
+		p := New(lexer.New(tt.input))
+		p.ParseProgram()
+
+		errors := p.Errors()
+		if len(errors) == 0 {
+			t.Errorf("expected parser errors for %s", tt.input)
+			continue
+		}
+
+		if errors[0].Error() != tt.expected {
+			t.Errorf("wrong error for %s. want=%q, got=%q", tt.input, tt.expected, errors[0].Error())
+		}
+	}
+}
+
 func TestParsingArrayLiteral(t *testing.T) {
 	input := "[1, 2 * 2, 3 + 3]"
 
@@ -750,6 +1022,29 @@ func TestParsingIndexExpression(t *testing.T) {
 	}
 }
 
+func TestParsingMemberExpression(t *testing.T) {
+	input := "math.abs"
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	stmt := program.Statements[0].(*ast.ExpressionStatement)
+	member, ok := stmt.Expression.(*ast.MemberExpression)
+	if !ok {
+		t.Fatalf("exp is not of type *ast.MemberExpression. got=%T", stmt.Expression)
+	}
+
+	if !testIdentifier(t, member.Left, "math") {
+		return
+	}
+
+	if !testIdentifier(t, member.Property, "abs") {
+		return
+	}
+}
+
 func TestParsingHashLiteralStringKeys(t *testing.T) {
 	input := `{"a": 1, "b": 2, "c": 3}`
 
@@ -770,7 +1065,8 @@ func TestParsingHashLiteralStringKeys(t *testing.T) {
 		"c": 3,
 	}
 
-	for key, value := range hash.Pairs {
+	for _, pair := range hash.Pairs {
+		key, value := pair.Key, pair.Value
 		literal, ok := key.(*ast.StringLiteral)
 		if !ok {
 			t.Errorf("key is not of type *ast.StringLiteral. got=%T", key)
@@ -781,6 +1077,32 @@ func TestParsingHashLiteralStringKeys(t *testing.T) {
 	}
 }
 
+func TestParsingHashLiteralKeepsSourceOrder(t *testing.T) {
+	input := `{"c": 3, "a": 1, "b": 2}`
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	stmt := program.Statements[0].(*ast.ExpressionStatement)
+	hash, ok := stmt.Expression.(*ast.HashLiteral)
+	if !ok {
+		t.Fatalf("hash is not of type *ast.HashLiteral. got=%T", stmt.Expression)
+	}
+
+	expected := []string{"c", "a", "b"}
+	if len(hash.Pairs) != len(expected) {
+		t.Fatalf("hash.Pairs has wrong length. got=%d", len(hash.Pairs))
+	}
+
+	for i, pair := range hash.Pairs {
+		if pair.Key.String() != expected[i] {
+			t.Errorf("key %d is not %q. got=%q", i, expected[i], pair.Key.String())
+		}
+	}
+}
+
 func TestParsingHashLiteralIntegerKeys(t *testing.T) {
 	input := `{1: 1, 2: 2, 3: 3}`
 
@@ -801,7 +1123,8 @@ func TestParsingHashLiteralIntegerKeys(t *testing.T) {
 		3: 3,
 	}
 
-	for key, value := range hash.Pairs {
+	for _, pair := range hash.Pairs {
+		key, value := pair.Key, pair.Value
 		literal, ok := key.(*ast.IntegerLiteral)
 		if !ok {
 			t.Errorf("key is not of type *ast.IntegerLiteral. got=%T", key)
@@ -832,7 +1155,8 @@ func TestParsingHashLiteralBooleanKeys(t *testing.T) {
 		false: 2,
 	}
 
-	for key, value := range hash.Pairs {
+	for _, pair := range hash.Pairs {
+		key, value := pair.Key, pair.Value
 		literal, ok := key.(*ast.BooleanExpression)
 		if !ok {
 			t.Errorf("key is not of type *ast.BooleanExpression. got=%T", key)
@@ -873,7 +1197,8 @@ func TestParsingHashLiteralsWithExpressions(t *testing.T) {
 		},
 	}
 
-	for key, value := range hash.Pairs {
+	for _, pair := range hash.Pairs {
+		key, value := pair.Key, pair.Value
 		literal, ok := key.(*ast.StringLiteral)
 		if !ok {
 			t.Errorf("key is not ast.StringLiteral. got=%T", key)
@@ -0,0 +1,34 @@
+let identity = fn(x) { x };
+
+let default = fn(value, fallback) {
+	if (is_null(value)) { fallback } else { value }
+};
+
+let each = fn(array, f) {
+	if (len(array) > 0) {
+		f(first(array));
+		each(rest(array), f)
+	}
+};
+
+let find = fn(array, predicate) {
+	if (len(array) > 0) {
+		if (predicate(first(array))) { first(array) } else { find(rest(array), predicate) }
+	}
+};
+
+let any = fn(array, predicate) {
+	if (len(array) == 0) {
+		false
+	} else {
+		if (predicate(first(array))) { true } else { any(rest(array), predicate) }
+	}
+};
+
+let all = fn(array, predicate) {
+	if (len(array) == 0) {
+		true
+	} else {
+		if (predicate(first(array))) { all(rest(array), predicate) } else { false }
+	}
+};
@@ -0,0 +1,124 @@
+// Package prelude contains the Monkey source files that are evaluated before a program runs, defining the functions
+// that every program can use.
+//
+// The standard prelude defines:
+//   - identity(x) returns x.
+//   - default(value, fallback) returns fallback if value is null and value otherwise.
+//   - each(array, f) calls f with every element of array.
+//   - find(array, predicate) returns the first element for which predicate returns a truthy value, or null.
+//   - any(array, predicate) checks if predicate returns a truthy value for any element.
+//   - all(array, predicate) checks if predicate returns a truthy value for every element.
+package prelude
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+
+	"github.com/grantwforsythe/monkeylang/pkg/compiler"
+	"github.com/grantwforsythe/monkeylang/pkg/evaluator"
+	"github.com/grantwforsythe/monkeylang/pkg/object"
+	"github.com/grantwforsythe/monkeylang/pkg/vm"
+)
+
+//go:embed lib/*.mk
+var lib embed.FS
+
+// File is a Monkey source file of a prelude.
+type File struct {
+	Name   string // Name identifies the file in errors.
+	Source string
+}
+
+// Standard gets the files of the standard prelude bundled with the interpreter.
+func Standard() []File {
+	names, err := fs.Glob(lib, "lib/*.mk")
+	if err != nil {
+		panic(err)
+	}
+
+	files, err := ReadFiles(lib, names...)
+	if err != nil {
+		panic(err)
+	}
+
+	return files
+}
+
+// ReadFiles reads prelude files from a file system, such as a host's own files to evaluate after the standard prelude.
+func ReadFiles(fsys fs.FS, names ...string) ([]File, error) {
+	files := make([]File, len(names))
+	for i, name := range names {
+		source, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return nil, err
+		}
+
+		files[i] = File{Name: path.Base(name), Source: string(source)}
+	}
+
+	return files, nil
+}
+
+// Eval evaluates prelude files in order into an environment, so the programs evaluated in the environment can use the
+// bindings they define.
+func Eval(env *object.Environment, files ...File) error {
+	for _, file := range files {
+		program, err := object.ParseSource(file.Source)
+		if err != nil {
+			return fmt.Errorf("%s: %s", file.Name, err.Message)
+		}
+
+		if err, ok := evaluator.Eval(program, env).(*object.Error); ok {
+			return fmt.Errorf("%s: %s", file.Name, err.Message)
+		}
+	}
+
+	return nil
+}
+
+// Compiled is a prelude compiled into bytecode.
+// It is compiled once and can be run before any number of programs.
+type Compiled struct {
+	bytecode    *compiler.ByteCode
+	symbolTable *compiler.SymbolTable
+}
+
+// Compile compiles prelude files in order into bytecode.
+func Compile(files ...File) (*Compiled, error) {
+	comp := compiler.New()
+	for _, file := range files {
+		program, err := object.ParseSource(file.Source)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", file.Name, err.Message)
+		}
+
+		if err := comp.Compile(program); err != nil {
+			return nil, fmt.Errorf("%s: %s", file.Name, err)
+		}
+	}
+
+	return &Compiled{bytecode: comp.ByteCode(), symbolTable: comp.SymbolTable()}, nil
+}
+
+// Compiler creates a compiler for a program that can use the bindings of the prelude.
+// The program must run with the globals returned by Run.
+func (c *Compiled) Compiler(loader object.ModuleLoader) *compiler.Compiler {
+	// The program adds its own constants and bindings, which must not leak into the next program
+	constants := make([]object.Object, len(c.bytecode.Constants))
+	copy(constants, c.bytecode.Constants)
+
+	return compiler.NewWithState(c.symbolTable.Copy(), constants, loader)
+}
+
+// Run runs the prelude in a virtual machine whose builtins share a runtime.
+// Returns the globals that hold the bindings of the prelude.
+func (c *Compiled) Run(runtime *object.Runtime) ([]object.Object, error) {
+	machine := vm.NewWithRuntime(c.bytecode, runtime)
+	if err := machine.Run(); err != nil {
+		return nil, err
+	}
+
+	return machine.Globals(), nil
+}
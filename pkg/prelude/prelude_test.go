@@ -0,0 +1,137 @@
+package prelude
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/grantwforsythe/monkeylang/pkg/ast"
+	"github.com/grantwforsythe/monkeylang/pkg/evaluator"
+	"github.com/grantwforsythe/monkeylang/pkg/lexer"
+	"github.com/grantwforsythe/monkeylang/pkg/object"
+	"github.com/grantwforsythe/monkeylang/pkg/parser"
+	"github.com/grantwforsythe/monkeylang/pkg/vm"
+)
+
+var standardTests = []struct {
+	input    string
+	expected string
+}{
+	{"identity(5)", "5"},
+	{"default(first([]), 1)", "1"},
+	{"default(2, 1)", "2"},
+	{"each([1, 2], fn(x) { x })", "null"},
+	{"find([1, 2, 3], fn(x) { x > 1 })", "2"},
+	{"find([1, 2, 3], fn(x) { x > 3 })", "null"},
+	{"any([1, 2, 3], fn(x) { x == 2 })", "true"},
+	{"any([], fn(x) { true })", "false"},
+	{"all([1, 2, 3], fn(x) { x > 0 })", "true"},
+	{"all([1, 2, 3], fn(x) { x > 1 })", "false"},
+}
+
+func TestStandardEval(t *testing.T) {
+	for _, tt := range standardTests {
+		env := object.NewEnvironment()
+		if err := Eval(env, Standard()...); err != nil {
+			t.Fatalf("could not evaluate the standard prelude: %s", err)
+		}
+
+		result := evaluator.Eval(parse(tt.input), env)
+		if result == nil || result.Inspect() != tt.expected {
+			t.Errorf("%s: wrong result. got=%v, want=%s", tt.input, result, tt.expected)
+		}
+	}
+}
+
+func TestStandardCompiled(t *testing.T) {
+	compiled, err := Compile(Standard()...)
+	if err != nil {
+		t.Fatalf("could not compile the standard prelude: %s", err)
+	}
+
+	for _, tt := range standardTests {
+		comp := compiled.Compiler(nil)
+		if err := comp.Compile(parse(tt.input)); err != nil {
+			t.Fatalf("%s: compiler error: %s", tt.input, err)
+		}
+
+		rt := object.NewRuntime()
+		globals, err := compiled.Run(rt)
+		if err != nil {
+			t.Fatalf("could not run the standard prelude: %s", err)
+		}
+
+		machine := vm.NewWithGlobals(comp.ByteCode(), rt, globals)
+		if err := machine.Run(); err != nil {
+			t.Fatalf("%s: vm error: %s", tt.input, err)
+		}
+
+		result := machine.LastPoppedStackElem()
+		if result == nil || result.Inspect() != tt.expected {
+			t.Errorf("%s: wrong result. got=%v, want=%s", tt.input, result, tt.expected)
+		}
+	}
+}
+
+func TestCompiledDoesNotLeakBetweenPrograms(t *testing.T) {
+	compiled, err := Compile(File{Name: "one.mk", Source: "let one = 1;"})
+	if err != nil {
+		t.Fatalf("compile error: %s", err)
+	}
+
+	first := compiled.Compiler(nil)
+	if err := first.Compile(parse("let two = 2; one + two")); err != nil {
+		t.Fatalf("compiler error: %s", err)
+	}
+
+	second := compiled.Compiler(nil)
+	if err := second.Compile(parse("two")); err == nil || err.Error() != "undefined variable two" {
+		t.Errorf("binding leaked into the next program. got=%v", err)
+	}
+
+	if n := len(second.ByteCode().Constants); n != 1 {
+		t.Errorf("constants leaked into the next program. got=%d, want=1", n)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	tests := []struct {
+		file     File
+		expected string
+	}{
+		{File{Name: "parse.mk", Source: "let = 1;"}, "parse.mk: could not parse source: expected next token to be IDENT. got==; no prefix parse function for ="},
+		{File{Name: "eval.mk", Source: "1 + true"}, "eval.mk: type mismatch: INTEGER + BOOLEAN"},
+	}
+
+	for _, tt := range tests {
+		err := Eval(object.NewEnvironment(), tt.file)
+		if err == nil || err.Error() != tt.expected {
+			t.Errorf("wrong error. got=%v, want=%q", err, tt.expected)
+		}
+	}
+
+	_, err := Compile(File{Name: "compile.mk", Source: "undefined"})
+	if err == nil || err.Error() != "compile.mk: undefined variable undefined" {
+		t.Errorf("wrong compile error. got=%v", err)
+	}
+}
+
+func TestReadFiles(t *testing.T) {
+	fsys := fstest.MapFS{"lib/host.mk": {Data: []byte("let host = true;")}}
+
+	files, err := ReadFiles(fsys, "lib/host.mk")
+	if err != nil {
+		t.Fatalf("could not read files: %s", err)
+	}
+
+	if len(files) != 1 || files[0] != (File{Name: "host.mk", Source: "let host = true;"}) {
+		t.Errorf("wrong files. got=%+v", files)
+	}
+
+	if _, err := ReadFiles(fsys, "missing.mk"); err == nil {
+		t.Errorf("expected an error for a missing file")
+	}
+}
+
+func parse(input string) *ast.Program {
+	return parser.New(lexer.New(input)).ParseProgram()
+}
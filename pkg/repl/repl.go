@@ -1,48 +1,51 @@
-// Package repl contains the Read Evaluate Print Loop (REPL).
+// Package repl contains the Read Evaluate Print Loop (REPL) and a runner for whole programs.
 package repl
 
 import (
 	"bufio"
 	"fmt"
 	"io"
+	"os"
 
 	"github.com/grantwforsythe/monkeylang/pkg/evaluator"
 	"github.com/grantwforsythe/monkeylang/pkg/lexer"
 	"github.com/grantwforsythe/monkeylang/pkg/object"
 	"github.com/grantwforsythe/monkeylang/pkg/parser"
+	"github.com/grantwforsythe/monkeylang/pkg/prelude"
 )
 
 const PROMPT = ">> "
 
 // Start starts the REPL.
-func Start(in io.Reader, out io.Writer) {
-	scanner := bufio.NewScanner(in)
-	env := object.NewEnvironment()
-	macroEnv := object.NewEnvironment()
+// The standard prelude and then the given prelude files are evaluated before the first line.
+// Builtins read from in and write to out. Returns the exit code passed to `quit`, or 0 once the input is exhausted.
+func Start(in io.Reader, out io.Writer, files ...prelude.File) int {
+	rt := newRuntime(in, out)
+
+	env := object.NewEnvironmentWithRuntime(rt)
+	macroEnv := object.NewEnvironmentWithRuntime(rt)
+
+	if err := prelude.Eval(env, append(prelude.Standard(), files...)...); err != nil {
+		fmt.Fprintf(out, "could not load the prelude: %s\n", err)
+		return 1
+	}
 
 	for {
 		fmt.Fprint(out, PROMPT)
-		scanned := scanner.Scan()
-		if !scanned {
-			return
+
+		// Lines are read through the runtime so that `gets` reads the line after the one that called it
+		line, err := rt.Stdin.ReadString('\n')
+		if line == "" && err != nil {
+			return 0
 		}
 
-		line := scanner.Text()
 		l := lexer.New(line)
 		p := parser.New(l)
 
 		program := p.ParseProgram()
 
 		if len(p.Errors()) != 0 {
-			for _, msg := range p.Errors() {
-				_, err := io.WriteString(
-					out,
-					"We ran into some monkey business! Parse errors:\n"+"\t- "+msg.Error()+"\n",
-				)
-				if err != nil {
-					break
-				}
-			}
+			printParserErrors(out, p)
 			continue
 		}
 
@@ -50,6 +53,10 @@ func Start(in io.Reader, out io.Writer) {
 		expanded := evaluator.ExpandMacros(program, macroEnv)
 
 		eval := evaluator.Eval(expanded, env)
+		if code, exited := rt.ExitCode(); exited {
+			return code
+		}
+
 		if eval != nil {
 			_, err := io.WriteString(out, eval.Inspect()+"\n")
 			if err != nil {
@@ -57,4 +64,68 @@ func Start(in io.Reader, out io.Writer) {
 			}
 		}
 	}
+
+	return 0
+}
+
+// Run runs a whole program.
+// The standard prelude and then the given prelude files are evaluated before the program.
+// Builtins read from in and write to out. Returns the exit code passed to `quit`, 1 if the program fails, or 0.
+func Run(source string, in io.Reader, out io.Writer, files ...prelude.File) int {
+	rt := newRuntime(in, out)
+
+	env := object.NewEnvironmentWithRuntime(rt)
+	macroEnv := object.NewEnvironmentWithRuntime(rt)
+
+	if err := prelude.Eval(env, append(prelude.Standard(), files...)...); err != nil {
+		fmt.Fprintf(out, "could not load the prelude: %s\n", err)
+		return 1
+	}
+
+	p := parser.New(lexer.New(source))
+	program := p.ParseProgram()
+
+	if len(p.Errors()) != 0 {
+		printParserErrors(out, p)
+		return 1
+	}
+
+	evaluator.DefineMacros(program, macroEnv)
+	expanded := evaluator.ExpandMacros(program, macroEnv)
+
+	eval := evaluator.Eval(expanded, env)
+	if code, exited := rt.ExitCode(); exited {
+		return code
+	}
+
+	if err, ok := eval.(*object.Error); ok {
+		fmt.Fprintln(out, err.Inspect())
+		return 1
+	}
+
+	return 0
+}
+
+// newRuntime creates a runtime whose builtins read from in and write to out.
+func newRuntime(in io.Reader, out io.Writer) *object.Runtime {
+	rt := object.NewRuntime()
+	rt.Stdin = bufio.NewReader(in)
+	rt.Stdout = out
+	// Modules are imported relative to the working directory
+	rt.Loader = object.FSLoader{FS: os.DirFS(".")}
+
+	return rt
+}
+
+// printParserErrors writes the errors of a program that could not be parsed.
+func printParserErrors(out io.Writer, p *parser.Parser) {
+	for _, msg := range p.Errors() {
+		_, err := io.WriteString(
+			out,
+			"We ran into some monkey business! Parse errors:\n"+"\t- "+msg.Error()+"\n",
+		)
+		if err != nil {
+			break
+		}
+	}
 }
@@ -0,0 +1,79 @@
+package repl
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/grantwforsythe/monkeylang/pkg/prelude"
+)
+
+func TestStart(t *testing.T) {
+	tests := []struct {
+		input          string
+		expectedOutput string
+		expectedCode   int
+	}{
+		{"1 + 1\n", ">> 2\n>> ", 0},
+		{"let name = gets()\nmonkey\nputs(name)\n", ">> >> monkey\nnull\n>> ", 0},
+		{"quit(2)\nputs(1)\n", ">> ", 2},
+	}
+
+	for _, tt := range tests {
+		var out bytes.Buffer
+		code := Start(strings.NewReader(tt.input), &out)
+
+		if out.String() != tt.expectedOutput {
+			t.Errorf("%q: wrong output. got=%q, expected=%q", tt.input, out.String(), tt.expectedOutput)
+		}
+
+		if code != tt.expectedCode {
+			t.Errorf("%q: wrong exit code. got=%d, expected=%d", tt.input, code, tt.expectedCode)
+		}
+	}
+}
+
+func TestStartWithPrelude(t *testing.T) {
+	files := []prelude.File{{Name: "host.mk", Source: "let greeting = \"hello\";"}}
+
+	var out bytes.Buffer
+	Start(strings.NewReader("greeting\nidentity(1)\n"), &out, files...)
+
+	expected := ">> hello\n>> 1\n>> "
+	if out.String() != expected {
+		t.Errorf("wrong output. got=%q, expected=%q", out.String(), expected)
+	}
+
+	out.Reset()
+	code := Start(strings.NewReader("1\n"), &out, prelude.File{Name: "broken.mk", Source: "1 + true"})
+	if code != 1 || out.String() != "could not load the prelude: broken.mk: type mismatch: INTEGER + BOOLEAN\n" {
+		t.Errorf("wrong result for a broken prelude. got=%q (%d)", out.String(), code)
+	}
+}
+
+func TestRun(t *testing.T) {
+	tests := []struct {
+		source         string
+		expectedOutput string
+		expectedCode   int
+	}{
+		{"puts(1 + 1)", "2\n", 0},
+		{"puts(find([1, 2, 3], fn(x) { x > 1 }))", "2\n", 0},
+		{"quit(3)", "", 3},
+		{"1 + true", "Error: type mismatch: INTEGER + BOOLEAN\n", 1},
+		{"let", "We ran into some monkey business! Parse errors:\n\t- expected next token to be IDENT. got=EOF\n", 1},
+	}
+
+	for _, tt := range tests {
+		var out bytes.Buffer
+		code := Run(tt.source, strings.NewReader(""), &out)
+
+		if out.String() != tt.expectedOutput {
+			t.Errorf("%q: wrong output. got=%q, expected=%q", tt.source, out.String(), tt.expectedOutput)
+		}
+
+		if code != tt.expectedCode {
+			t.Errorf("%q: wrong exit code. got=%d, expected=%d", tt.source, code, tt.expectedCode)
+		}
+	}
+}
@@ -5,7 +5,6 @@ type TokenType string
 
 // TODO: Replace assignment operator `let` with `:=`
 // TODO: Replace fn defintion with func
-// TODO: Add a loop token
 // TODO: Add less/greater than or equal to operators
 // TODO: Add comment token that will stop evaluation of a line
 // TODO: Add exponent operator
@@ -16,8 +15,11 @@ const (
 
 	IDENT  = "IDENT"  // Identifier, e.g. add, foobar, x, y
 	INT    = "INT"    // Integer literal, e.g. 1234
+	FLOAT  = "FLOAT"  // Float literal, e.g. 3.14
 	STRING = "STRING" // String literal, "Hello, World!"
 
+	TEMPLATE = "TEMPLATE" // String literal with interpolated expressions, "Hello, ${name}!"
+
 	ASSIGN   = "=" // Assignment operator, "="
 	PLUS     = "+" // Additional operator, "+"
 	MINUS    = "-" // Subtraction operator, "-"
@@ -34,6 +36,7 @@ const (
 	COMMA     = "," // Comma, ","
 	SEMICOLON = ";" // Semicolon, ";"
 	COLON     = ":" // Colon, ":"
+	DOT       = "." // Member access operator, "."
 
 	LPAREN   = "(" // Left parenthesis, "("
 	RPAREN   = ")" // Right parenthesis, ")"
@@ -48,8 +51,11 @@ const (
 	FALSE    = "FALSE"    // Boolean literal "false"
 	IF       = "IF"       // Conditonal definition, "if"
 	ELSE     = "ELSE"     // Alternative conditional definition, "else"
+	WHILE    = "WHILE"    // Loop definition, "while"
 	RETURN   = "RETURN"   // Return statement, "return"
 	MACRO    = "MACRO"    // Macro definition, e.g. "macro(x, y)"
+	IMPORT   = "IMPORT"   // Import statement, e.g. "import "math.mk" as m"
+	EXPORT   = "EXPORT"   // Export declaration, e.g. "export let x = 1"
 )
 
 type Token struct {
@@ -64,8 +70,11 @@ var keywords = map[string]TokenType{
 	"false":  FALSE,
 	"if":     IF,
 	"else":   ELSE,
+	"while":  WHILE,
 	"return": RETURN,
 	"macro":  MACRO,
+	"import": IMPORT,
+	"export": EXPORT,
 }
 
 // Get the token associated with a keyword.
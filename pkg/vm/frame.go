@@ -0,0 +1,23 @@
+package vm
+
+import (
+	"github.com/grantwforsythe/monkeylang/pkg/code"
+	"github.com/grantwforsythe/monkeylang/pkg/object"
+)
+
+// Frame represents the call of a function, holding the execution-relevant information of the call.
+type Frame struct {
+	cl          *object.Closure
+	ip          int // ip represents the instruction pointer of the function being called.
+	basePointer int // basePointer represents the position of the stack pointer before the call, where the locals of the call start.
+}
+
+// NewFrame creates a frame for the call of a closure whose locals start at a position in the stack.
+func NewFrame(cl *object.Closure, basePointer int) *Frame {
+	return &Frame{cl: cl, ip: -1, basePointer: basePointer}
+}
+
+// Instructions gets the instructions of the function being called.
+func (f *Frame) Instructions() code.Instructions {
+	return f.cl.Fn.Instructions
+}
@@ -4,34 +4,99 @@
 package vm
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"slices"
+	"strings"
 
 	"github.com/grantwforsythe/monkeylang/pkg/code"
 	"github.com/grantwforsythe/monkeylang/pkg/compiler"
 	"github.com/grantwforsythe/monkeylang/pkg/object"
 )
 
-// StackSize represents the maximum number of elements in the stack.
+// StackSize represents the initial number of elements in the stack, which grows as function calls need more.
 const StackSize = 2048 // This number was abritarily choosen
 
+// MaxStackSize represents the maximum number of elements in the stack.
+// The number of function calls in progress is limited by the runtime, this only stops a program that pushes too many
+// elements onto the stack in other ways.
+const MaxStackSize = 1 << 24
+
+// GlobalsSize represents the maximum number of global bindings.
+// It is the number of values that can be referenced by the two byte operand of OpGetGlobal and OpSetGlobal.
+const GlobalsSize = 65536
+
+var (
+	TRUE  = object.TRUE
+	FALSE = object.FALSE
+	NULL  = object.NULL
+)
+
 type VM struct {
-	constants    []object.Object
-	instructions code.Instructions
+	constants []object.Object
+
+	// frames is the stack of function calls in progress, with the main program at the bottom.
+	frames      []*Frame
+	framesIndex int
 
 	// Instructions
 	stack []object.Object
 	// sp represents a stackpointer which always points to the next free space in the stack.
 	sp int
+
+	globals []object.Object
+
+	// upvalues are the upvalues that refer to locals of the frames in progress, ordered by the position of the local.
+	upvalues []openUpvalue
+
+	// runtime is the state shared by the builtins called by the virtual machine.
+	runtime *object.Runtime
+
+	// caller calls the closures passed to builtins, see callFromBuiltin.
+	caller object.Caller
+}
+
+// openUpvalue is an upvalue that refers to a local on the stack.
+type openUpvalue struct {
+	index   int // index represents the position of the local in the stack.
+	upvalue *object.Upvalue
 }
 
 // New creates a new virtual machine from bytecode.
 func New(bytecode *compiler.ByteCode) *VM {
-	return &VM{
-		constants:    bytecode.Constants,
-		instructions: bytecode.Instructions,
-		stack:        make([]object.Object, StackSize),
-		sp:           0,
+	return NewWithRuntime(bytecode, object.NewRuntime())
+}
+
+// NewWithRuntime creates a new virtual machine from bytecode whose builtins share a runtime.
+func NewWithRuntime(bytecode *compiler.ByteCode, runtime *object.Runtime) *VM {
+	return NewWithGlobals(bytecode, runtime, make([]object.Object, GlobalsSize))
+}
+
+// NewWithGlobals creates a new virtual machine from bytecode that shares its globals with a previous virtual machine,
+// so the program can use the bindings of a program compiled before it.
+func NewWithGlobals(bytecode *compiler.ByteCode, runtime *object.Runtime, globals []object.Object) *VM {
+	mainFn := &object.CompiledFunction{Instructions: bytecode.Instructions}
+
+	frames := []*Frame{NewFrame(&object.Closure{Fn: mainFn}, 0)}
+
+	vm := &VM{
+		constants:   bytecode.Constants,
+		frames:      frames,
+		framesIndex: 1,
+		stack:       make([]object.Object, StackSize),
+		sp:          0,
+		globals:     globals,
+		runtime:     runtime,
 	}
+	vm.caller = vm.callFromBuiltin
+
+	return vm
+}
+
+// Globals gets the globals of the virtual machine, which can be shared with a virtual machine created after it.
+func (vm *VM) Globals() []object.Object {
+	return vm.globals
 }
 
 // StackTop gets the top element on the stack.
@@ -43,26 +108,107 @@ func (vm *VM) StackTop() object.Object {
 	return vm.stack[vm.sp-1]
 }
 
-// lastPoppedStackElem gets the last element popped from the stack. Values are not zero out when they are popped from the stack, instead the stackpointer is decremented.
+// LastPoppedStackElem gets the last element popped from the stack. Values are not zero out when they are popped from the stack, instead the stackpointer is decremented.
 // Returns the object last popped from the stack.
-func (vm *VM) lastPoppedStackElem() object.Object {
+func (vm *VM) LastPoppedStackElem() object.Object {
 	// The stackpointer always points to the next free slot in memory.
 	return vm.stack[vm.sp]
 }
 
+// RunContext runs the bytecode the same way as Run, stopping with an error once the context is done.
+func (vm *VM) RunContext(ctx context.Context) error {
+	previous := vm.runtime.Context()
+	vm.runtime.SetContext(ctx)
+	defer vm.runtime.SetContext(previous)
+
+	return vm.Run()
+}
+
+// Call calls a function with arguments and returns its result. The function can be a closure defined by a program
+// that ran with the same constants and globals, in which case the virtual machine must have been created from bytecode
+// with those constants and no instructions.
+func (vm *VM) Call(fn object.Object, args ...object.Object) (object.Object, error) {
+	err := vm.push(fn)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, arg := range args {
+		err := vm.push(arg)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// A closure runs until its frame returns, while a builtin returns its result right away
+	depth := vm.framesIndex
+	err = vm.callFunction(len(args))
+	if err != nil {
+		return nil, err
+	}
+
+	err = vm.run(depth)
+	if err != nil {
+		return nil, err
+	}
+
+	// A function that calls `quit` stops without returning
+	if _, exited := vm.runtime.ExitCode(); exited {
+		return NULL, nil
+	}
+
+	return vm.pop(), nil
+}
+
+// callFromBuiltin calls a function passed to a builtin that is running in the middle of the program, e.g. `map`.
+// Errors are returned as error objects, which the builtin returns to stop the program.
+func (vm *VM) callFromBuiltin(fn object.Object, args []object.Object, rt *object.Runtime) object.Object {
+	result, err := vm.Call(fn, args...)
+
+	// The builtin stops once the function calls `quit`, the same way it does in the evaluator
+	if code, exited := vm.runtime.ExitCode(); exited {
+		return &object.Exit{Code: int64(code)}
+	}
+
+	var objErr *object.Error
+	switch {
+	case err == nil:
+		return result
+	case errors.As(err, &objErr):
+		return objErr
+	default:
+		return &object.Error{Message: err.Error(), Err: err}
+	}
+}
+
 // Run is the fetch-decode-excute cycle for the virtual machine.
+// Every instruction counts as a step towards the step limit of the runtime.
 func (vm *VM) Run() error {
+	return vm.run(0)
+}
+
+// run runs the bytecode until the frames above a depth have returned, or the main program ends.
+func (vm *VM) run(depth int) error {
 	// The fetch part.
-	for ip := 0; ip < len(vm.instructions); ip++ {
+	for vm.framesIndex > depth && vm.currentFrame().ip < len(vm.currentFrame().Instructions())-1 {
+		if err := vm.runtime.Step(); err != nil {
+			return err
+		}
+
+		frame := vm.currentFrame()
+		frame.ip++
+
+		ip := frame.ip
+		ins := frame.Instructions()
 
 		// The decode part.
-		op := code.Opcode(vm.instructions[ip])
+		op := code.Opcode(ins[ip])
 
 		// The execute part.
 		switch op {
 		case code.OpConstant:
-			index := code.ReadUint16(vm.instructions[ip+1:])
-			ip += 2
+			index := code.ReadUint16(ins[ip+1:])
+			frame.ip += 2
 
 			err := vm.push(vm.constants[index])
 			if err != nil {
@@ -70,29 +216,246 @@ func (vm *VM) Run() error {
 			}
 
 		case code.OpAdd, code.OpSub, code.OpMul, code.OpDiv:
-			right := vm.pop()
+			err := vm.executeBinaryOperation(op)
+			if err != nil {
+				return err
+			}
+
+		case code.OpEqual, code.OpNotEqual, code.OpGreaterThan:
+			err := vm.executeComparison(op)
+			if err != nil {
+				return err
+			}
+
+		case code.OpTrue:
+			err := vm.push(TRUE)
+			if err != nil {
+				return err
+			}
+
+		case code.OpFalse:
+			err := vm.push(FALSE)
+			if err != nil {
+				return err
+			}
+
+		case code.OpNull:
+			err := vm.push(NULL)
+			if err != nil {
+				return err
+			}
+
+		case code.OpBang:
+			err := vm.executeBangOperator()
+			if err != nil {
+				return err
+			}
+
+		case code.OpMinus:
+			err := vm.executeMinusOperator()
+			if err != nil {
+				return err
+			}
+
+		case code.OpJump:
+			position := int(code.ReadUint16(ins[ip+1:]))
+			// The loop increments ip so it is set to the instruction before the target.
+			frame.ip = position - 1
+
+		case code.OpJumpNotTruthy:
+			position := int(code.ReadUint16(ins[ip+1:]))
+			frame.ip += 2
+
+			condition := vm.pop()
+			if !isTruthy(condition) {
+				frame.ip = position - 1
+			}
+
+		case code.OpSetGlobal:
+			index := code.ReadUint16(ins[ip+1:])
+			frame.ip += 2
+
+			vm.globals[index] = vm.pop()
+
+		case code.OpGetGlobal:
+			index := code.ReadUint16(ins[ip+1:])
+			frame.ip += 2
+
+			// A function can refer to a global defined after it, which is not set if the function is called first
+			if vm.globals[index] == nil {
+				return fmt.Errorf("variable used before it is defined")
+			}
+
+			err := vm.push(vm.globals[index])
+			if err != nil {
+				return err
+			}
+
+		case code.OpArray:
+			numElements := int(code.ReadUint16(ins[ip+1:]))
+			frame.ip += 2
+
+			array := vm.buildArray(vm.sp-numElements, vm.sp)
+			vm.sp = vm.sp - numElements
+
+			err := vm.pushAllocated(array)
+			if err != nil {
+				return err
+			}
+
+		case code.OpHash:
+			numElements := int(code.ReadUint16(ins[ip+1:]))
+			frame.ip += 2
+
+			hash, err := vm.buildHash(vm.sp-numElements, vm.sp)
+			if err != nil {
+				return err
+			}
+			vm.sp = vm.sp - numElements
+
+			err = vm.pushAllocated(hash)
+			if err != nil {
+				return err
+			}
+
+		case code.OpConcat:
+			numElements := int(code.ReadUint16(ins[ip+1:]))
+			frame.ip += 2
+
+			str := vm.buildString(vm.sp-numElements, vm.sp)
+			vm.sp = vm.sp - numElements
+
+			err := vm.pushAllocated(str)
+			if err != nil {
+				return err
+			}
+
+		case code.OpModule:
+			path := vm.constants[code.ReadUint16(ins[ip+1:])].(*object.String)
+			numElements := int(code.ReadUint16(ins[ip+3:]))
+			frame.ip += 4
+
+			exports, err := vm.buildHash(vm.sp-numElements, vm.sp)
+			if err != nil {
+				return err
+			}
+			vm.sp = vm.sp - numElements
+
+			err = vm.push(&object.Module{Path: path.Value, Exports: exports.(*object.Hash)})
+			if err != nil {
+				return err
+			}
+
+		case code.OpIndex:
+			index := vm.pop()
 			left := vm.pop()
 
-			// BUG: Handle other object types
-			var result int64
-			switch op {
-			case code.OpAdd:
-				result = left.(*object.Integer).Value + right.(*object.Integer).Value
-			case code.OpSub:
-				result = left.(*object.Integer).Value - right.(*object.Integer).Value
-			case code.OpMul:
-				result = left.(*object.Integer).Value * right.(*object.Integer).Value
-			case code.OpDiv:
-				result = left.(*object.Integer).Value / right.(*object.Integer).Value
-			default:
-				return fmt.Errorf("unknown integer operator: %d", op)
+			err := vm.executeIndexExpression(left, index)
+			if err != nil {
+				return err
+			}
+
+		case code.OpGetBuiltin:
+			index := code.ReadUint16(ins[ip+1:])
+			frame.ip += 2
+
+			builtin, _ := vm.runtime.LookupBuiltin(object.BuiltinNames()[index])
+			err := vm.push(builtin)
+			if err != nil {
+				return err
+			}
+
+		case code.OpCall:
+			numArgs := int(code.ReadUint8(ins[ip+1:]))
+			frame.ip += 1
+
+			err := vm.callFunction(numArgs)
+			if err != nil {
+				return err
+			}
+
+			// Calling `quit` stops the program without an error
+			if _, exited := vm.runtime.ExitCode(); exited {
+				return nil
+			}
+
+		case code.OpTailCall:
+			numArgs := int(code.ReadUint8(ins[ip+1:]))
+			frame.ip += 1
+
+			err := vm.tailCallFunction(numArgs)
+			if err != nil {
+				return err
+			}
+
+			if _, exited := vm.runtime.ExitCode(); exited {
+				return nil
+			}
+
+		case code.OpReturnValue:
+			returnValue := vm.pop()
+
+			// A return at the top level stops the program with the returned value as its result
+			if vm.framesIndex == 1 {
+				return nil
+			}
+
+			frame := vm.popFrame()
+			vm.sp = frame.basePointer - 1
+
+			err := vm.push(returnValue)
+			if err != nil {
+				return err
+			}
+
+		case code.OpReturn:
+			frame := vm.popFrame()
+			vm.sp = frame.basePointer - 1
+
+			err := vm.push(NULL)
+			if err != nil {
+				return err
+			}
+
+		case code.OpSetLocal:
+			index := code.ReadUint8(ins[ip+1:])
+			frame.ip += 1
+
+			vm.stack[frame.basePointer+int(index)] = vm.pop()
+
+		case code.OpGetLocal:
+			index := code.ReadUint8(ins[ip+1:])
+			frame.ip += 1
+
+			err := vm.push(vm.stack[frame.basePointer+int(index)])
+			if err != nil {
+				return err
+			}
+
+		case code.OpClosure:
+			index := code.ReadUint16(ins[ip+1:])
+			frame.ip += 2
+
+			err := vm.pushClosure(int(index))
+			if err != nil {
+				return err
 			}
 
-			err := vm.push(&object.Integer{Value: result})
+		case code.OpGetFree:
+			index := code.ReadUint8(ins[ip+1:])
+			frame.ip += 1
+
+			err := vm.push(frame.cl.Free[index].Get())
 			if err != nil {
 				return err
 			}
 
+		case code.OpSetFree:
+			index := code.ReadUint8(ins[ip+1:])
+			frame.ip += 1
+
+			frame.cl.Free[index].Set(vm.pop())
+
 		case code.OpPop:
 			vm.pop()
 
@@ -102,6 +465,405 @@ func (vm *VM) Run() error {
 	return nil
 }
 
+// executeBinaryOperation pops two objects off the stack and pushes the result of an arithmetic operation on them.
+func (vm *VM) executeBinaryOperation(op code.Opcode) error {
+	right := vm.pop()
+	left := vm.pop()
+
+	switch {
+	case left.Type() == object.INTEGER_OBJ && right.Type() == object.INTEGER_OBJ:
+		return vm.executeBinaryIntegerOperation(op, left, right)
+	case isNumber(left) && isNumber(right):
+		return vm.executeBinaryFloatOperation(op, left, right)
+	case left.Type() == object.STRING_OBJ && right.Type() == object.STRING_OBJ:
+		return vm.executeBinaryStringOperation(op, left, right)
+	case left.Type() != right.Type():
+		return fmt.Errorf("type mismatch: %s %s %s", left.Type(), operators[op], right.Type())
+	default:
+		return fmt.Errorf("unknown operator: %s %s %s", left.Type(), operators[op], right.Type())
+	}
+}
+
+func (vm *VM) executeBinaryIntegerOperation(op code.Opcode, left, right object.Object) error {
+	lValue := left.(*object.Integer).Value
+	rValue := right.(*object.Integer).Value
+
+	var result int64
+	switch op {
+	case code.OpAdd:
+		result = lValue + rValue
+	case code.OpSub:
+		result = lValue - rValue
+	case code.OpMul:
+		result = lValue * rValue
+	case code.OpDiv:
+		quotient, err := object.DivideIntegers(lValue, rValue)
+		if err != nil {
+			return err
+		}
+
+		result = quotient
+	default:
+		return fmt.Errorf("unknown integer operator: %d", op)
+	}
+
+	return vm.push(&object.Integer{Value: result})
+}
+
+// executeBinaryFloatOperation applies an arithmetic operator where either operand is a float.
+// Integer operands are converted to floats.
+func (vm *VM) executeBinaryFloatOperation(op code.Opcode, left, right object.Object) error {
+	lValue, _ := object.AsFloat(left)
+	rValue, _ := object.AsFloat(right)
+
+	var result float64
+	switch op {
+	case code.OpAdd:
+		result = lValue + rValue
+	case code.OpSub:
+		result = lValue - rValue
+	case code.OpMul:
+		result = lValue * rValue
+	case code.OpDiv:
+		result = lValue / rValue
+	default:
+		return fmt.Errorf("unknown float operator: %d", op)
+	}
+
+	return vm.push(&object.Float{Value: result})
+}
+
+func (vm *VM) executeBinaryStringOperation(op code.Opcode, left, right object.Object) error {
+	if op != code.OpAdd {
+		return fmt.Errorf("unknown operator: %s %s %s", left.Type(), operators[op], right.Type())
+	}
+
+	lValue := left.(*object.String).Value
+	rValue := right.(*object.String).Value
+
+	return vm.pushAllocated(&object.String{Value: lValue + rValue})
+}
+
+// executeComparison pops two objects off the stack and pushes the result of comparing them.
+func (vm *VM) executeComparison(op code.Opcode) error {
+	right := vm.pop()
+	left := vm.pop()
+
+	switch {
+	case left.Type() == object.INTEGER_OBJ && right.Type() == object.INTEGER_OBJ:
+		lValue := left.(*object.Integer).Value
+		rValue := right.(*object.Integer).Value
+
+		switch op {
+		case code.OpEqual:
+			return vm.push(nativeBoolToBooleanObject(lValue == rValue))
+		case code.OpNotEqual:
+			return vm.push(nativeBoolToBooleanObject(lValue != rValue))
+		case code.OpGreaterThan:
+			return vm.push(nativeBoolToBooleanObject(lValue > rValue))
+		}
+	case isNumber(left) && isNumber(right):
+		lValue, _ := object.AsFloat(left)
+		rValue, _ := object.AsFloat(right)
+
+		switch op {
+		case code.OpEqual:
+			return vm.push(nativeBoolToBooleanObject(lValue == rValue))
+		case code.OpNotEqual:
+			return vm.push(nativeBoolToBooleanObject(lValue != rValue))
+		case code.OpGreaterThan:
+			return vm.push(nativeBoolToBooleanObject(lValue > rValue))
+		}
+	case left.Type() == object.STRING_OBJ && right.Type() == object.STRING_OBJ:
+		lValue := left.(*object.String).Value
+		rValue := right.(*object.String).Value
+
+		switch op {
+		case code.OpEqual:
+			return vm.push(nativeBoolToBooleanObject(lValue == rValue))
+		case code.OpNotEqual:
+			return vm.push(nativeBoolToBooleanObject(lValue != rValue))
+		}
+	// Booleans and null are singletons so they are compared by identity
+	case op == code.OpEqual:
+		return vm.push(nativeBoolToBooleanObject(left == right))
+	case op == code.OpNotEqual:
+		return vm.push(nativeBoolToBooleanObject(left != right))
+	case left.Type() != right.Type():
+		return fmt.Errorf("type mismatch: %s %s %s", left.Type(), operators[op], right.Type())
+	}
+
+	return fmt.Errorf("unknown operator: %s %s %s", left.Type(), operators[op], right.Type())
+}
+
+func (vm *VM) executeBangOperator() error {
+	operand := vm.pop()
+
+	switch operand {
+	case TRUE:
+		return vm.push(FALSE)
+	case FALSE:
+		return vm.push(TRUE)
+	case NULL:
+		return vm.push(TRUE)
+	default:
+		return vm.push(FALSE)
+	}
+}
+
+func (vm *VM) executeMinusOperator() error {
+	operand := vm.pop()
+
+	switch operand := operand.(type) {
+	case *object.Integer:
+		return vm.push(&object.Integer{Value: -operand.Value})
+	case *object.Float:
+		return vm.push(&object.Float{Value: -operand.Value})
+	default:
+		return fmt.Errorf("unknown operator: -%s", operand.Type())
+	}
+}
+
+// buildArray creates an array from the elements in the stack between two positions.
+func (vm *VM) buildArray(startIndex, endIndex int) object.Object {
+	elements := make([]object.Object, endIndex-startIndex)
+	copy(elements, vm.stack[startIndex:endIndex])
+
+	return &object.Array{Elements: elements}
+}
+
+// buildString creates a string by concatenating the string representations of the objects in the stack between two
+// positions.
+func (vm *VM) buildString(startIndex, endIndex int) object.Object {
+	var out strings.Builder
+	for _, obj := range vm.stack[startIndex:endIndex] {
+		out.WriteString(obj.Inspect())
+	}
+
+	return &object.String{Value: out.String()}
+}
+
+// buildHash creates a hash from the alternating keys and values in the stack between two positions.
+func (vm *VM) buildHash(startIndex, endIndex int) (object.Object, error) {
+	hash := &object.Hash{}
+
+	for i := startIndex; i < endIndex; i += 2 {
+		key := vm.stack[i]
+		value := vm.stack[i+1]
+
+		hashKey, ok := key.(object.Hashable)
+		if !ok {
+			return nil, fmt.Errorf("unhashable key: %s", key.Type())
+		}
+
+		hash.Set(hashKey, value)
+	}
+
+	return hash, nil
+}
+
+func (vm *VM) executeIndexExpression(left, index object.Object) error {
+	switch {
+	case left.Type() == object.ARRAY_OBJ && index.Type() == object.INTEGER_OBJ:
+		array := left.(*object.Array)
+		idx := index.(*object.Integer).Value
+
+		// Indexing out of bounds
+		if idx < 0 || idx > int64(len(array.Elements)-1) {
+			return vm.push(NULL)
+		}
+
+		return vm.push(array.Elements[idx])
+	case left.Type() == object.HASH_OBJ:
+		hash := left.(*object.Hash)
+
+		key, ok := index.(object.Hashable)
+		if !ok {
+			return fmt.Errorf("unhashable key: %s", index.Type())
+		}
+
+		pair, ok := hash.Get(key)
+		if !ok {
+			return vm.push(NULL)
+		}
+
+		return vm.push(pair.Value)
+	case left.Type() == object.MODULE_OBJ:
+		value := left.(*object.Module).Export(index)
+		if err, ok := value.(*object.Error); ok {
+			return fmt.Errorf("%s", err.Message)
+		}
+
+		return vm.push(value)
+	default:
+		return fmt.Errorf("index operator not supported: %s", left.Type())
+	}
+}
+
+// callFunction calls the function below the arguments on the stack, replacing both with the result.
+func (vm *VM) callFunction(numArgs int) error {
+	callee := vm.stack[vm.sp-1-numArgs]
+
+	switch callee := callee.(type) {
+	case *object.Builtin:
+		args := vm.stack[vm.sp-numArgs : vm.sp]
+
+		vm.runtime.SetCaller(vm.caller)
+		result := callee.Call(vm.runtime, args...)
+		vm.sp = vm.sp - numArgs - 1
+
+		// Errors from builtins stop execution the same way errors do in the evaluator
+		if err, ok := result.(*object.Error); ok {
+			return err
+		}
+
+		if result == nil {
+			result = NULL
+		}
+
+		return vm.push(result)
+	case *object.Closure:
+		if numArgs != callee.Fn.NumParameters {
+			return fmt.Errorf("wrong number of arguments. got=%d, want=%d", numArgs, callee.Fn.NumParameters)
+		}
+
+		// The main program is not a function call
+		if vm.framesIndex-1 >= vm.runtime.MaxCallDepth {
+			return vm.callDepthError(callee)
+		}
+
+		// The arguments on the stack become the first locals of the call
+		frame := NewFrame(callee, vm.sp-numArgs)
+		err := vm.growStack(frame.basePointer + callee.Fn.NumLocals)
+		if err != nil {
+			return err
+		}
+
+		vm.pushFrame(frame)
+		vm.sp = frame.basePointer + callee.Fn.NumLocals
+
+		return nil
+	default:
+		return fmt.Errorf("not a function: %s", callee.Type())
+	}
+}
+
+// tailCallFunction calls the function below the arguments on the stack, reusing the frame of the current function.
+// The callee and its arguments replace the current function and its locals on the stack, so when the callee returns it
+// returns to the caller of the current function. Builtins are called the same way as callFunction.
+func (vm *VM) tailCallFunction(numArgs int) error {
+	callee, ok := vm.stack[vm.sp-1-numArgs].(*object.Closure)
+	if !ok {
+		return vm.callFunction(numArgs)
+	}
+
+	if numArgs != callee.Fn.NumParameters {
+		return fmt.Errorf("wrong number of arguments. got=%d, want=%d", numArgs, callee.Fn.NumParameters)
+	}
+
+	frame := vm.currentFrame()
+	err := vm.growStack(frame.basePointer + callee.Fn.NumLocals)
+	if err != nil {
+		return err
+	}
+
+	// The locals of the current function are about to be overwritten
+	vm.closeUpvalues(frame.basePointer)
+	copy(vm.stack[frame.basePointer-1:], vm.stack[vm.sp-1-numArgs:vm.sp])
+
+	frame.cl = callee
+	frame.ip = -1
+	vm.sp = frame.basePointer + callee.Fn.NumLocals
+
+	return nil
+}
+
+// currentFrame gets the frame of the function call being executed.
+func (vm *VM) currentFrame() *Frame {
+	return vm.frames[vm.framesIndex-1]
+}
+
+// pushFrame starts executing a function call.
+func (vm *VM) pushFrame(f *Frame) {
+	if vm.framesIndex == len(vm.frames) {
+		vm.frames = append(vm.frames, f)
+	} else {
+		vm.frames[vm.framesIndex] = f
+	}
+	vm.framesIndex++
+}
+
+// callDepthError creates the error for a call of a closure that exceeds the maximum call depth of the runtime.
+func (vm *VM) callDepthError(callee *object.Closure) error {
+	calls := make([]string, 0, vm.framesIndex)
+	for _, frame := range vm.frames[1:vm.framesIndex] {
+		calls = append(calls, frame.cl.Fn.Name)
+	}
+	calls = append(calls, callee.Fn.Name)
+
+	return fmt.Errorf("%s", object.CallDepthError(vm.runtime.MaxCallDepth, calls).Message)
+}
+
+// popFrame finishes executing the current function call, closing the upvalues that refer to its locals.
+// Returns the frame of the finished call.
+func (vm *VM) popFrame() *Frame {
+	vm.framesIndex--
+	frame := vm.frames[vm.framesIndex]
+	vm.closeUpvalues(frame.basePointer)
+
+	return frame
+}
+
+// pushClosure pushes a closure of the function constant at an index, capturing the variables it describes from the
+// current frame.
+func (vm *VM) pushClosure(index int) error {
+	fn, ok := vm.constants[index].(*object.CompiledFunction)
+	if !ok {
+		return fmt.Errorf("not a function: %s", vm.constants[index].Type())
+	}
+
+	frame := vm.currentFrame()
+	free := make([]*object.Upvalue, len(fn.Free))
+	for i, variable := range fn.Free {
+		if variable.Local {
+			free[i] = vm.captureUpvalue(frame.basePointer + variable.Index)
+		} else {
+			free[i] = frame.cl.Free[variable.Index]
+		}
+	}
+
+	return vm.push(&object.Closure{Fn: fn, Free: free})
+}
+
+// captureUpvalue gets the upvalue that refers to the local at a position in the stack.
+// Closures that capture the same local share its upvalue.
+func (vm *VM) captureUpvalue(index int) *object.Upvalue {
+	for i := len(vm.upvalues) - 1; i >= 0 && vm.upvalues[i].index >= index; i-- {
+		if vm.upvalues[i].index == index {
+			return vm.upvalues[i].upvalue
+		}
+	}
+
+	upvalue := object.NewUpvalue(&vm.stack, index)
+
+	// Upvalues are kept ordered so the ones of a frame can be closed from the end
+	i := len(vm.upvalues)
+	for i > 0 && vm.upvalues[i-1].index > index {
+		i--
+	}
+	vm.upvalues = slices.Insert(vm.upvalues, i, openUpvalue{index: index, upvalue: upvalue})
+
+	return upvalue
+}
+
+// closeUpvalues closes the upvalues that refer to locals at or above a position in the stack.
+func (vm *VM) closeUpvalues(index int) {
+	for len(vm.upvalues) > 0 && vm.upvalues[len(vm.upvalues)-1].index >= index {
+		vm.upvalues[len(vm.upvalues)-1].upvalue.Close()
+		vm.upvalues = vm.upvalues[:len(vm.upvalues)-1]
+	}
+}
+
 // TODO: Refactor stack into own struct
 
 // pop removes the top object from the stack.
@@ -118,10 +880,11 @@ func (vm *VM) pop() object.Object {
 }
 
 // push adds an object to the top of the stack and increments the pointer.
-// Returns an error if a stackover flow occurs.
+// Returns an error if the stack overflows.
 func (vm *VM) push(obj object.Object) error {
-	if vm.sp >= StackSize {
-		return fmt.Errorf("stackover flow")
+	err := vm.growStack(vm.sp + 1)
+	if err != nil {
+		return err
 	}
 
 	vm.stack[vm.sp] = obj
@@ -129,3 +892,69 @@ func (vm *VM) push(obj object.Object) error {
 
 	return nil
 }
+
+// pushAllocated pushes an object created by the program onto the stack after recording it in the runtime.
+func (vm *VM) pushAllocated(obj object.Object) error {
+	if err := vm.runtime.Allocate(obj); err != nil {
+		return err
+	}
+
+	return vm.push(obj)
+}
+
+// growStack makes room for at least size elements in the stack.
+// Returns an error if the stack would grow past its maximum size.
+func (vm *VM) growStack(size int) error {
+	if size <= len(vm.stack) {
+		return nil
+	}
+
+	if size > MaxStackSize {
+		return fmt.Errorf("stack overflow")
+	}
+
+	// Open upvalues refer to the stack through the field, so they see the new stack
+	stack := make([]object.Object, min(max(size, 2*len(vm.stack)), MaxStackSize))
+	copy(stack, vm.stack)
+	vm.stack = stack
+
+	return nil
+}
+
+// operators maps an opcode to the operator it was compiled from for error messages.
+var operators = map[code.Opcode]string{
+	code.OpAdd:         "+",
+	code.OpSub:         "-",
+	code.OpMul:         "*",
+	code.OpDiv:         "/",
+	code.OpEqual:       "==",
+	code.OpNotEqual:    "!=",
+	code.OpGreaterThan: ">",
+}
+
+func nativeBoolToBooleanObject(value bool) *object.Boolean {
+	if value {
+		return TRUE
+	}
+	return FALSE
+}
+
+// isTruthy determines the truthiness of an object the same way the evaluator does.
+func isTruthy(obj object.Object) bool {
+	switch obj := obj.(type) {
+	case *object.Boolean:
+		return obj.Value
+	case *object.Null:
+		return false
+	case *object.Integer:
+		return obj.Value > 0
+	case *object.Float:
+		return obj.Value > 0
+	default:
+		return true
+	}
+}
+
+func isNumber(obj object.Object) bool {
+	return obj.Type() == object.INTEGER_OBJ || obj.Type() == object.FLOAT_OBJ
+}
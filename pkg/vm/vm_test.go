@@ -1,8 +1,14 @@
 package vm
 
 import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/grantwforsythe/monkeylang/pkg/ast"
 	"github.com/grantwforsythe/monkeylang/pkg/compiler"
@@ -35,6 +41,609 @@ func TestIntegerArithmetic(t *testing.T) {
 	runVmTests(t, tests)
 }
 
+func TestFloatArithmetic(t *testing.T) {
+	tests := []vmTestCase{
+		{"1.5", "1.5"},
+		{"-1.5", "-1.5"},
+		{"1.5 + 1.5", "3.0"},
+		{"1 + 0.5", "1.5"},
+		{"7 / 2.0", "3.5"},
+		{"2 * 0.25", "0.5"},
+		{"1.5 < 2", true},
+		{"2.5 > 2", true},
+		{"2.0 == 2", true},
+		{"if (0.0) { 1 } else { 2 }", 2},
+		{`{0.0: "a"}[-0.0]`, "a"},
+		{`{1: "a"}[1.0]`, "a"},
+		{`len(keys({1: "a", 1.0: "b", -0.0: "c", 0: "d"}))`, 2},
+		{`{1: "a", 1.0: "b"}[1]`, "b"},
+	}
+
+	runVmTests(t, tests)
+}
+
+func TestBooleanExpressions(t *testing.T) {
+	tests := []vmTestCase{
+		{"true", true},
+		{"false", false},
+		{"1 < 2", true},
+		{"1 > 2", false},
+		{"1 == 1", true},
+		{"1 != 1", false},
+		{"true == true", true},
+		{"true != false", true},
+		{"(1 < 2) == true", true},
+		{`"a" == "a"`, true},
+		{`"a" != "b"`, true},
+		{"!true", false},
+		{"!!5", true},
+		{"!(if (false) { 5; })", true},
+		{"-5", -5},
+	}
+
+	runVmTests(t, tests)
+}
+
+func TestConditionals(t *testing.T) {
+	tests := []vmTestCase{
+		{"if (true) { 10 }", 10},
+		{"if (true) { 10 } else { 20 }", 10},
+		{"if (false) { 10 } else { 20 } ", 20},
+		{"if (1) { 10 }", 10},
+		{"if (-1) { 10 }", NULL},
+		{"if (1 > 2) { 10 }", NULL},
+		{"if (true) { }", NULL},
+		{`if ("") { 10 }`, 10},
+		{"if ((if (false) { 10 })) { 10 } else { 20 }", 20},
+	}
+
+	runVmTests(t, tests)
+}
+
+func TestGlobalLetStatements(t *testing.T) {
+	tests := []vmTestCase{
+		{"let one = 1; one", 1},
+		{"let one = 1; let two = 2; one + two", 3},
+		{"let one = 1; let two = one + one; one + two", 3},
+		{"let f = fn() { y }; let y = 2; f()", 2},
+	}
+
+	runVmTests(t, tests)
+}
+
+func TestStringArrayAndHashExpressions(t *testing.T) {
+	tests := []vmTestCase{
+		{`"mon" + "key"`, "monkey"},
+		{"[1, 2 * 2, 3 + 3]", "[1, 4, 6]"},
+		{"[]", "[]"},
+		{`{"b": 1, "a": 2 * 2}`, "{b: 1, a: 4}"},
+		{"[1, 2, 3][1]", 2},
+		{"[1, 2, 3][3]", NULL},
+		{`{"a": 1}["a"]`, 1},
+		{`{"a": 1}["b"]`, NULL},
+		{`let name = "key"; "mon${name}"`, "monkey"},
+		{`"${1 + 1} ${[true]} ${first([])}"`, "2 [true] null"},
+		{`format("%-4s|%03d|%.1f", "ab", 7, 2.25)`, "ab  |007|2.2"},
+	}
+
+	runVmTests(t, tests)
+}
+
+func TestBuiltinFunctions(t *testing.T) {
+	tests := []vmTestCase{
+		{`len("four")`, 4},
+		{`len([1, 2])`, 2},
+		{`first([1, 2])`, 1},
+		{`push([], 1)`, "[1]"},
+		{`keys({"b": 1, "a": 2})`, "[b, a]"},
+		{`split("a,b , c", ",")`, "[a, b ,  c]"},
+		{`let parts = split("1, 2, 3", ","); to_int(trim(parts[2])) + 1`, 4},
+		{`upper("monkey")`, "MONKEY"},
+		{`contains("monkey", "key")`, true},
+		{`starts_with("monkey", "key")`, false},
+		{`substr("monkey", 3)`, "key"},
+		{`substr("héllo", 1, 2)`, "é"},
+		{`index_of("héllo", "l")`, 2},
+		{`replace("a-b-c", "-", "+")`, "a+b+c"},
+		{`chars("abc")`, "[a, b, c]"},
+		{`to_string(12) + "!"`, "12!"},
+		{"math.abs(-2)", 2},
+		{"math.sqrt(2.25)", "1.5"},
+		{"math.pow(3, 3)", 27},
+		{"math.pow(1, 3000000000)", 1},
+		{"math.max(1, 3.5, 2)", "3.5"},
+		{"math.round(math.PI * 100)", 314},
+		{"math.log10(100)", "2.0"},
+		{"let m = math; m.gcd(8, 12)", 4},
+		{"math.missing", NULL},
+		{"to_float(1)", "1.0"},
+		{`json_parse(json_stringify({"a": [1, 2.5]}))["a"][1]`, "2.5"},
+		{`re_match("(\d+)-(\d+)", "10-20")[2]`, "20"},
+		{`[type(1.5), type(len), is_number(2)]`, "[FLOAT, BUILTIN, true]"},
+		{`[type(fn() {}), is_function(fn() {})]`, "[FUNCTION, true]"},
+		{`parse("a * b")`, "QUOTE((a * b))"},
+	}
+
+	runVmTests(t, tests)
+}
+
+func TestHigherOrderBuiltins(t *testing.T) {
+	tests := []vmTestCase{
+		{"map([1, 2, 3], fn(x) { x * 2 })", "[2, 4, 6]"},
+		{"map([[1], [1, 2]], len)", "[1, 2]"},
+		{"let offset = 10; map([1, 2], fn(x) { x + offset })", "[11, 12]"},
+		{"let f = fn(n) { map([n], fn(x) { x + n }) }; map([1, 2], f)", "[[2], [4]]"},
+		{"let count = fn(n, acc) { if (n == 0) { acc } else { count(n - 1, acc + 1) } }; map([2, 3], fn(n) { count(n, 0) })", "[2, 3]"},
+		{"filter([1, 2, 3, 4], fn(x) { x > 2 })", "[3, 4]"},
+		{"reduce([1, 2, 3], fn(acc, x) { acc + x })", 6},
+		{"reduce([1, 2, 3], fn(acc, x) { acc + x }, 10)", 16},
+		{"sort([3, 1, 2])", "[1, 2, 3]"},
+		{"sort([3, 1, 2], fn(a, b) { b - a })", "[3, 2, 1]"},
+		{"let total = reduce(map([1, 2], fn(x) { x * 3 }), fn(acc, x) { acc + x }); total + 1", 10},
+	}
+
+	runVmTests(t, tests)
+
+	errorTests := []vmErrorTestCase{
+		{"map([1], fn(x) { x + true })", "type mismatch: INTEGER + BOOLEAN"},
+		{"map([1], fn(x, y) { x })", "wrong number of arguments. got=1, want=2"},
+		{"map([1], 1)", "argument 2 to `map` must be FUNCTION. got=INTEGER"},
+		{"reduce([], fn(acc, x) { acc + x })", "'reduce' of an empty array with no initial value"},
+		{"sort([1, 2], fn(a, b) { true })", "comparator for `sort` must return INTEGER. got=BOOLEAN"},
+	}
+
+	runVmErrorTests(t, errorTests)
+}
+
+func TestSeededRandomIsReproducible(t *testing.T) {
+	input := `seed(7); [random_int(0, 1000), random_choice(range(100)), shuffle(range(10))]`
+
+	run := func(runtime *object.Runtime) string {
+		comp := compiler.New()
+		err := comp.Compile(parse(input))
+		if err != nil {
+			t.Fatalf("compiler error: %s", err)
+		}
+
+		vm := NewWithRuntime(comp.ByteCode(), runtime)
+		err = vm.Run()
+		if err != nil {
+			t.Fatalf("vm error: %s", err)
+		}
+
+		return vm.LastPoppedStackElem().Inspect()
+	}
+
+	first := run(object.NewRuntime())
+	if again := run(object.NewRuntime()); again != first {
+		t.Fatalf("seeded runs differ. first=%s, again=%s", first, again)
+	}
+}
+
+func TestOutputAndExit(t *testing.T) {
+	var stdout bytes.Buffer
+	runtime := object.NewRuntime()
+	runtime.Stdout = &stdout
+	runtime.Stdin = bufio.NewReader(strings.NewReader("monkey\n"))
+
+	comp := compiler.New()
+	err := comp.Compile(parse(`puts("Hello " + gets()); quit(4); puts("unreachable")`))
+	if err != nil {
+		t.Fatalf("compiler error: %s", err)
+	}
+
+	err = NewWithRuntime(comp.ByteCode(), runtime).Run()
+	if err != nil {
+		t.Fatalf("vm error: %s", err)
+	}
+
+	if stdout.String() != "Hello monkey\n" {
+		t.Errorf("wrong output. got=%q", stdout.String())
+	}
+
+	code, exited := runtime.ExitCode()
+	if !exited || code != 4 {
+		t.Errorf("wrong exit. got=(%d, %t), expected=(4, true)", code, exited)
+	}
+}
+
+func TestExitFromCallback(t *testing.T) {
+	var stdout bytes.Buffer
+	runtime := object.NewRuntime()
+	runtime.Stdout = &stdout
+
+	comp := compiler.New()
+	err := comp.Compile(parse(`map([4, 5], fn(code) { puts(code); quit(code) }); puts("unreachable")`))
+	if err != nil {
+		t.Fatalf("compiler error: %s", err)
+	}
+
+	err = NewWithRuntime(comp.ByteCode(), runtime).Run()
+	if err != nil {
+		t.Fatalf("vm error: %s", err)
+	}
+
+	if stdout.String() != "4\n" {
+		t.Errorf("wrong output. got=%q", stdout.String())
+	}
+
+	code, exited := runtime.ExitCode()
+	if !exited || code != 4 {
+		t.Errorf("wrong exit. got=(%d, %t), expected=(4, true)", code, exited)
+	}
+}
+
+func TestModules(t *testing.T) {
+	loader := object.MapLoader{
+		"util.mk":  `let secret = 40; export let answer = secret + 2; export let names = ["a", "b"]; puts("loading util");`,
+		"both.mk":  `import "util.mk" as util; export let sum = util.answer + len(util.names);`,
+		"a.mk":     `import "b.mk" as b;`,
+		"b.mk":     `import "a.mk" as a;`,
+		"error.mk": `export let x = 1 + true;`,
+	}
+
+	tests := []struct {
+		input          string
+		expected       string
+		expectedStdout string
+	}{
+		{`import "util.mk" as util; util.answer`, "42", "loading util\n"},
+		{`import "util.mk" as util; import "both.mk" as both; import "./util.mk" as again; [both.sum, again.names]`, "[44, [a, b]]", "loading util\n"},
+		{`let x = 1; import "util.mk" as util; let y = 2; [x, y, util]`, "[1, 2, module(util.mk)]", "loading util\n"},
+	}
+
+	for _, tt := range tests {
+		var stdout bytes.Buffer
+		runtime := object.NewRuntime()
+		runtime.Stdout = &stdout
+
+		comp := compiler.NewWithLoader(loader)
+		err := comp.Compile(parse(tt.input))
+		if err != nil {
+			t.Fatalf("compiler error: %s", err)
+		}
+
+		vm := NewWithRuntime(comp.ByteCode(), runtime)
+		err = vm.Run()
+		if err != nil {
+			t.Fatalf("vm error: %s", err)
+		}
+
+		if vm.LastPoppedStackElem().Inspect() != tt.expected {
+			t.Errorf("%s: wrong result. got=%s, expected=%s", tt.input, vm.LastPoppedStackElem().Inspect(), tt.expected)
+		}
+
+		if stdout.String() != tt.expectedStdout {
+			t.Errorf("%s: wrong output. got=%q, expected=%q", tt.input, stdout.String(), tt.expectedStdout)
+		}
+	}
+
+	compileErrors := []struct {
+		input    string
+		expected string
+	}{
+		{`import "a.mk" as a;`, "import cycle: a.mk -> b.mk -> a.mk"},
+		{`import "missing.mk" as m;`, `could not import "missing.mk": file does not exist`},
+	}
+
+	for _, tt := range compileErrors {
+		err := compiler.NewWithLoader(loader).Compile(parse(tt.input))
+		if err == nil || err.Error() != tt.expected {
+			t.Errorf("%s: wrong compiler error. got=%v, expected=%s", tt.input, err, tt.expected)
+		}
+	}
+
+	runtimeErrors := []struct {
+		input    string
+		expected string
+	}{
+		{`import "util.mk" as util; util.secret`, `module "util.mk" has no export "secret"`},
+		{`import "error.mk" as m;`, "type mismatch: INTEGER + BOOLEAN"},
+	}
+
+	for _, tt := range runtimeErrors {
+		comp := compiler.NewWithLoader(loader)
+		err := comp.Compile(parse(tt.input))
+		if err != nil {
+			t.Fatalf("compiler error: %s", err)
+		}
+
+		runtime := object.NewRuntime()
+		runtime.Stdout = &bytes.Buffer{}
+		err = NewWithRuntime(comp.ByteCode(), runtime).Run()
+		if err == nil || err.Error() != tt.expected {
+			t.Errorf("%s: wrong vm error. got=%v, expected=%s", tt.input, err, tt.expected)
+		}
+	}
+}
+
+func TestCallingFunctions(t *testing.T) {
+	tests := []vmTestCase{
+		{"let five = fn() { 5 }; five()", 5},
+		{"let add = fn(a, b) { a + b }; add(1, 2)", 3},
+		{"fn(a) { let b = a * 2; b + 1 }(4)", 9},
+		{"let early = fn() { return 1; 2 }; early()", 1},
+		{"let nothing = fn() { }; nothing()", &object.Null{}},
+		{"let one = fn() { let x = 1; x }; let two = fn() { let x = 2; x }; one() + two()", 3},
+		{"let twice = fn(f, x) { f(f(x)) }; twice(fn(x) { x * 2 }, 3)", 12},
+		{"let fib = fn(n) { if (n < 2) { n } else { fib(n - 1) + fib(n - 2) } }; fib(15)", 610},
+		{"is_function(fn() { 1 })", true},
+		{"fn_arity(fn(a, b, c) { a })", 3},
+		{"fn_params(fn(left, right) { left })", "[left, right]"},
+		{"fn_source(fn(x, y) { x + y })", "fn(x, y) { (x + y) }"},
+		{"let offset = 1; let add = fn(x) { x + offset }; fn_params(add)", "[x]"},
+		{"return 1; 2", 1},
+	}
+
+	runVmTests(t, tests)
+}
+
+func TestClosures(t *testing.T) {
+	tests := []vmTestCase{
+		{"let counter = fn() { let n = 0; fn() { n = n + 1 } }; let c = counter(); c(); c(); c()", 3},
+		{"let counter = fn() { let n = 0; fn() { n = n + 1 } }; let a = counter(); let b = counter(); a(); a(); b()", 1},
+		{"let pair = fn() { let n = 0; [fn() { n = n + 1 }, fn() { n }] }; let p = pair(); p[0](); p[0](); p[1]()", 2},
+		{"let outer = fn() { let x = 1; let f = fn() { x }; x = 2; f }; outer()()", 2},
+		{"let outer = fn() { let x = 1; let f = fn() { x }; let x = 2; f() }; outer()", 2},
+		{"let x = 1; let f = fn() { x }; let x = 2; f()", 2},
+		{"let total = 0; let add = fn(x) { total = total + x }; add(2); add(3); total", 5},
+		{"let a = fn(x) { fn() { fn() { x = x + 1 } } }; let inc = a(1)(); inc(); inc()", 3},
+		{"let f = fn() { let fact = fn(n) { if (n < 2) { 1 } else { n * fact(n - 1) } }; fact(5) }; f()", 120},
+		{"let a = 1; let b = a = 5; a + b", 10},
+		{"let i = 0; let sum = 0; while (i < 5) { sum = sum + i; i = i + 1 }; sum", 10},
+		{"while (false) { 1 }", &object.Null{}},
+		{"let f = fn() { let i = 0; while (true) { if (i == 3) { return i; }; i = i + 1 } }; f()", 3},
+		{`
+		let make = fn() {
+			let fns = [];
+			let i = 0;
+			while (i < 3) {
+				let j = i;
+				fns = push(fns, fn() { j });
+				i = i + 1;
+			}
+			fns
+		};
+		let fns = make();
+		fns[0]() + fns[1]() + fns[2]()
+		`, 6},
+		{`
+		let make = fn() {
+			let fns = [];
+			let i = 0;
+			while (i < 3) {
+				fns = push(fns, fn(j) { fn() { j } }(i));
+				i = i + 1;
+			}
+			fns
+		};
+		let fns = make();
+		fns[0]() + fns[1]() + fns[2]()
+		`, 3},
+	}
+
+	runVmTests(t, tests)
+}
+
+func TestTailCalls(t *testing.T) {
+	tests := []vmTestCase{
+		{"let count = fn(n) { if (n == 0) { 0 } else { count(n - 1) } }; count(1000000)", 0},
+		{"let sum = fn(n, acc) { if (n == 0) { return acc; }; return sum(n - 1, acc + n); }; sum(1000000, 0)", 500000500000},
+		{"let loop = fn(n) { if (n > 0) { loop(n - 1) } }; loop(1000000)", &object.Null{}},
+		{"let last = fn(array) { if (len(array) == 1) { first(array) } else { last(rest(array)) } }; last([1, 2, 3])", 3},
+		{"let f = fn(n, acc) { if (n == 0) { acc } else { let g = fn() { n }; f(n - 1, push(acc, g)) } }; let fs = f(3, []); fs[0]() + fs[1]() + fs[2]()", 6},
+		{"let twice = fn(x) { x * 2 }; let apply = fn(f, x) { f(x) }; apply(twice, 4) + 1", 9},
+		{"let inner = fn() { len([1]) }; inner()", 1},
+		{"let even = fn(n) { if (n == 0) { true } else { odd(n - 1) } }; let odd = fn(n) { if (n == 0) { false } else { even(n - 1) } }; even(1000001)", false},
+	}
+
+	runVmTests(t, tests)
+}
+
+func TestCallDepth(t *testing.T) {
+	tests := []struct {
+		input    string
+		limit    int
+		expected string
+	}{
+		{"let f = fn(n) { f(n + 1) + 1 }; f(0)", 10000, "maximum call depth exceeded. limit=10000, most recent calls: f, f, f, f, f"},
+		{"let f = fn(n) { if (n == 0) { 0 } else { f(n - 1) + 1 } }; f(3)", 3, "maximum call depth exceeded. limit=3, most recent calls: f, f, f, f"},
+		{"let g = fn() { fn() { 1 + 1 }() + 1 }; let f = fn() { g() + 1 }; f()", 2, "maximum call depth exceeded. limit=2, most recent calls: anonymous, g, f"},
+	}
+
+	for _, tt := range tests {
+		comp := compiler.New()
+		err := comp.Compile(parse(tt.input))
+		if err != nil {
+			t.Fatalf("compiler error: %s", err)
+		}
+
+		runtime := object.NewRuntime()
+		runtime.MaxCallDepth = tt.limit
+
+		err = NewWithRuntime(comp.ByteCode(), runtime).Run()
+		if err == nil || err.Error() != tt.expected {
+			t.Errorf("%s: wrong vm error. got=%v, want=%q", tt.input, err, tt.expected)
+		}
+	}
+}
+
+func TestLimits(t *testing.T) {
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := []struct {
+		input          string
+		ctx            context.Context
+		maxSteps       int64
+		maxAllocations int64
+		expected       error
+	}{
+		{"let x = 0; while (x < 10) { x = x + 1 }; x", context.Background(), 1000, 0, nil},
+		{"while (true) { 1 }", context.Background(), 1000, 0, object.ErrStepLimitExceeded},
+		{"let f = fn() { f() }; f()", context.Background(), 1000, 0, object.ErrStepLimitExceeded},
+		{"map([1], fn(x) { while (true) { x } })", context.Background(), 1000, 0, object.ErrStepLimitExceeded},
+		{`let s = ""; while (true) { s = s + "a" }`, context.Background(), 0, 100, object.ErrAllocationLimitExceeded},
+		{"let a = []; while (true) { a = push(a, 1) }", context.Background(), 0, 100, object.ErrAllocationLimitExceeded},
+		{"while (true) { {} }", context.Background(), 0, 100, object.ErrAllocationLimitExceeded},
+		{"while (true) { 1 }", cancelled, 0, 0, context.Canceled},
+	}
+
+	for _, tt := range tests {
+		comp := compiler.New()
+		err := comp.Compile(parse(tt.input))
+		if err != nil {
+			t.Fatalf("compiler error: %s", err)
+		}
+
+		runtime := object.NewRuntime()
+		runtime.MaxSteps = tt.maxSteps
+		runtime.MaxAllocations = tt.maxAllocations
+
+		err = NewWithRuntime(comp.ByteCode(), runtime).RunContext(tt.ctx)
+		if tt.expected == nil && err != nil {
+			t.Errorf("%s: unexpected vm error. got=%q", tt.input, err)
+		}
+		if tt.expected != nil && !errors.Is(err, tt.expected) {
+			t.Errorf("%s: wrong vm error. got=%v, want=%v", tt.input, err, tt.expected)
+		}
+	}
+
+	comp := compiler.New()
+	if err := comp.Compile(parse("while (true) { 1 }")); err != nil {
+		t.Fatalf("compiler error: %s", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	err := New(comp.ByteCode()).RunContext(ctx)
+	if err == nil || err.Error() != "program interrupted: context deadline exceeded" {
+		t.Errorf("wrong vm error. got=%v", err)
+	}
+}
+
+func TestMemory(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{`"ab" + "cd"`, 36},
+		{"[1, 2]", 56},
+		{`{"a": 1}`, 144},
+		{`let name = "Monkey"; "Hello ${name}!"`, 45},
+		{"push([1], 2)", 96},
+		{"let a = [1]; let b = a; b", 40},
+		{"let f = fn(x) { x * 2 }; f(2)", 0},
+		{`entries({"a": 1})`, 240},
+		{`split("a b", " ")`, 122},
+		{`json_parse("[[1]]")`, 80},
+	}
+
+	for _, tt := range tests {
+		comp := compiler.New()
+		err := comp.Compile(parse(tt.input))
+		if err != nil {
+			t.Fatalf("compiler error: %s", err)
+		}
+
+		runtime := object.NewRuntime()
+		err = NewWithRuntime(comp.ByteCode(), runtime).Run()
+		if err != nil {
+			t.Fatalf("%s: vm error: %s", tt.input, err)
+		}
+
+		if runtime.Memory() != tt.expected {
+			t.Errorf("%s: wrong memory. got=%d, want=%d", tt.input, runtime.Memory(), tt.expected)
+		}
+	}
+
+	// Programs that allocate without bound
+	limits := []string{
+		`let s = "a"; while (true) { s = s + s }`,
+		"let a = []; while (true) { a = push(a, 1) }",
+		`while (true) { {"a": 1} }`,
+	}
+
+	for _, input := range limits {
+		comp := compiler.New()
+		err := comp.Compile(parse(input))
+		if err != nil {
+			t.Fatalf("compiler error: %s", err)
+		}
+
+		runtime := object.NewRuntime()
+		runtime.MaxMemory = 1 << 20
+
+		err = NewWithRuntime(comp.ByteCode(), runtime).Run()
+		if !errors.Is(err, object.ErrMemoryLimitExceeded) || err.Error() != "memory limit exceeded. limit=1048576" {
+			t.Errorf("%s: wrong vm error. got=%v, want=%v", input, err, object.ErrMemoryLimitExceeded)
+		}
+	}
+
+	// Builtins that would create a result past the limit are stopped before they create it
+	builtins := []string{
+		"range(0, 300000000)",
+		`repeat("ab", 300000000)`,
+		"let a = range(0, 50000); concat(a, a)",
+		"flatten([range(0, 40000), range(0, 40000)])",
+		"zip(range(0, 20000), range(0, 20000))",
+		`join(range(0, 40000), repeat(" ", 20))`,
+		`split(repeat("a", 40000), "")`,
+		`json_parse("[" + repeat("[],", 40000) + "[]]")`,
+	}
+
+	for _, input := range builtins {
+		comp := compiler.New()
+		err := comp.Compile(parse(input))
+		if err != nil {
+			t.Fatalf("compiler error: %s", err)
+		}
+
+		runtime := object.NewRuntime()
+		runtime.MaxMemory = 1 << 20
+
+		err = NewWithRuntime(comp.ByteCode(), runtime).Run()
+		if !errors.Is(err, object.ErrMemoryLimitExceeded) || err.Error() != "memory limit exceeded. limit=1048576" {
+			t.Errorf("%s: wrong vm error. got=%v, want=%v", input, err, object.ErrMemoryLimitExceeded)
+		}
+
+		if runtime.Memory() > runtime.MaxMemory {
+			t.Errorf("%s: allocated past the limit. got=%d", input, runtime.Memory())
+		}
+	}
+}
+
+func TestRuntimeErrors(t *testing.T) {
+	tests := []vmErrorTestCase{
+		{"5 + true", "type mismatch: INTEGER + BOOLEAN"},
+		{"true + false", "unknown operator: BOOLEAN + BOOLEAN"},
+		{"1.5 + true", "type mismatch: FLOAT + BOOLEAN"},
+		{"math.abs(true)", "argument to `abs` must be INTEGER or FLOAT. got=BOOLEAN"},
+		{"math.pow(2, 64)", "integer overflow: pow(2, 64)"},
+		{"json_stringify(fn(x) { x })", "could not convert to JSON: unsupported type FUNCTION"},
+		{"fn() {} + 1", "type mismatch: FUNCTION + INTEGER"},
+		{`{"a": 1}[fn(x) { x }]`, "unhashable key: FUNCTION"},
+		{"math.floor(100000000000000000000000.5)", "cannot convert 1.0000000000000001e+23 to an integer"},
+		{"1.abs", "index operator not supported: INTEGER"},
+		{`"a" - "b"`, "unknown operator: STRING - STRING"},
+		{"-true", "unknown operator: -BOOLEAN"},
+		{"1[0]", "index operator not supported: INTEGER"},
+		{`{[]: 1}`, "unhashable key: ARRAY"},
+		{"1()", "not a function: INTEGER"},
+		{"len(1)", "argument to `len` not supported. got=INTEGER"},
+		{`to_int("a")`, `could not convert "a" to an integer`},
+		{"fn(a) { a }()", "wrong number of arguments. got=0, want=1"},
+		{"fn_arity(len)", "'fn_arity' only accepts a function defined in Monkey as an argument. got=BUILTIN"},
+		{"let even = fn(n) { odd(n) }; even(1); let odd = fn(n) { n }", "variable used before it is defined"},
+		{"let f = fn() { y }; f(); let y = 2", "variable used before it is defined"},
+		{"1 / 0", "division by zero"},
+		{"let min = -9223372036854775807 - 1; min / -1", "integer overflow: -9223372036854775808 / -1"},
+	}
+
+	runVmErrorTests(t, tests)
+}
+
 func runVmTests(t *testing.T, tests []vmTestCase) {
 	t.Helper()
 
@@ -53,7 +662,7 @@ func runVmTests(t *testing.T, tests []vmTestCase) {
 			t.Fatalf("vm error: %s", err)
 		}
 
-		stackElem := vm.lastPoppedStackElem()
+		stackElem := vm.LastPoppedStackElem()
 
 		testExpectedObject(t, test.expected, stackElem)
 	}
@@ -72,6 +681,46 @@ func testExpectedObject(
 		if err != nil {
 			t.Errorf("testIntegerObject failed: %s", err)
 		}
+	case bool:
+		if actual != nativeBoolToBooleanObject(expected) {
+			t.Errorf("object is not %t. got=%T (%+v)", expected, actual, actual)
+		}
+	case *object.Null:
+		if actual != NULL {
+			t.Errorf("object is not NULL. got=%T (%+v)", actual, actual)
+		}
+	case string:
+		// Strings are compared against the inspected object so arrays and hashes can be tested too
+		if actual == nil || actual.Inspect() != expected {
+			t.Errorf("object has wrong value. got=%v, want=%s", actual, expected)
+		}
+	}
+}
+
+type vmErrorTestCase struct {
+	input    string
+	expected string
+}
+
+func runVmErrorTests(t *testing.T, tests []vmErrorTestCase) {
+	t.Helper()
+
+	for _, test := range tests {
+		comp := compiler.New()
+		err := comp.Compile(parse(test.input))
+		if err != nil {
+			t.Fatalf("compiler error: %s", err)
+		}
+
+		err = New(comp.ByteCode()).Run()
+		if err == nil {
+			t.Errorf("%s: expected vm error %q", test.input, test.expected)
+			continue
+		}
+
+		if err.Error() != test.expected {
+			t.Errorf("%s: wrong vm error. got=%q, want=%q", test.input, err, test.expected)
+		}
 	}
 }
 func parse(input string) *ast.Program {